@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/samber/lo"
+	"github.com/spf13/cobra"
+	"github.com/vpnda/wsfetch/pkg/client"
+	"github.com/vpnda/wsfetch/pkg/client/generated"
+	"github.com/vpnda/wsfetch/pkg/fx"
+	"github.com/vpnda/wsfetch/pkg/output"
+)
+
+// accountsCmd represents the accounts command
+var accountsCmd = &cobra.Command{
+	Use:   "accounts",
+	Short: "Lists the accounts of one or more profiles.",
+	Long: `Lists the accounts of the selected profile along with their net liquidation
+value. With --profiles or --all-profiles the accounts of every selected
+profile are listed, each row showing the profile it belongs to.
+
+Values in another currency than --reporting-currency are also converted to
+it, at the rates of the conversions made over the lookback days of the
+profile, or at --reference-rate, eg --reference-rate USD/CAD=1.3650.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+		names, err := selectedProfiles(cmd)
+		if err != nil {
+			return err
+		}
+		reporting, _ := cmd.Flags().GetString("reporting-currency")
+		reporting = strings.ToUpper(reporting)
+
+		start := now()
+		var records []output.Account
+		for _, name := range names {
+			c, err := clientForProfile(ctx, name)
+			if err != nil {
+				return fmt.Errorf("profile %s: %w", name, err)
+			}
+			accounts, err := c.GetAccounts(ctx)
+			if err != nil {
+				return fmt.Errorf("profile %s: %w", name, err)
+			}
+
+			var fetched []output.Account
+			for _, account := range accounts {
+				fetched = append(fetched, output.NewAccount(name, &account))
+			}
+			if lo.SomeBy(fetched, func(r output.Account) bool { return r.Currency != "" && r.Currency != reporting }) {
+				rates, err := accountRates(ctx, cmd, name, c, accounts)
+				if err != nil {
+					return fmt.Errorf("profile %s: %w", name, err)
+				}
+				for i := range fetched {
+					if err := fetched[i].Convert(rates, reporting, start); err != nil {
+						logger.Sugar().Infow("Value not converted", "account", fetched[i].Id, "error", err)
+					}
+				}
+			}
+			records = append(records, fetched...)
+		}
+		return render(records)
+	},
+}
+
+// accountRates returns the rates of the conversions the accounts made
+// over the lookback days of the profile, along with --reference-rate
+func accountRates(ctx context.Context, cmd *cobra.Command, name string, c client.Client, accounts []generated.AccountWithFinancials) (*fx.Book, error) {
+	rates, err := referenceRates(cmd)
+	if err != nil {
+		return nil, err
+	}
+	store, err := profileStore()
+	if err != nil {
+		return nil, err
+	}
+	p, err := store.Load(name)
+	if err != nil {
+		return nil, err
+	}
+	from, to, err := window(cmd, p, now())
+	if err != nil {
+		return nil, err
+	}
+	ids := lo.Map(accounts, func(a generated.AccountWithFinancials, _ int) client.AccountId { return client.AccountId(a.Id) })
+	activities, err := c.GetActivities(ctx, ids, &from, &to)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range ids {
+		for i := range activities[id] {
+			rates.Record(&activities[id][i])
+		}
+	}
+	return rates, nil
+}
+
+func init() {
+	rootCmd.AddCommand(accountsCmd)
+
+	addAggregateFlags(accountsCmd)
+	accountsCmd.Flags().String("reporting-currency", fx.DefaultReportingCurrency,
+		"Currency values in other currencies are converted to, at the rates of the conversions made")
+	accountsCmd.Flags().StringSlice("reference-rate", nil, "Rate used when no conversion of the pair was made, eg USD/CAD=1.3650")
+}
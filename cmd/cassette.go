@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/vpnda/wsfetch/pkg/auth/types"
+	"github.com/vpnda/wsfetch/pkg/cassette"
+)
+
+var (
+	// recordDir and replayDir are given with --record and --replay
+	recordDir string
+	replayDir string
+
+	// replayer answers every request when replaying
+	replayer http.RoundTripper
+
+	// recorder records the requests of every client when recording
+	recorder *cassette.Recorder
+)
+
+// configureCassette loads the interactions to replay, if any, or sets
+// up the recorder every client records through
+func configureCassette() error {
+	if recordDir != "" {
+		recorder = cassette.NewRecorder(recordDir)
+	}
+	if replayDir == "" {
+		return nil
+	}
+	rt, err := cassette.NewReplayer(replayDir)
+	if err != nil {
+		return err
+	}
+	replayer = rt
+	return nil
+}
+
+// replaying reports whether requests are answered from recordings,
+// in that case no credentials are needed
+func replaying() bool {
+	return replayer != nil
+}
+
+// replaySession is the session used when replaying, it is never
+// sent to Wealthsimple
+func replaySession() types.Session {
+	expiry := time.Now().Add(24 * time.Hour)
+	return types.Session{AccessToken: "replay", Expiry: &expiry}
+}
+
+// transport sends the requests of every client the CLI creates
+func transport() http.RoundTripper {
+	if replaying() {
+		return replayer
+	}
+	return http.DefaultTransport
+}
+
+func init() {
+	rootCmd.PersistentFlags().StringVar(&recordDir, "record", "",
+		"Record every HTTP interaction, with secrets scrubbed, to this directory")
+	rootCmd.PersistentFlags().StringVar(&replayDir, "replay", "",
+		"Answer every HTTP request from the interactions recorded in this directory, no credentials needed")
+	rootCmd.MarkFlagsMutuallyExclusive("record", "replay")
+}
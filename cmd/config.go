@@ -0,0 +1,181 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/vpnda/wsfetch/pkg/config"
+	"golang.org/x/text/language"
+)
+
+var (
+	// configPath is the configuration file given with --config
+	configPath string
+
+	// cfg is the configuration file with the WSFETCH_* environment
+	// variables applied, flags take precedence over it
+	cfg = &config.Config{}
+
+	// location is the timezone dates are read and printed in
+	location = time.Local
+
+	// locale is the locale tables print numbers in
+	locale = language.Und
+)
+
+// resolveConfigPath returns the configuration file in use
+func resolveConfigPath() (string, error) {
+	if configPath != "" {
+		return configPath, nil
+	}
+	return config.DefaultPath()
+}
+
+// configure loads the configuration and applies it to the flags that
+// weren't given on the command line
+func configure(cmd *cobra.Command) error {
+	path, err := resolveConfigPath()
+	if err != nil {
+		return err
+	}
+	c, err := config.Load(path)
+	if err != nil {
+		return err
+	}
+	if err := c.ApplyEnv(os.Environ()); err != nil {
+		return err
+	}
+	cfg = c
+
+	flags := cmd.Flags()
+	if c.Output != "" && !flags.Changed("output") {
+		outputFlag = c.Output
+	}
+	if c.Profile != "" && !flags.Changed("profile") {
+		profileName = c.Profile
+	}
+	if location, err = c.Location(); err != nil {
+		return err
+	}
+	if locale, err = c.Language(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// now is the current time in the configured timezone
+func now() time.Time {
+	return time.Now().In(location)
+}
+
+// configCmd represents the config command
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Reads and writes the configuration file.",
+	Long: `Reads and writes the configuration file, $XDG_CONFIG_HOME/wsfetch/config.yaml
+unless --config or ` + config.PathEnv + ` point to another one.
+
+Every key can be overridden with an environment variable, flags overriding
+both. Account aliases are set with aliases.NAME keys, --account NAME then
+standing for the comma separated selectors of the alias.
+
+Keys:
+` + keysHelp(),
+	// the configuration commands must work with an invalid
+	// configuration, to fix it
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error { return nil },
+}
+
+func keysHelp() string {
+	var b strings.Builder
+	tw := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	for _, k := range config.Keys() {
+		fmt.Fprintf(tw, "  %s\t%s\t%s\n", k.Name, k.Env, k.Help)
+	}
+	_ = tw.Flush()
+	return b.String()
+}
+
+var configPathCmd = &cobra.Command{
+	Use:   "path",
+	Short: "Prints the path of the configuration file.",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path, err := resolveConfigPath()
+		if err != nil {
+			return err
+		}
+		fmt.Println(path)
+		return nil
+	},
+}
+
+var configGetCmd = &cobra.Command{
+	Use:   "get [KEY]",
+	Short: "Prints the value of a key, or of every key set.",
+	Long: `Prints the value of a key, or of every key set when none is given. Values
+are the ones of the configuration file, unless --env is given, in which case
+the WSFETCH_* environment variables are applied.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path, err := resolveConfigPath()
+		if err != nil {
+			return err
+		}
+		c, err := config.Load(path)
+		if err != nil {
+			return err
+		}
+		if env, _ := cmd.Flags().GetBool("env"); env {
+			if err := c.ApplyEnv(os.Environ()); err != nil {
+				return err
+			}
+		}
+
+		if len(args) == 1 {
+			value, err := c.Get(args[0])
+			if err != nil {
+				return err
+			}
+			fmt.Println(value)
+			return nil
+		}
+		for _, kv := range c.Values() {
+			fmt.Printf("%s=%s\n", kv[0], kv[1])
+		}
+		return nil
+	},
+}
+
+var configSetCmd = &cobra.Command{
+	Use:   "set KEY VALUE",
+	Short: "Sets the value of a key, an empty value unsets it.",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path, err := resolveConfigPath()
+		if err != nil {
+			return err
+		}
+		c, err := config.Load(path)
+		if err != nil {
+			return err
+		}
+		if err := c.Set(args[0], args[1]); err != nil {
+			return err
+		}
+		return c.Save(path)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(configCmd)
+	configCmd.AddCommand(configPathCmd, configGetCmd, configSetCmd)
+	configGetCmd.Flags().Bool("env", false, "Apply the "+config.EnvPrefix+"* environment variables")
+
+	rootCmd.PersistentFlags().StringVar(&configPath, "config", "",
+		"Configuration file (default $XDG_CONFIG_HOME/wsfetch/config.yaml, env "+config.PathEnv+")")
+}
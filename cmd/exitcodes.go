@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"errors"
+
+	"github.com/vpnda/wsfetch/pkg/auth/authenticator"
+	"github.com/vpnda/wsfetch/pkg/auth/creds"
+	"github.com/vpnda/wsfetch/pkg/base"
+	"github.com/vpnda/wsfetch/pkg/cassette"
+	"github.com/vpnda/wsfetch/pkg/client"
+	"github.com/vpnda/wsfetch/pkg/profile"
+)
+
+// Exit codes returned by wsfetch, scripts can rely on them
+// to decide whether to retry or ask the user to log in again
+const (
+	exitError             = 1
+	exitReauthenticate    = 3
+	exitInsufficientScope = 4
+	exitRetryLater        = 5
+	exitAPIError          = 6
+)
+
+// exitCode maps err to the exit code of the process
+func exitCode(err error) int {
+	var (
+		scopeErr   *client.InsufficientScopeError
+		networkErr *base.NetworkError
+		graphqlErr *client.GraphQLError
+		statusErr  *base.StatusError
+	)
+	switch {
+	case errors.Is(err, cassette.ErrNoInteraction):
+		return exitError
+	case errors.As(err, &scopeErr):
+		return exitInsufficientScope
+	case errors.Is(err, base.ErrRateLimited),
+		errors.Is(err, base.ErrServerError),
+		errors.As(err, &networkErr):
+		return exitRetryLater
+	case errors.Is(err, authenticator.ErrInvalidCredentials),
+		errors.Is(err, authenticator.ErrTwoFactorRequired),
+		errors.Is(err, authenticator.ErrInvalidTwoFactorCode),
+		errors.Is(err, authenticator.ErrRefreshTokenExpired),
+		errors.Is(err, creds.ErrSessionExpired),
+		errors.Is(err, base.ErrUnauthorized),
+		errors.Is(err, profile.ErrNoSession):
+		return exitReauthenticate
+	case errors.As(err, &graphqlErr),
+		errors.As(err, &statusErr),
+		errors.Is(err, base.ErrUnexpectedResponse):
+		return exitAPIError
+	default:
+		return exitError
+	}
+}
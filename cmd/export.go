@@ -0,0 +1,298 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/samber/lo"
+	"github.com/spf13/cobra"
+	"github.com/vpnda/wsfetch/pkg/client"
+	"github.com/vpnda/wsfetch/pkg/client/generated"
+	"github.com/vpnda/wsfetch/pkg/fx"
+	"github.com/vpnda/wsfetch/pkg/ledger"
+	"github.com/vpnda/wsfetch/pkg/ofx"
+)
+
+// exportCmd represents the export command
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Exports activities in the formats of personal finance software.",
+	Long: `Exports the activities of the selected accounts and profiles, between --from
+and --to, to stdout in a format personal finance software imports: OFX for
+GnuCash, Moneydance and Quicken, beancount, or ledger for ledger and hledger.`,
+}
+
+var exportOfxCmd = &cobra.Command{
+	Use:   "ofx",
+	Short: "Exports activities as OFX statements for GnuCash, Moneydance or Quicken.",
+	Long: `Exports activities as an OFX 2.2 file. Cash accounts are written as bank
+statements, other accounts as investment statements with their trades,
+dividends, cash movements and positions, along with the list of the
+securities they trade and hold.
+
+Activities are identified by their canonical id, so importing overlapping
+exports doesn't duplicate them. Quicken imports QFX files, OFX files with the
+Intuit id of the bank, given with --intu-bid.
+
+Amounts in another currency than the one of their account are written with
+the rate of the conversions fetched, or of --reference-rate, eg
+--reference-rate USD/CAD=1.3650, when none was made.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+		exported, rates, err := fetchExport(ctx, cmd)
+		if err != nil {
+			return err
+		}
+
+		var statements []ofx.Statement
+		securities := map[string]*generated.SecurityMarketData{}
+		for _, e := range exported {
+			s := ofx.Statement{Account: e.account, Start: e.from, End: e.to}
+			for i, act := range e.activities {
+				s.Transactions = append(s.Transactions, ofx.Transaction{Activity: act, Description: e.descriptions[i]})
+			}
+			for _, id := range ofx.SecurityIds([]ofx.Statement{s}) {
+				if _, ok := securities[id]; ok {
+					continue
+				}
+				// securities Wealthsimple no longer knows are
+				// listed without market data
+				data, err := e.client.GetSecurityMarketData(ctx, id)
+				var gqlErr *client.GraphQLError
+				if errors.As(err, &gqlErr) {
+					logger.Sugar().Infow("Security market data not found", "security", id, "error", err)
+				} else if err != nil {
+					return fmt.Errorf("profile %s: %w", e.profile, err)
+				}
+				securities[id] = data
+			}
+			statements = append(statements, s)
+		}
+
+		intuBid, _ := cmd.Flags().GetString("intu-bid")
+		err = ofx.Write(os.Stdout, statements, securities, ofx.Options{
+			Now:      now(),
+			Location: location,
+			IntuBid:  intuBid,
+			Rates:    rates,
+		})
+		if errors.Is(err, fx.ErrNoRate) {
+			return fmt.Errorf("%w, give one with --reference-rate", err)
+		}
+		return err
+	},
+}
+
+var exportBeancountCmd = &cobra.Command{
+	Use:   "beancount",
+	Short: "Exports activities as beancount transactions.",
+	Long: `Exports activities as beancount transactions, preceded by the open directives
+of the accounts they use. Trades are lots held at cost, sales being booked
+first in, first out with the gain going to the capital-gains account.
+` + ledgerHelp,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return exportLedger(cmd, ledger.Beancount)
+	},
+}
+
+var exportLedgerCmd = &cobra.Command{
+	Use:   "ledger",
+	Short: "Exports activities as ledger transactions, which hledger also reads.",
+	Long: `Exports activities as ledger transactions, which hledger also reads. Trades
+bought are lots held at their cost. Sales are written at the price obtained,
+without the cost of the lots sold, so capital gains aren't tracked: export
+to beancount to track them.
+` + ledgerHelp,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return exportLedger(cmd, ledger.Ledger)
+	},
+}
+
+// ledgerHelp documents the account mapping and appends of the
+// plain-text accounting exports
+var ledgerHelp = `
+Wealthsimple accounts map to Assets:Wealthsimple:NICKNAME, or their ID when
+they have no nickname. Dividends, interest, withholding taxes and other
+activities are balanced against the accounts of these roles:
+
+` + rolesHelp() + `
+Accounts and roles are mapped to other accounts with --map, eg
+--map Savings=Assets:TFSA --map external=Assets:Bank:Chequing, or with the
+ledgerAccounts.NAME configuration keys.
+
+Every transaction carries the canonical id of its activity. With --append
+the transactions are appended to a journal, skipping those it already has
+and the accounts it already opens, so exports can be appended repeatedly.
+Transfers between Wealthsimple accounts also carry a transfer key, so one
+written from either account isn't written again from the other.`
+
+func rolesHelp() string {
+	var b strings.Builder
+	for _, r := range ledger.Roles {
+		fmt.Fprintf(&b, "  %-16s %s\n", r[0], r[1])
+	}
+	return b.String()
+}
+
+// exportLedger writes the activities of the selected accounts as
+// plain-text accounting transactions
+func exportLedger(cmd *cobra.Command, format ledger.Format) error {
+	ctx := context.Background()
+	names := map[string]string{}
+	for name, account := range cfg.LedgerAccounts {
+		names[name] = account
+	}
+	maps, _ := cmd.Flags().GetStringArray("map")
+	for _, m := range maps {
+		name, account, ok := strings.Cut(m, "=")
+		if !ok || name == "" || account == "" {
+			return fmt.Errorf("invalid --map %q, expected NAME=ACCOUNT", m)
+		}
+		names[strings.ToLower(name)] = account
+	}
+	appendTo, _ := cmd.Flags().GetString("append")
+
+	exported, _, err := fetchExport(ctx, cmd)
+	if err != nil {
+		return err
+	}
+
+	mapping := ledger.Mapping{Names: names, Accounts: map[string]*generated.AccountWithFinancials{}}
+	var accounts []ledger.Account
+	for _, e := range exported {
+		a := ledger.Account{Account: e.account}
+		mapping.Accounts[e.account.Id] = &a.Account
+		for i, act := range e.activities {
+			a.Transactions = append(a.Transactions, ledger.Transaction{Activity: act, Description: e.descriptions[i]})
+			// opposing accounts of transfers are named after
+			// their nickname too
+			if id := lo.FromPtr(act.OpposingAccountId); id != "" && mapping.Accounts[id] == nil {
+				if opposing, err := e.client.GetAccount(ctx, id); err == nil {
+					mapping.Accounts[id] = opposing
+				}
+			}
+		}
+		accounts = append(accounts, a)
+	}
+
+	opts := ledger.Options{Location: location}
+	if appendTo == "" {
+		return ledger.Write(os.Stdout, format, accounts, mapping, opts)
+	}
+	f, err := os.OpenFile(appendTo, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if opts.Existing, err = ledger.ReadExisting(f); err != nil {
+		return fmt.Errorf("unable to read %s: %w", appendTo, err)
+	}
+	if _, err := f.Seek(0, io.SeekEnd); err != nil {
+		return err
+	}
+	if err := ledger.Write(f, format, accounts, mapping, opts); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// exportedAccount is an account along with the activities fetched
+// for an export
+type exportedAccount struct {
+	profile  string
+	client   client.Client
+	account  generated.AccountWithFinancials
+	from, to time.Time
+
+	activities   []generated.Activity
+	descriptions []string
+}
+
+// fetchExport returns the activities of the selected accounts of the
+// selected profiles, along with the rates of the conversions they made
+// and of --reference-rate
+func fetchExport(ctx context.Context, cmd *cobra.Command) ([]exportedAccount, *fx.Book, error) {
+	names, err := selectedProfiles(cmd)
+	if err != nil {
+		return nil, nil, err
+	}
+	rates, err := referenceRates(cmd)
+	if err != nil {
+		return nil, nil, err
+	}
+	store, err := profileStore()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	start := now()
+	var exported []exportedAccount
+	for _, name := range names {
+		c, err := clientForProfile(ctx, name)
+		if err != nil {
+			return nil, nil, fmt.Errorf("profile %s: %w", name, err)
+		}
+		p, err := store.Load(name)
+		if err != nil {
+			return nil, nil, err
+		}
+		from, to, err := window(cmd, p, start)
+		if err != nil {
+			return nil, nil, err
+		}
+		accounts, err := selectedAccounts(ctx, cmd, c, p)
+		if err != nil {
+			return nil, nil, fmt.Errorf("profile %s: %w", name, err)
+		}
+		ids := lo.Map(accounts, func(a generated.AccountWithFinancials, _ int) client.AccountId { return client.AccountId(a.Id) })
+		activities, err := c.GetActivities(ctx, ids, &from, &to)
+		if errors.Is(err, client.ErrIncompleteList) {
+			// importers trust the range of an export to be complete
+			return nil, nil, fmt.Errorf("profile %s: not exporting %s to %s, some activities could not be fetched: %w",
+				name, from.Format(time.DateOnly), to.Format(time.DateOnly), err)
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("profile %s: %w", name, err)
+		}
+
+		for _, account := range accounts {
+			e := exportedAccount{profile: name, client: c, account: account, from: from, to: to}
+			for _, act := range activities[client.AccountId(account.Id)] {
+				rates.Record(&act)
+				desc, err := client.GetActivityDescription(ctx, c, &act)
+				if err != nil {
+					return nil, nil, fmt.Errorf("profile %s: %w", name, err)
+				}
+				e.activities = append(e.activities, act)
+				e.descriptions = append(e.descriptions, desc)
+			}
+			exported = append(exported, e)
+		}
+	}
+	return exported, rates, nil
+}
+
+func init() {
+	rootCmd.AddCommand(exportCmd)
+	exportCmd.AddCommand(exportOfxCmd, exportBeancountCmd, exportLedgerCmd)
+
+	for _, c := range exportCmd.Commands() {
+		addAggregateFlags(c)
+		addWindowFlags(c)
+		addAccountFlags(c)
+	}
+	exportOfxCmd.Flags().StringSlice("reference-rate", nil, "Rate used when no conversion of the pair was fetched, eg USD/CAD=1.3650")
+	exportOfxCmd.Flags().String("intu-bid", "", "Intuit id of the bank written for Quicken, making the file a QFX")
+	for _, c := range []*cobra.Command{exportBeancountCmd, exportLedgerCmd} {
+		c.Flags().StringArray("map", nil, "Map an account ID, nickname or role to a ledger account, eg Savings=Assets:TFSA, can be repeated")
+		c.Flags().String("append", "", "Append to this journal, skipping the transactions it already has")
+	}
+}
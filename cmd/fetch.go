@@ -5,16 +5,17 @@ package cmd
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	"os"
+	"strings"
 	"time"
 
 	"github.com/samber/lo"
 	"github.com/spf13/cobra"
-	"github.com/vpnda/wsfetch/pkg/auth/types"
-	"github.com/vpnda/wsfetch/pkg/base"
 	"github.com/vpnda/wsfetch/pkg/client"
+	"github.com/vpnda/wsfetch/pkg/client/generated"
+	"github.com/vpnda/wsfetch/pkg/fx"
+	"github.com/vpnda/wsfetch/pkg/output"
+	"github.com/vpnda/wsfetch/pkg/profile"
 )
 
 // fetchCmd represents the fetch command
@@ -26,85 +27,139 @@ from your brokerage account.
 
 It can authenticate using a username/password or a previously saved session.
 If a session exists, it will be used; otherwise, you will be prompted for credentials.
-The session will be refreshed and saved for future use.`,
-	Run: func(cmd *cobra.Command, args []string) {
-		ctx := context.Background()
-		fmt.Println("fetch called")
+The session will be refreshed and saved for future use.
+
+With --profiles or --all-profiles the data of several profiles is fetched,
+every row showing the profile it belongs to. Amounts in another currency than
+--reporting-currency are also converted to it.
 
-		var c client.Client
-		session, err := loadSession(ctx)
+Activities of the last 30 days, or of the lookback days of the profile, are
+fetched unless --from and --to say otherwise, eg --from 2025-01-15,
+--from -90d, --from ytd or --from 2024 --to 2024. The accounts of the profile
+defaults, or all open accounts, are used unless --account selects some.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+		names, err := selectedProfiles(cmd)
+		if err != nil {
+			return err
+		}
+		signFlag, _ := cmd.Flags().GetString("sign-convention")
+		sign, err := client.ParseSignConvention(signFlag)
+		if err != nil {
+			return err
+		}
+		reporting, _ := cmd.Flags().GetString("reporting-currency")
+		opts := fetchOptions{
+			sign:      sign,
+			reporting: strings.ToUpper(reporting),
+		}
+		store, err := profileStore()
 		if err != nil {
-			fmt.Println("Failed to load session, using password method:", err)
-			var username, password string
-			fmt.Println("Enter your username:")
-			fmt.Scanln(&username)
-			fmt.Println("Enter your password:")
-			fmt.Scanln(&password)
-			authClient := base.DefaultAuthClient(types.PasswordCredentials{
-				Username: username,
-				Password: password,
-			})
-			serializeSession(lo.Must(authClient.Fetcher.GetSession(ctx)))
-			c = client.NewCachingClient(lo.Must(client.NewClient(ctx, authClient)))
-		} else {
-			fmt.Println("Loaded session from file")
-			authClient := base.AuthClientFromSession(session)
-			serializeSession(lo.Must(authClient.Fetcher.GetSession(ctx)))
-			c = client.NewCachingClient(lo.Must(client.NewClient(ctx, authClient)))
+			return err
 		}
 
-		accounts := lo.Must(c.GetAccounts(ctx))
-		for _, account := range accounts {
-			fmt.Printf("Account: %s -- %s\n", account.Id, account.Financials.CurrentCombined.NetLiquidationValueV2.Amount)
-			activites := lo.Must(c.GetActivities(ctx,
-				[]client.AccountId{client.AccountId(account.Id)}, lo.ToPtr(time.Now().Add(-30*24*time.Hour)), lo.ToPtr(time.Now())))
-			for _, activity := range activites[client.AccountId(account.Id)] {
-				desc := lo.Must(client.GetActivityDescription(ctx, c, &activity))
-				fmt.Printf("%15s $%10s: %s\n", activity.OccurredAt.Format(time.DateOnly), client.GetFormattedAmount(&activity), desc)
+		start := now()
+		var records []output.Activity
+		for _, name := range names {
+			c, err := clientForProfile(ctx, name)
+			if err != nil {
+				return err
+			}
+			p, err := store.Load(name)
+			if err != nil {
+				return err
+			}
+			from, to, err := window(cmd, p, start)
+			if err != nil {
+				return err
+			}
+			accounts, err := selectedAccounts(ctx, cmd, c, p)
+			if err != nil {
+				return fmt.Errorf("profile %s: %w", name, err)
 			}
+			fetched, err := fetchProfile(ctx, c, p, accounts, from, to, opts)
+			if err != nil {
+				return fmt.Errorf("profile %s: %w", name, err)
+			}
+			records = append(records, fetched...)
 		}
+		return render(records)
 	},
 }
 
-const (
-	sessionFile = "session.json"
-)
+// fetchOptions tune how fetched data is printed
+type fetchOptions struct {
+	sign client.SignConvention
 
-func serializeSession(sess *types.Session) {
-	sessFile, err := os.Create(sessionFile)
-	if err != nil {
-		fmt.Println("Failed to create session file:", err)
-		return
-	}
-	defer sessFile.Close()
-	if err := json.NewEncoder(sessFile).Encode(sess); err != nil {
-		fmt.Println("Failed to encode session file:", err)
-	}
+	// reporting is the currency amounts in other currencies
+	// are converted to
+	reporting string
 }
 
-func loadSession(ctx context.Context) (*types.Session, error) {
-	var sess *types.Session
-	sessFile, err := os.Open(sessionFile)
-	if err != nil {
-		return nil, fmt.Errorf("failed to open session file: %w", err)
+// fetchProfile returns the activities of the accounts of a profile
+// that occurred between from and to
+func fetchProfile(ctx context.Context, c client.Client, p *profile.Profile, accounts []generated.AccountWithFinancials,
+	from, to time.Time, opts fetchOptions) ([]output.Activity, error) {
+	// activities are all fetched first so the rates of every
+	// conversion are known when converting amounts
+	rates := fx.NewBook()
+	activities := map[string][]generated.Activity{}
+	for _, account := range accounts {
+		fetched, err := c.GetActivities(ctx, []client.AccountId{client.AccountId(account.Id)}, &from, &to)
+		if err != nil {
+			return nil, err
+		}
+		activities[account.Id] = fetched[client.AccountId(account.Id)]
+		for i := range activities[account.Id] {
+			rates.Record(&activities[account.Id][i])
+		}
 	}
-	defer sessFile.Close()
-	if err := json.NewDecoder(sessFile).Decode(&sess); err != nil {
-		return nil, fmt.Errorf("failed to decode session file: %w", err)
+
+	var records []output.Activity
+	for _, account := range accounts {
+		for _, activity := range activities[account.Id] {
+			desc, err := client.GetActivityDescription(ctx, c, &activity)
+			if err != nil {
+				return nil, err
+			}
+			flow := client.CashFlow(&activity)
+			if flow.Amount.Currency == "" {
+				flow.Amount.Currency = lo.FromPtr(account.Currency)
+			}
+			record := output.Activity{
+				Profile:     p.Name,
+				AccountId:   account.Id,
+				Id:          lo.FromPtr(activity.CanonicalId),
+				OccurredAt:  lo.FromPtr(activity.OccurredAt),
+				Type:        string(activity.Type),
+				SubType:     string(activity.SubType),
+				Status:      lo.FromPtr(activity.Status),
+				Description: desc,
+				Direction:   string(flow.Direction),
+				Amount:      flow.Signed(opts.sign),
+				Currency:    flow.Amount.Currency,
+				SecurityId:  lo.FromPtr(activity.SecurityId),
+			}
+			if !activity.AssetQuantity.IsZero() {
+				record.Quantity = &activity.AssetQuantity
+			}
+			if err := record.Convert(rates, opts.reporting); err != nil {
+				logger.Sugar().Infow("Amount not converted", "activity", record.Id, "error", err)
+			}
+			records = append(records, record)
+		}
 	}
-	return sess, nil
+	return records, nil
 }
 
 func init() {
 	rootCmd.AddCommand(fetchCmd)
 
-	// Here you will define your flags and configuration settings.
-
-	// Cobra supports Persistent Flags which will work for this command
-	// and all subcommands, e.g.:
-	// fetchCmd.PersistentFlags().String("foo", "", "A help for foo")
-
-	// Cobra supports local flags which will only run when this command
-	// is called directly, e.g.:
-	// fetchCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
+	addAggregateFlags(fetchCmd)
+	addWindowFlags(fetchCmd)
+	addAccountFlags(fetchCmd)
+	fetchCmd.Flags().String("sign-convention", string(client.InflowPositive),
+		"Which amounts are positive: inflow (money received) or outflow (money spent)")
+	fetchCmd.Flags().String("reporting-currency", fx.DefaultReportingCurrency,
+		"Currency amounts in other currencies are converted to, at the rates of the conversions fetched")
 }
@@ -0,0 +1,136 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/samber/lo"
+	"github.com/spf13/cobra"
+	"github.com/vpnda/wsfetch/pkg/client"
+	"github.com/vpnda/wsfetch/pkg/client/generated"
+	"github.com/vpnda/wsfetch/pkg/fx"
+	"github.com/vpnda/wsfetch/pkg/money"
+	"github.com/vpnda/wsfetch/pkg/output"
+)
+
+// fxCmd represents the fx command
+var fxCmd = &cobra.Command{
+	Use:   "fx",
+	Short: "Lists currency conversions and the spread paid on each.",
+	Long: `Lists the currency conversions made by the activities of the selected
+profiles, funds conversions and trades settled in another currency, with the
+effective rate obtained.
+
+The spread is measured against the rate Wealthsimple quotes for the
+activity, or against --reference-rate, typically the mid-market rate of the
+day, when given for the pair. Reference rates are written FROM/TO=VALUE,
+eg --reference-rate USD/CAD=1.3650.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+		names, err := selectedProfiles(cmd)
+		if err != nil {
+			return err
+		}
+		reference, err := referenceRates(cmd)
+		if err != nil {
+			return err
+		}
+
+		store, err := profileStore()
+		if err != nil {
+			return err
+		}
+
+		start := now()
+		var records []output.Conversion
+		for _, name := range names {
+			c, err := clientForProfile(ctx, name)
+			if err != nil {
+				return fmt.Errorf("profile %s: %w", name, err)
+			}
+			p, err := store.Load(name)
+			if err != nil {
+				return err
+			}
+			from, to, err := window(cmd, p, start)
+			if err != nil {
+				return err
+			}
+			accounts, err := selectedAccounts(ctx, cmd, c, p)
+			if err != nil {
+				return fmt.Errorf("profile %s: %w", name, err)
+			}
+			ids := lo.Map(accounts, func(a generated.AccountWithFinancials, _ int) client.AccountId { return client.AccountId(a.Id) })
+			activities, err := c.GetActivities(ctx, ids, &from, &to)
+			if err != nil {
+				return fmt.Errorf("profile %s: %w", name, err)
+			}
+
+			for _, id := range ids {
+				for _, act := range activities[id] {
+					conversion, ok := fx.ConversionFromActivity(&act)
+					if !ok {
+						continue
+					}
+					records = append(records, conversionRecord(name, string(id), conversion, reference))
+				}
+			}
+		}
+		return render(records)
+	},
+}
+
+// referenceRates returns the rates given with --reference-rate
+func referenceRates(cmd *cobra.Command) (*fx.Book, error) {
+	rates := fx.NewBook()
+	rawRates, _ := cmd.Flags().GetStringSlice("reference-rate")
+	for _, raw := range rawRates {
+		r, err := fx.ParseRate(raw, time.Time{})
+		if err != nil {
+			return nil, err
+		}
+		rates.Add(r)
+	}
+	return rates, nil
+}
+
+// conversionRecord measures the spread of a conversion against the
+// reference rate of its pair, the rate Wealthsimple quoted otherwise
+func conversionRecord(profileName, accountId string, c fx.Conversion, reference *fx.Book) output.Conversion {
+	r := output.Conversion{
+		Profile:        profileName,
+		AccountId:      accountId,
+		ActivityId:     c.ActivityId,
+		OccurredAt:     c.At,
+		SoldAmount:     c.Sold.Amount,
+		SoldCurrency:   c.Sold.Currency,
+		BoughtAmount:   c.Bought.Amount,
+		BoughtCurrency: c.Bought.Currency,
+		Rate:           c.Rate.Round(6, money.HalfEven),
+		QuotedRate:     c.QuotedRate,
+	}
+	if rate, err := reference.Rate(c.Sold.Currency, c.Bought.Currency, c.At); err == nil {
+		r.ReferenceRate = &rate
+	} else if c.QuotedRate != nil {
+		r.ReferenceRate = c.QuotedRate
+	}
+
+	if r.ReferenceRate != nil {
+		if s, err := c.Spread(*r.ReferenceRate); err == nil {
+			r.Spread = &s.Relative
+			r.SpreadCost = &s.Cost.Amount
+		}
+	}
+	return r
+}
+
+func init() {
+	rootCmd.AddCommand(fxCmd)
+
+	addAggregateFlags(fxCmd)
+	addWindowFlags(fxCmd)
+	addAccountFlags(fxCmd)
+	fxCmd.Flags().StringSlice("reference-rate", nil, "Rate spreads are measured against, eg USD/CAD=1.3650")
+}
@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/samber/lo"
+	"github.com/spf13/cobra"
+	"github.com/vpnda/wsfetch/pkg/auth/creds"
+	"github.com/vpnda/wsfetch/pkg/auth/types"
+	"github.com/vpnda/wsfetch/pkg/base"
+)
+
+// loginCmd represents the login command
+var loginCmd = &cobra.Command{
+	Use:   "login",
+	Short: "Authenticates with Wealthsimple and stores the session.",
+	Long: `Prompts for your Wealthsimple username and password, going through
+two-factor authentication if Wealthsimple asks for it, and saves the
+resulting session so later commands don't have to prompt again.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+		store, err := profileStore()
+		if err != nil {
+			return err
+		}
+		p, err := store.Load(profileName)
+		if err != nil {
+			return err
+		}
+
+		username, _ := cmd.Flags().GetString("username")
+		if username == "" {
+			username = p.Username
+		}
+		pc, err := promptCredentials(username)
+		if err != nil {
+			return err
+		}
+
+		if cmd.Flags().Changed("scopes") {
+			scopes, _ := cmd.Flags().GetStringSlice("scopes")
+			p.Scopes = lo.Map(scopes, func(s string, _ int) types.Scope { return types.Scope(s) })
+		}
+		if cmd.Flags().Changed("oauth-profile") {
+			p.OAuthProfile, _ = cmd.Flags().GetString("oauth-profile")
+		}
+		pc.Scopes = p.Scopes
+		pc.ProfileName = p.OAuthProfile
+
+		fetcher := creds.NewDefaultFetcherForDevice(pc, p.DeviceId, p.OtpClaim, fetcherOptions()...)
+		sess, err := base.AuthClientFromFetcher(fetcher, baseOptions()...).Fetcher.GetSession(ctx)
+		if err != nil {
+			return fmt.Errorf("unable to log in: %w", err)
+		}
+
+		p.Username = pc.Username
+		if err := store.Save(p); err != nil {
+			return err
+		}
+		serializeSession(profileName, sess)
+
+		fmt.Printf("Logged in as profile %s with scopes %q, session valid until %s\n",
+			profileName, types.FormatScopes(sess.Scopes), sess.Expiry.Local().Format(timeLayout))
+		return nil
+	},
+}
+
+const timeLayout = "2006-01-02 15:04:05 MST"
+
+func init() {
+	rootCmd.AddCommand(loginCmd)
+
+	loginCmd.Flags().StringP("username", "u", "", "Wealthsimple username, prompted for if empty")
+	loginCmd.Flags().StringSlice("scopes", lo.Map(types.DefaultScopes, func(s types.Scope, _ int) string { return string(s) }),
+		"OAuth scopes to request, remembered by the profile. Use trade.read alone for a read-only reporting token")
+	loginCmd.Flags().String("oauth-profile", "", "Wealthsimple profile to log in to (eg invest, trade), remembered by the profile")
+}
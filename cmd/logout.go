@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/vpnda/wsfetch/pkg/auth/authenticator"
+)
+
+// logoutCmd represents the logout command
+var logoutCmd = &cobra.Command{
+	Use:   "logout",
+	Short: "Revokes the stored session and deletes it.",
+	Long: `Revokes the refresh token of the stored session with Wealthsimple, which
+also invalidates the access tokens issued from it, and deletes the session.
+The device ID and remember-me claim of the profile are kept.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+		sess, err := loadSession(ctx, profileName)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Not logged in")
+			return nil
+		}
+
+		if err := authenticator.NewClientFromSession(sess, authenticatorOptions()...).Revoke(ctx, sess); err != nil {
+			force, _ := cmd.Flags().GetBool("force")
+			if !force {
+				return fmt.Errorf("%w, use --force to delete the session anyway", err)
+			}
+			fmt.Fprintln(os.Stderr, "Failed to revoke session, deleting it anyway:", err)
+		}
+
+		if err := removeSession(profileName); err != nil {
+			return err
+		}
+		fmt.Printf("Logged out of profile %s\n", profileName)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(logoutCmd)
+
+	logoutCmd.Flags().Bool("force", false, "Delete the stored session even if it can't be revoked")
+}
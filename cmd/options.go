@@ -0,0 +1,185 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/vpnda/wsfetch/pkg/auth/authenticator"
+	"github.com/vpnda/wsfetch/pkg/auth/creds"
+	"github.com/vpnda/wsfetch/pkg/base"
+	"github.com/vpnda/wsfetch/pkg/endpoints"
+	"github.com/vpnda/wsfetch/pkg/ratelimit"
+)
+
+const (
+	clientIdEnv = "WSFETCH_CLIENT_ID"
+)
+
+var (
+	// clientIdOverride is the client id given with --client-id
+	clientIdOverride string
+
+	// maxRetries and retryTimeout tune how failed GraphQL queries are retried
+	maxRetries   int
+	retryTimeout time.Duration
+
+	// apiBase sends every request to an alternate server, given with --api-base
+	apiBase string
+
+	// traceHTTP dumps every request and response, redacted, to stderr
+	traceHTTP bool
+
+	// graphqlLimit and authLimit are the budgets of the process wide rate limiters
+	graphqlLimit = ratelimit.DefaultGraphQLLimit
+	authLimit    = ratelimit.DefaultAuthLimit
+)
+
+// authenticatorOptions are the options of every authenticator the CLI creates
+func authenticatorOptions() []authenticator.Option {
+	opts := []authenticator.Option{
+		authenticator.WithLogger(logger),
+		authenticator.WithEndpoints(endpointsConfig),
+	}
+	if mws := middlewares(); len(mws) != 0 || replaying() {
+		opts = append(opts, authenticator.WithTransport(base.Chain(transport(), mws...)))
+	}
+	if replaying() {
+		opts = append(opts, authenticator.WithRateLimiter(nil))
+	}
+
+	clientId := clientIdOverride
+	if clientId == "" {
+		clientId = os.Getenv(clientIdEnv)
+	}
+	if clientId != "" {
+		opts = append(opts, authenticator.WithClientId(clientId))
+	}
+
+	if cache := clientIdCache(); cache != nil {
+		maxAge, err := cfg.ClientIdMaxAge(authenticator.DefaultClientIdMaxAge)
+		if err != nil {
+			logger.Sugar().Warnw("Using the default client id max age", "error", err)
+			maxAge = authenticator.DefaultClientIdMaxAge
+		}
+		opts = append(opts, authenticator.WithClientIdCache(cache, maxAge))
+	}
+	return opts
+}
+
+// clientIdCache is where the discovered client id is cached, next to
+// the profiles unless the cache is configured otherwise
+func clientIdCache() *authenticator.FileClientIdCache {
+	if cfg.Cache.Disabled {
+		return nil
+	}
+	if cfg.Cache.Dir != "" {
+		return &authenticator.FileClientIdCache{Path: filepath.Join(cfg.Cache.Dir, "clientid.json")}
+	}
+	store, err := profileStore()
+	if err != nil {
+		return nil
+	}
+	return &authenticator.FileClientIdCache{Path: filepath.Join(filepath.Dir(store.Dir), "clientid.json")}
+}
+
+// fetcherOptions are the options of every session fetcher the CLI creates
+func fetcherOptions() []creds.Option {
+	return []creds.Option{
+		creds.WithLogger(logger),
+		creds.WithAuthenticatorOptions(authenticatorOptions()...),
+	}
+}
+
+// baseOptions are the options of every Wealthsimple client the CLI creates
+func baseOptions() []base.Option {
+	opts := []base.Option{
+		base.WithLogger(logger),
+		base.WithAuthenticatorOptions(authenticatorOptions()...),
+		base.WithRetryPolicy(retryPolicy()),
+		base.WithMiddleware(middlewares()...),
+		base.WithTransport(transport()),
+		base.WithEndpoints(endpointsConfig),
+	}
+	if replaying() {
+		opts = append(opts, base.WithRateLimiters(nil, nil))
+	}
+	return opts
+}
+
+// middlewares wrap the transport of every client the CLI creates
+func middlewares() []base.Middleware {
+	var mws []base.Middleware
+	if traceHTTP {
+		mws = append(mws, base.TraceMiddleware(os.Stderr))
+	}
+	if recorder != nil {
+		mws = append(mws, recorder.Transport)
+	}
+	return mws
+}
+
+// retryPolicy is the default retry policy tuned by --max-retries and --retry-timeout
+func retryPolicy() base.RetryPolicy {
+	p := base.DefaultRetryPolicy
+	p.MaxAttempts = maxRetries + 1
+	p.MaxElapsed = retryTimeout
+	return p
+}
+
+// endpointsConfig is where requests are sent, see --api-base
+var endpointsConfig = endpoints.Default
+
+// configureEndpoints applies --api-base
+func configureEndpoints() error {
+	if apiBase == "" {
+		return nil
+	}
+	cfg, err := endpoints.ParseBase(apiBase)
+	if err != nil {
+		return fmt.Errorf("invalid --api-base: %w", err)
+	}
+	endpointsConfig = cfg
+	return nil
+}
+
+// configureRateLimits applies the rate limit flags to the process wide limiters
+func configureRateLimits() {
+	ratelimit.GraphQL.SetLimit(graphqlLimit)
+	ratelimit.Auth.SetLimit(authLimit)
+}
+
+// logRateLimitStats reports how long requests were throttled
+func logRateLimitStats() {
+	for _, l := range []*ratelimit.Limiter{ratelimit.Auth, ratelimit.GraphQL} {
+		stats := l.Stats()
+		if stats.Requests == 0 {
+			continue
+		}
+		logger.Sugar().Infow("Rate limiter stats", "limiter", l.Name,
+			"requests", stats.Requests, "throttled", stats.Throttled,
+			"totalWait", stats.TotalWait, "maxWait", stats.MaxWait)
+	}
+}
+
+func init() {
+	rootCmd.PersistentFlags().StringVar(&clientIdOverride, "client-id", "",
+		"Wealthsimple OAuth client id, discovered from the web app when empty (env "+clientIdEnv+")")
+	rootCmd.PersistentFlags().StringVar(&apiBase, "api-base", "",
+		"Send every request to this base URL instead of Wealthsimple, for example a local mock server")
+	rootCmd.PersistentFlags().BoolVar(&traceHTTP, "trace-http", false,
+		"Dump every HTTP request and response to stderr, with credentials and personal fields redacted")
+	rootCmd.PersistentFlags().IntVar(&maxRetries, "max-retries", base.DefaultRetryPolicy.MaxAttempts-1,
+		"How many times a GraphQL query failing with a transient error is retried, 0 disables retries")
+	rootCmd.PersistentFlags().DurationVar(&retryTimeout, "retry-timeout", base.DefaultRetryPolicy.MaxElapsed,
+		"Give up retrying a GraphQL query after this long, 0 means no limit")
+	rootCmd.PersistentFlags().Float64Var(&graphqlLimit.RequestsPerSecond, "rate-limit", graphqlLimit.RequestsPerSecond,
+		"GraphQL requests per second sent to Wealthsimple, 0 disables the limit")
+	rootCmd.PersistentFlags().IntVar(&graphqlLimit.Burst, "rate-burst", graphqlLimit.Burst,
+		"GraphQL requests sent in a burst before being throttled")
+	rootCmd.PersistentFlags().Float64Var(&authLimit.RequestsPerSecond, "auth-rate-limit", authLimit.RequestsPerSecond,
+		"Authentication requests per second sent to Wealthsimple, 0 disables the limit")
+	rootCmd.PersistentFlags().IntVar(&authLimit.Burst, "auth-rate-burst", authLimit.Burst,
+		"Authentication requests sent in a burst before being throttled")
+}
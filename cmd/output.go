@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"os"
+	"strings"
+
+	"github.com/vpnda/wsfetch/pkg/output"
+)
+
+var (
+	// outputFlag is the format given with --output
+	outputFlag string
+
+	// outputFormat is the format records are printed in, stdout only
+	// carries records, diagnostics go to stderr
+	outputFormat = output.Table
+)
+
+// configureOutput applies --output
+func configureOutput() error {
+	f, err := output.ParseFormat(outputFlag)
+	if err != nil {
+		return err
+	}
+	outputFormat = f
+	return nil
+}
+
+// render prints records to stdout in the chosen format
+func render[T any](records []T) error {
+	return output.Render(os.Stdout, outputFormat, output.Options{Location: location, Locale: locale}, records)
+}
+
+func init() {
+	rootCmd.PersistentFlags().StringVarP(&outputFlag, "output", "o", string(output.Table),
+		"Output format: "+strings.Join(output.FormatNames(), ", "))
+}
@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/vpnda/wsfetch/pkg/client/generated"
+	"github.com/vpnda/wsfetch/pkg/output"
+)
+
+// positionsCmd represents the positions command
+var positionsCmd = &cobra.Command{
+	Use:   "positions",
+	Short: "Lists the securities and cash held by the accounts.",
+	Long: `Lists the quantity of every security and currency held by the accounts of
+the selected profiles, priced at the last quote of the security.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+		names, err := selectedProfiles(cmd)
+		if err != nil {
+			return err
+		}
+
+		var records []output.Position
+		for _, name := range names {
+			c, err := clientForProfile(ctx, name)
+			if err != nil {
+				return fmt.Errorf("profile %s: %w", name, err)
+			}
+			accounts, err := c.GetAccounts(ctx)
+			if err != nil {
+				return fmt.Errorf("profile %s: %w", name, err)
+			}
+
+			for _, account := range accounts {
+				for _, custodian := range account.AccountFinancials.CustodianAccounts {
+					if custodian.Financials == nil {
+						continue
+					}
+					for _, balance := range custodian.Financials.Balance {
+						var data *generated.SecurityMarketData
+						if !output.IsCash(balance.SecurityId) {
+							data, err = c.GetSecurityMarketData(ctx, balance.SecurityId)
+							if err != nil {
+								return fmt.Errorf("profile %s: %w", name, err)
+							}
+						}
+						records = append(records, output.NewPosition(name, account.Id, balance.Quantity, balance.SecurityId, data))
+					}
+				}
+			}
+		}
+		return render(records)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(positionsCmd)
+
+	addAggregateFlags(positionsCmd)
+}
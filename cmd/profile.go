@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/samber/lo"
+	"github.com/spf13/cobra"
+	"github.com/vpnda/wsfetch/pkg/profile"
+)
+
+var (
+	// profileName is the profile selected with --profile
+	profileName string
+
+	// profileStore is where profiles are stored, under the user
+	// configuration directory unless sessionDir is configured
+	profileStore = sync.OnceValues(func() (*profile.Store, error) {
+		if cfg.SessionDir != "" {
+			return &profile.Store{Dir: cfg.SessionDir}, nil
+		}
+		return profile.DefaultStore()
+	})
+)
+
+// profileCmd represents the profile command
+var profileCmd = &cobra.Command{
+	Use:   "profile",
+	Short: "Manages the named profiles used to log in to Wealthsimple.",
+	Long: `Each profile holds its own session, device ID, remember-me 2FA claim and
+command defaults, so several Wealthsimple logins can be used side by side.
+Select a profile with --profile, profiles are created on first login.`,
+}
+
+var profileListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Lists the stored profiles.",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		store, err := profileStore()
+		if err != nil {
+			return err
+		}
+		names, err := store.List()
+		if err != nil {
+			return err
+		}
+		for _, name := range names {
+			p, err := store.Load(name)
+			if err != nil {
+				return err
+			}
+			_, sessErr := store.LoadSession(name)
+			fmt.Printf("%-15s %-30s logged in: %t\n", name, p.Username, sessErr == nil)
+		}
+		return nil
+	},
+}
+
+// addAggregateFlags registers the flags selecting several profiles at once
+func addAggregateFlags(cmd *cobra.Command) {
+	cmd.Flags().StringSlice("profiles", nil, "Run across these profiles instead of --profile")
+	cmd.Flags().Bool("all-profiles", false, "Run across every stored profile")
+}
+
+// selectedProfiles returns the profiles a command should run for
+func selectedProfiles(cmd *cobra.Command) ([]string, error) {
+	if all, _ := cmd.Flags().GetBool("all-profiles"); all {
+		store, err := profileStore()
+		if err != nil {
+			return nil, err
+		}
+		names, err := store.List()
+		if err != nil {
+			return nil, err
+		}
+		if len(names) == 0 {
+			return nil, fmt.Errorf("no profiles stored, log in first")
+		}
+		return names, nil
+	}
+
+	if names, _ := cmd.Flags().GetStringSlice("profiles"); len(names) != 0 {
+		return lo.Uniq(names), nil
+	}
+	if len(cfg.Profiles) != 0 && !cmd.Flags().Changed("profile") {
+		return lo.Uniq(cfg.Profiles), nil
+	}
+	return []string{profileName}, nil
+}
+
+func init() {
+	rootCmd.AddCommand(profileCmd)
+	profileCmd.AddCommand(profileListCmd)
+
+	rootCmd.PersistentFlags().StringVarP(&profileName, "profile", "p", profile.DefaultName, "Profile to authenticate as")
+}
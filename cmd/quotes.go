@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/vpnda/wsfetch/pkg/output"
+)
+
+// quotesCmd represents the quotes command
+var quotesCmd = &cobra.Command{
+	Use:   "quotes SECURITY_ID...",
+	Short: "Prints the last quote of securities.",
+	Long: `Prints the last quote of the given securities, identified by their
+Wealthsimple security id such as sec-s-76a7155242e8477880cbb43269235cb6,
+the id activities and positions refer to them by.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+		c, err := clientForProfile(ctx, profileName)
+		if err != nil {
+			return err
+		}
+
+		var records []output.Quote
+		for _, id := range args {
+			data, err := c.GetSecurityMarketData(ctx, id)
+			if err != nil {
+				return fmt.Errorf("security %s: %w", id, err)
+			}
+			records = append(records, output.NewQuote(data))
+		}
+		return render(records)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(quotesCmd)
+}
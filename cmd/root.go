@@ -1,6 +1,5 @@
 /*
 Copyright © 2024 NAME HERE <EMAIL ADDRESS>
-
 */
 package cmd
 
@@ -8,44 +7,64 @@ import (
 	"os"
 
 	"github.com/spf13/cobra"
+	"github.com/vpnda/wsfetch/pkg/logging"
 )
 
+var (
+	// logger is shared by every command, it logs to stderr and
+	// redacts secrets
+	logger = logging.Nop()
 
+	logLevel  string
+	logFormat string
+)
 
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
 	Use:   "wsfetch",
-	Short: "A brief description of your application",
-	Long: `A longer description that spans multiple lines and likely contains
-examples and usage of using your application. For example:
-
-Cobra is a CLI library for Go that empowers applications.
-This application is a tool to generate the needed files
-to quickly create a Cobra application.`,
-	// Uncomment the following line if your bare application
-	// has an action associated with it:
-	// Run: func(cmd *cobra.Command, args []string) { },
+	Short: "Fetches accounts, activities and positions from Wealthsimple.",
+	Long: `wsfetch logs in to Wealthsimple and fetches the accounts, activities,
+positions and quotes of one or more profiles, printing them as a table or as
+JSON, NDJSON, CSV or YAML for scripts and spreadsheets.
+
+Settings are read from $XDG_CONFIG_HOME/wsfetch/config.yaml, overridden by
+WSFETCH_* environment variables, themselves overridden by flags. See
+wsfetch config --help.`,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		l, err := logging.New(os.Stderr, logLevel, logFormat)
+		if err != nil {
+			return err
+		}
+		logger = l
+		if err := configure(cmd); err != nil {
+			return err
+		}
+		if err := configureOutput(); err != nil {
+			return err
+		}
+		configureRateLimits()
+		if err := configureEndpoints(); err != nil {
+			return err
+		}
+		return configureCassette()
+	},
+	PersistentPostRun: func(cmd *cobra.Command, args []string) {
+		logRateLimitStats()
+	},
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
+	defer func() { _ = logger.Sync() }()
 	err := rootCmd.Execute()
 	if err != nil {
-		os.Exit(1)
+		_ = logger.Sync()
+		os.Exit(exitCode(err))
 	}
 }
 
 func init() {
-	// Here you will define your flags and configuration settings.
-	// Cobra supports persistent flags, which, if defined here,
-	// will be global for your application.
-
-	// rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.wsfetch.yaml)")
-
-	// Cobra also supports local flags, which will only run
-	// when this action is called directly.
-	rootCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
+	rootCmd.PersistentFlags().StringVar(&logLevel, "log-level", "warn", "Log level: debug, info, warn or error")
+	rootCmd.PersistentFlags().StringVar(&logFormat, "log-format", logging.FormatConsole, "Log format: console or json")
 }
-
-
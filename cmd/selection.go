@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/vpnda/wsfetch/pkg/client"
+	"github.com/vpnda/wsfetch/pkg/client/generated"
+	"github.com/vpnda/wsfetch/pkg/daterange"
+	"github.com/vpnda/wsfetch/pkg/profile"
+)
+
+// defaultLookbackDays is how far back activities are fetched when
+// neither --from nor the profile tell
+const defaultLookbackDays = 30
+
+// addWindowFlags registers the flags choosing the dates activities
+// are fetched between
+func addWindowFlags(cmd *cobra.Command) {
+	cmd.Flags().String("from", "", "Fetch activities from this date, "+daterange.Expressions+
+		" (default: the lookback days of the profile ago, or 30 days ago)")
+	cmd.Flags().String("to", "", "Fetch activities up to this date, same forms as --from (default: now)")
+}
+
+// window returns the dates activities are fetched between, --from
+// defaulting to the lookback days of the profile
+func window(cmd *cobra.Command, p *profile.Profile, now time.Time) (time.Time, time.Time, error) {
+	fromFlag, _ := cmd.Flags().GetString("from")
+	toFlag, _ := cmd.Flags().GetString("to")
+
+	lookback := defaultLookbackDays
+	if p.Defaults.LookbackDays > 0 {
+		lookback = p.Defaults.LookbackDays
+	}
+	from := now.Add(-time.Duration(lookback) * 24 * time.Hour)
+	if fromFlag != "" {
+		r, err := daterange.Parse(fromFlag, now)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid --from: %w", err)
+		}
+		from = r.Start
+	}
+	to := now
+	if toFlag != "" {
+		r, err := daterange.Parse(toFlag, now)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid --to: %w", err)
+		}
+		to = r.End
+	}
+	if from.After(to) {
+		return time.Time{}, time.Time{}, fmt.Errorf("--from %s is after --to %s",
+			from.Format(time.DateOnly), to.Format(time.DateOnly))
+	}
+	return from, to, nil
+}
+
+// addAccountFlags registers the flags choosing the accounts a
+// command runs on
+func addAccountFlags(cmd *cobra.Command) {
+	cmd.Flags().StringArray("account", nil,
+		"Only use this account, given by ID, nickname or type such as tfsa, can be repeated (default: the accounts of the profile, or all)")
+	cmd.Flags().Bool("include-closed", false, "Also use closed accounts")
+}
+
+// expandAliases replaces the configured account aliases by the comma
+// separated selectors they stand for
+func expandAliases(selectors []string) []string {
+	var expanded []string
+	for _, s := range selectors {
+		alias, ok := cfg.Aliases[strings.ToLower(s)]
+		if !ok {
+			expanded = append(expanded, s)
+			continue
+		}
+		for _, selector := range strings.Split(alias, ",") {
+			if selector = strings.TrimSpace(selector); selector != "" {
+				expanded = append(expanded, selector)
+			}
+		}
+	}
+	return expanded
+}
+
+// selectedAccounts returns the accounts chosen with --account, those
+// of the profile defaults otherwise
+func selectedAccounts(ctx context.Context, cmd *cobra.Command, c client.Client, p *profile.Profile) ([]generated.AccountWithFinancials, error) {
+	selectors, _ := cmd.Flags().GetStringArray("account")
+	if len(selectors) == 0 {
+		selectors = p.Defaults.Accounts
+	}
+	selectors = expandAliases(selectors)
+	includeClosed, _ := cmd.Flags().GetBool("include-closed")
+
+	accounts, err := c.GetAccounts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return client.SelectAccounts(accounts, selectors, includeClosed)
+}
@@ -0,0 +1,188 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/vpnda/wsfetch/pkg/auth/creds"
+	"github.com/vpnda/wsfetch/pkg/auth/types"
+	"github.com/vpnda/wsfetch/pkg/base"
+	"github.com/vpnda/wsfetch/pkg/client"
+	"github.com/vpnda/wsfetch/pkg/profile"
+	"golang.org/x/term"
+)
+
+const (
+	// legacySessionFile is where sessions were stored before profiles,
+	// it is still picked up by the default profile
+	legacySessionFile = "session.json"
+)
+
+func serializeSession(name string, sess *types.Session) {
+	store, err := profileStore()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Failed to open profile store:", err)
+		return
+	}
+	if err := store.SaveSession(name, sess); err != nil {
+		fmt.Fprintln(os.Stderr, "Failed to save session:", err)
+	}
+}
+
+func loadSession(ctx context.Context, name string) (*types.Session, error) {
+	store, err := profileStore()
+	if err != nil {
+		return nil, err
+	}
+
+	sess, err := store.LoadSession(name)
+	if errors.Is(err, profile.ErrNoSession) && name == profile.DefaultName {
+		if legacy, legacyErr := loadLegacySession(); legacyErr == nil {
+			return legacy, nil
+		}
+	}
+	return sess, err
+}
+
+func loadLegacySession() (*types.Session, error) {
+	var sess *types.Session
+	sessFile, err := os.Open(legacySessionFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open session file: %w", err)
+	}
+	defer sessFile.Close()
+	if err := json.NewDecoder(sessFile).Decode(&sess); err != nil {
+		return nil, fmt.Errorf("failed to decode session file: %w", err)
+	}
+	return sess, nil
+}
+
+func removeSession(name string) error {
+	store, err := profileStore()
+	if err != nil {
+		return err
+	}
+	if err := store.RemoveSession(name); err != nil {
+		return err
+	}
+	if name == profile.DefaultName {
+		err := os.Remove(legacySessionFile)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to remove session file: %w", err)
+		}
+	}
+	return nil
+}
+
+// authClientForProfile returns a client authenticated as the named profile.
+// When the profile has no session the user is prompted for credentials,
+// reusing the device ID and remember-me claim of the profile.
+func authClientForProfile(ctx context.Context, name string) (*base.Wealthsimple, error) {
+	if replaying() {
+		return base.StatictAuthClient(replaySession(), baseOptions()...), nil
+	}
+
+	sess, err := loadSession(ctx, name)
+	if err == nil {
+		return base.AuthClientFromSession(sess, baseOptions()...), nil
+	}
+	fmt.Fprintf(os.Stderr, "Failed to load session for profile %s, using password method: %s\n", name, err)
+
+	store, err := profileStore()
+	if err != nil {
+		return nil, err
+	}
+	p, err := store.Load(name)
+	if err != nil {
+		return nil, err
+	}
+
+	pc, err := promptCredentials(p.Username)
+	if err != nil {
+		return nil, err
+	}
+	pc.Scopes = p.Scopes
+	pc.ProfileName = p.OAuthProfile
+	if p.Username != pc.Username {
+		p.Username = pc.Username
+		if err := store.Save(p); err != nil {
+			return nil, err
+		}
+	}
+	fetcher := creds.NewDefaultFetcherForDevice(pc, p.DeviceId, p.OtpClaim, fetcherOptions()...)
+	return base.AuthClientFromFetcher(fetcher, baseOptions()...), nil
+}
+
+// clientForProfile returns a caching API client for the named profile
+// and stores its, possibly refreshed, session
+func clientForProfile(ctx context.Context, name string) (client.Client, error) {
+	authClient, err := authClientForProfile(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	if !replaying() {
+		sess, err := authClient.Fetcher.GetSession(ctx)
+		if err != nil {
+			return nil, err
+		}
+		serializeSession(name, sess)
+	}
+	if recorder != nil {
+		// the client reads the identity from the claims of the access
+		// token, replays have no token and read it from the recorded
+		// token information instead
+		if _, err := authClient.GetTokenInformation(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	c, err := client.NewClient(ctx, authClient)
+	if err != nil {
+		return nil, err
+	}
+	return client.NewCachingClient(c), nil
+}
+
+// promptCredentials asks for the username, unless one is given, and
+// the password. The password is not echoed when stdin is a terminal.
+func promptCredentials(username string) (types.PasswordCredentials, error) {
+	in := bufio.NewReader(os.Stdin)
+	if username == "" {
+		fmt.Fprintln(os.Stderr, "Enter your username:")
+		line, err := in.ReadString('\n')
+		if err != nil && line == "" {
+			return types.PasswordCredentials{}, fmt.Errorf("failed to read username: %w", err)
+		}
+		username = strings.TrimSpace(line)
+	} else {
+		fmt.Fprintf(os.Stderr, "Logging in as %s\n", username)
+	}
+
+	fmt.Fprintln(os.Stderr, "Enter your password:")
+	var password string
+	if fd := int(os.Stdin.Fd()); term.IsTerminal(fd) {
+		bits, err := term.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return types.PasswordCredentials{}, fmt.Errorf("failed to read password: %w", err)
+		}
+		password = string(bits)
+	} else {
+		line, err := in.ReadString('\n')
+		if err != nil && line == "" {
+			return types.PasswordCredentials{}, fmt.Errorf("failed to read password: %w", err)
+		}
+		password = strings.TrimRight(line, "\r\n")
+	}
+
+	return types.PasswordCredentials{
+		Username: username,
+		Password: password,
+	}, nil
+}
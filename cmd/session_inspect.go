@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/vpnda/wsfetch/pkg/auth/types"
+)
+
+// sessionCmd represents the session command
+var sessionCmd = &cobra.Command{
+	Use:   "session",
+	Short: "Works with the stored session of a profile.",
+}
+
+var sessionInspectCmd = &cobra.Command{
+	Use:   "inspect",
+	Short: "Shows the decoded state of the stored session, offline.",
+	Long: `Shows the stored session of the profile and, when its access token is a JWT,
+the claims decoded from it: identity, scopes, profiles and expiry. Nothing is
+sent to Wealthsimple and the session isn't refreshed. Tokens are never printed.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		sess, err := loadSession(context.Background(), profileName)
+		if err != nil {
+			return fmt.Errorf("not logged in: %w", err)
+		}
+
+		now := time.Now()
+		fmt.Printf("Profile:        %s\n", profileName)
+		fmt.Printf("Session ID:     %s\n", sess.SessionId)
+		fmt.Printf("Client ID:      %s\n", sess.ClientId)
+		fmt.Printf("Device ID:      %s\n", sess.WSSID)
+		fmt.Printf("OAuth profile:  %s\n", sess.Profile())
+		fmt.Printf("Refresh token:  %s\n", presence(sess.RefreshToken))
+		fmt.Printf("OTP claim:      %s\n", presence(sess.RefreshOtpToken))
+		fmt.Printf("Scopes:         %s\n", types.FormatScopes(sess.Scopes))
+		fmt.Printf("Local expiry:   %s\n", formatExpiry(sess.Expiry, now))
+
+		claims, err := sess.Claims()
+		if err != nil {
+			fmt.Printf("Token claims:   unavailable (%s)\n", err)
+		} else {
+			fmt.Printf("Identity:       %s\n", claims.IdentityId)
+			if claims.UserId != "" {
+				fmt.Printf("User:           %s\n", claims.UserId)
+			}
+			if claims.Issuer != "" {
+				fmt.Printf("Issuer:         %s\n", claims.Issuer)
+			}
+			if claims.IssuedAt != nil {
+				fmt.Printf("Issued at:      %s\n", claims.IssuedAt.Local().Format(timeLayout))
+			}
+			fmt.Printf("Token expiry:   %s\n", formatExpiry(claims.ExpiresAt, now))
+			fmt.Printf("Token scopes:   %s\n", types.FormatScopes(claims.Scopes))
+			profiles := make([]string, 0, len(claims.Profiles))
+			for profile := range claims.Profiles {
+				profiles = append(profiles, profile)
+			}
+			sort.Strings(profiles)
+			for _, profile := range profiles {
+				fmt.Printf("Client ID:      %s -> %s\n", profile, claims.Profiles[profile])
+			}
+			if skew, ok := sess.ClockSkew(); ok {
+				fmt.Printf("Clock skew:     %s\n", skew.Round(time.Second))
+			}
+		}
+
+		effective := sess.EffectiveExpiry()
+		active := effective != nil && effective.After(now)
+		fmt.Printf("Active:         %t\n", active)
+		return nil
+	},
+}
+
+func presence(secret string) string {
+	if secret == "" {
+		return "absent"
+	}
+	return "present"
+}
+
+func formatExpiry(t *time.Time, now time.Time) string {
+	if t == nil {
+		return "unknown"
+	}
+	if t.Before(now) {
+		return fmt.Sprintf("%s (expired %s ago)", t.Local().Format(timeLayout), now.Sub(*t).Round(time.Second))
+	}
+	return fmt.Sprintf("%s (in %s)", t.Local().Format(timeLayout), t.Sub(now).Round(time.Second))
+}
+
+func init() {
+	rootCmd.AddCommand(sessionCmd)
+	sessionCmd.AddCommand(sessionInspectCmd)
+}
@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/vpnda/wsfetch/pkg/auth/types"
+	"github.com/vpnda/wsfetch/pkg/base"
+)
+
+// whoamiCmd represents the whoami command
+var whoamiCmd = &cobra.Command{
+	Use:   "whoami",
+	Short: "Prints the identity behind the stored session.",
+	Long: `Prints the identity, user ID and profile client IDs of the stored session
+along with its expiry. Exits with a non-zero code when not logged in, so
+scripts can check the authentication state before running a long job.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+		sess, err := loadSession(ctx, profileName)
+		if err != nil {
+			return fmt.Errorf("not logged in: %w", err)
+		}
+
+		authClient := base.AuthClientFromSession(sess, baseOptions()...)
+		info, err := authClient.GetTokenInformation(ctx)
+		if err != nil {
+			return fmt.Errorf("unable to fetch token information: %w", err)
+		}
+
+		// the session may have been refreshed along the way
+		sess, err = authClient.Fetcher.GetSession(ctx)
+		if err != nil {
+			return err
+		}
+		serializeSession(profileName, sess)
+
+		fmt.Printf("Profile:    %s\n", profileName)
+		fmt.Printf("Identity:   %s\n", info.IdentityId)
+		fmt.Printf("User:       %s\n", info.UserId)
+		profiles := make([]string, 0, len(info.ProfileToClientId))
+		for profile := range info.ProfileToClientId {
+			profiles = append(profiles, string(profile))
+		}
+		sort.Strings(profiles)
+		for _, profile := range profiles {
+			fmt.Printf("Client ID:  %s -> %s\n", profile, info.ProfileToClientId[base.WsProfile(profile)])
+		}
+		fmt.Printf("Scopes:     %s\n", types.FormatScopes(sess.Scopes))
+		fmt.Printf("Expires at: %s\n", formatExpiry(sess.EffectiveExpiry(), time.Now()))
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(whoamiCmd)
+}
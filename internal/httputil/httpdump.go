@@ -1,20 +1,158 @@
 package httputil
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
+	"io"
+	"mime"
 	"net/http"
-	"net/http/httputil"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/vpnda/wsfetch/pkg/logging"
 )
 
+// sensitiveHeaders identify the device, they are redacted on top
+// of the headers logging.IsSensitive flags
+var sensitiveHeaders = []string{
+	"X-Ws-Device-Id",
+	"X-Ws-Session-Id",
+}
+
+// DumpTransport writes every request sent through R, and its response,
+// to W. Credentials and personal fields are redacted from the headers,
+// the query and JSON or form bodies so traces can be shared safely.
 type DumpTransport struct {
 	R http.RoundTripper
+	W io.Writer
+
+	mu sync.Mutex
 }
 
-func (d *DumpTransport) RoundTrip(h *http.Request) (*http.Response, error) {
-	dump, _ := httputil.DumpRequestOut(h, true)
-	fmt.Printf("****REQUEST****\n%q\n", dump)
-	resp, err := d.R.RoundTrip(h)
-	dump, _ = httputil.DumpResponse(resp, true)
-	fmt.Printf("****RESPONSE****\n%q\n****************\n\n", dump)
+func (d *DumpTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	reqBody, err := peekBody(&r.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := d.R.RoundTrip(r)
+
+	buf := &bytes.Buffer{}
+	fmt.Fprintf(buf, "> %s %s\n", r.Method, redactURL(r.URL))
+	writeHeaders(buf, "> ", r.Header)
+	writeBody(buf, r.Header.Get("Content-Type"), reqBody)
+	if err != nil {
+		fmt.Fprintf(buf, "< error: %s\n", err)
+	} else {
+		var respBody []byte
+		respBody, err = peekBody(&resp.Body)
+		fmt.Fprintf(buf, "< %s\n", resp.Status)
+		writeHeaders(buf, "< ", resp.Header)
+		writeBody(buf, resp.Header.Get("Content-Type"), respBody)
+	}
+	buf.WriteString("\n")
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	_, _ = d.W.Write(buf.Bytes())
 	return resp, err
 }
+
+// peekBody reads the body and puts back a copy so it can still be consumed
+func peekBody(body *io.ReadCloser) ([]byte, error) {
+	if *body == nil || *body == http.NoBody {
+		return nil, nil
+	}
+	bits, err := io.ReadAll(*body)
+	(*body).Close()
+	*body = io.NopCloser(bytes.NewReader(bits))
+	return bits, err
+}
+
+func redactURL(u *url.URL) string {
+	redacted := *u
+	redacted.RawQuery = redactValues(u.Query()).Encode()
+	return redacted.String()
+}
+
+func redactValues(values url.Values) url.Values {
+	for k := range values {
+		if logging.IsSensitive(k) {
+			values[k] = []string{logging.Redacted}
+		}
+	}
+	return values
+}
+
+func writeHeaders(w io.Writer, prefix string, h http.Header) {
+	keys := make([]string, 0, len(h))
+	for k := range h {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		v := strings.Join(h[k], ", ")
+		if isSensitiveHeader(k) {
+			v = logging.Redacted
+		}
+		fmt.Fprintf(w, "%s%s: %s\n", prefix, k, v)
+	}
+}
+
+func isSensitiveHeader(k string) bool {
+	for _, s := range sensitiveHeaders {
+		if strings.EqualFold(k, s) {
+			return true
+		}
+	}
+	return logging.IsSensitive(k)
+}
+
+// writeBody writes JSON and form bodies with their sensitive fields
+// redacted, other bodies are summarized as they can't be redacted
+func writeBody(w io.Writer, contentType string, body []byte) {
+	if len(body) == 0 {
+		return
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch {
+	case strings.HasSuffix(mediaType, "json"):
+		var v any
+		if json.Unmarshal(body, &v) == nil {
+			out, _ := json.MarshalIndent(RedactJSON(v), "", "  ")
+			fmt.Fprintf(w, "%s\n", out)
+			return
+		}
+	case mediaType == "application/x-www-form-urlencoded":
+		if values, err := url.ParseQuery(string(body)); err == nil {
+			fmt.Fprintf(w, "%s\n", redactValues(values).Encode())
+			return
+		}
+	}
+	fmt.Fprintf(w, "[%d bytes of %s]\n", len(body), mediaType)
+}
+
+// RedactJSON replaces the value of every sensitive key, see
+// logging.IsSensitive, of a decoded JSON document
+func RedactJSON(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, child := range v {
+			if logging.IsSensitive(k) {
+				v[k] = logging.Redacted
+				continue
+			}
+			v[k] = RedactJSON(child)
+		}
+	case []any:
+		for i, child := range v {
+			v[i] = RedactJSON(child)
+		}
+	}
+	return v
+}
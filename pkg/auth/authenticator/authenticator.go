@@ -4,13 +4,11 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/http/cookiejar"
-	"net/http/httputil"
-	"net/url"
-	"regexp"
 	"time"
 
 	"github.com/google/uuid"
@@ -18,21 +16,25 @@ import (
 	"github.com/vpnda/wsfetch/pkg/auth/cfetch"
 	"github.com/vpnda/wsfetch/pkg/auth/types"
 	"github.com/vpnda/wsfetch/pkg/endpoints"
+	"github.com/vpnda/wsfetch/pkg/logging"
+	"github.com/vpnda/wsfetch/pkg/ratelimit"
 	"go.uber.org/zap"
-)
-
-var (
-	log   = lo.Must(zap.NewProduction()).Sugar()
-	wsUrl = lo.Must(url.Parse("https://wealthsimple.com"))
+	"go.uber.org/zap/zapcore"
 )
 
 type AuthPayloadCreator interface {
 	AuthPayload(clientId string) ([]byte, error)
 	Profile() string
+	RequestedScopes() []types.Scope
+
+	// ConfiguredProfile is the profile the session is named after,
+	// empty when left to Wealthsimple
+	ConfiguredProfile() string
 }
 
 type Client interface {
 	Authenticate(ctx context.Context, creds AuthPayloadCreator) (*types.Session, error)
+	Revoke(ctx context.Context, sess *types.Session) error
 }
 
 type client struct {
@@ -57,39 +59,69 @@ type client struct {
 	// 2FA fetcher
 	codeFetcher types.TwoFactorCodeFetcher
 
+	// Cache of discovered client ids, optional
+	clientIdCache ClientIdCache
+
+	// How long a cached client id is trusted before
+	// discovering it again
+	clientIdMaxAge time.Duration
+
 	// client with authenticated information
 	client *http.Client
 
 	// Transport used for requests
 	transport http.RoundTripper
+
+	// Limiter every request waits on, shared by the
+	// whole process unless overridden
+	limiter *ratelimit.Limiter
+
+	// Endpoints requests are sent to
+	endpoints endpoints.Config
+
+	// User agent sent on every request, if set
+	userAgent string
+
+	// Clock used for expiries and request dates
+	now func() time.Time
+
+	log *zap.SugaredLogger
 }
 
-func NewClient() Client {
+func NewClient(opts ...Option) Client {
 	c := &client{
 		ShouldRemember2FA: true,
 	}
-	newFromExisting(c)
+	newFromExisting(c, opts...)
 	return c
 }
 
-func NewClientFromSession(s *types.Session) Client {
+func NewClientFromSession(s *types.Session, opts ...Option) Client {
 	c := &client{
 		ShouldRemember2FA: true,
 		WSSID:             s.WSSID,
 		ClientId:          s.ClientId,
 		RefreshOtpToken:   s.RefreshOtpToken,
 	}
-	newFromExisting(c)
+	newFromExisting(c, opts...)
 	return c
 }
 
-func newFromExisting(c *client) {
+func newFromExisting(c *client, opts ...Option) {
 	c.client = &http.Client{
 		Jar:       lo.Must(cookiejar.New(&cookiejar.Options{})),
 		Transport: c,
 	}
 	c.codeFetcher = cfetch.NewCli()
 	c.transport = http.DefaultTransport
+	c.endpoints = endpoints.Default
+	c.limiter = ratelimit.Auth
+	c.now = time.Now
+	c.clientIdMaxAge = DefaultClientIdMaxAge
+	c.log = logging.Nop().Sugar()
+	for _, opt := range opts {
+		opt(c)
+	}
 }
 
 var (
@@ -105,93 +137,39 @@ func (c *client) fetchWssidIfNotSet(ctx context.Context) error {
 	}
 
 	// call "my.wealthsimple.com/app/login" to get cookie
-	req, err := http.NewRequestWithContext(ctx, endpoints.MyWealthsimpleLoginSplash.Method, endpoints.MyWealthsimpleLoginSplash.String(), nil)
+	req, err := c.newRequest(ctx, endpoints.MyWealthsimpleLoginSplash, nil)
 	if err != nil {
 		return err
 	}
 
 	resp, err := c.client.Do(req)
 	if err != nil {
-		return err
+		return &NetworkError{Op: "fetching device id", Err: err}
 	}
-	log.Infow("Queried for wssdi", "respStatus", resp.Status)
+	resp.Body.Close()
+	c.log.Infow("Queried for wssdi", "respStatus", resp.Status)
 
 	// find wssdi cookie
-	wssidCookie, wssFound := lo.Find(c.client.Jar.Cookies(wsUrl), func(cookie *http.Cookie) bool {
+	wssidCookie, wssFound := lo.Find(c.client.Jar.Cookies(req.URL), func(cookie *http.Cookie) bool {
 		return cookie.Name == "wssdi"
 	})
 
 	if !wssFound {
-		return fmt.Errorf("wssdi not found in first request: %v", c.client.Jar.Cookies(wsUrl))
+		return fmt.Errorf("wssdi not found in first request: %v", c.client.Jar.Cookies(req.URL))
 	}
 
 	c.WSSID = wssidCookie.Value
-	log.Infow("Id for client is now", "id", c.WSSID)
-	return nil
-}
-
-func (c *client) fetchClientIdIfNotSet(ctx context.Context) error {
-	if c.ClientId != "" {
-		return nil
-	}
-
-	// call "my.wealthsimple.com/app/login" to get cookie
-	req, err := http.NewRequestWithContext(ctx, endpoints.MyWealthsimpleLoginSplash.Method, endpoints.MyWealthsimpleLoginSplash.String(), nil)
-	if err != nil {
-		return err
-	}
-
-	resp, err := c.client.Do(req)
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
-
-	bits, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return err
-	}
-	responseStr := string(bits)
-
-	re := regexp.MustCompile(`(?i)<script.*src="(.+/app-[a-f0-9]+\.js)`)
-	matches := re.FindStringSubmatch(responseStr)
-	if len(matches) <= 1 {
-		return fmt.Errorf("couldn't find app JS URL in login page response body")
-	}
-
-	appJSURL := matches[1]
-
-	// go to the app url to find the clientId
-	req, err = http.NewRequestWithContext(ctx, http.MethodGet, appJSURL, nil)
-	if err != nil {
-		return err
-	}
-	resp, err = c.client.Do(req)
-	if err != nil {
-		return err
-	}
-
-	bits, err = io.ReadAll(resp.Body)
-	if err != nil {
-		return err
-	}
-	responseStr = string(bits)
-
-	// Look for clientId in the app JS file
-	re = regexp.MustCompile(`(?i)production:.*clientId:"([a-f0-9]+)"`)
-	matches = re.FindStringSubmatch(responseStr)
-	if len(matches) <= 1 {
-		return fmt.Errorf("couldn't find clientId in app JS file response body")
-	}
-	c.ClientId = matches[1]
-	log.Infow("Using clientId", "clientId", c.ClientId)
+	c.log.Debugw("Id for client is now", "wssid", c.WSSID)
 	return nil
 }
 
 // Authenticate is the main entry point where we call the auth API
 // to fetch credentials
 func (c *client) Authenticate(ctx context.Context, creds AuthPayloadCreator) (*types.Session, error) {
-	log.Infow("Starting authentication", "creds", creds)
+	c.log.Infow("Starting authentication", "profile", creds.Profile())
+	if m, ok := creds.(zapcore.ObjectMarshaler); ok {
+		c.log.Debugw("Authenticating with credentials", "creds", m)
+	}
 
 	err := c.fetchWssidIfNotSet(ctx)
 	if err != nil {
@@ -208,10 +186,10 @@ func (c *client) Authenticate(ctx context.Context, creds AuthPayloadCreator) (*t
 		return nil, err
 	}
 
-	log.Infow("Sending request credential payload", "payload", string(tokenReqBody))
+	c.log.Debugw("Sending request credential payload", "clientId", c.ClientId)
 
 	// Try to get the bearer token by providing the credentials
-	req, err := http.NewRequestWithContext(ctx, endpoints.AuthToken.Method, endpoints.AuthToken.String(), bytes.NewBuffer(tokenReqBody))
+	req, err := c.newRequest(ctx, endpoints.AuthToken, bytes.NewBuffer(tokenReqBody))
 	if err != nil {
 		return nil, err
 	}
@@ -219,56 +197,116 @@ func (c *client) Authenticate(ctx context.Context, creds AuthPayloadCreator) (*t
 	req.Header.Add("x-ws-profile", creds.Profile())
 	resp, err := c.client.Do(req)
 	if err != nil {
-		return nil, err
+		return nil, &NetworkError{Op: "requesting token", Err: err}
 	}
 
-	log.Infow("First attempt at getting token", "respStatus", resp.Status)
+	c.log.Infow("First attempt at getting token", "respStatus", resp.Status)
 	if resp.StatusCode == http.StatusUnauthorized {
 		// parse header to see if its 2FA the issue
 		twoFaHeader, err := Parse2FAHeaders(resp.Header)
 		if err != nil {
+			resp.Body.Close()
 			return nil, fmt.Errorf("error parsing 2FA: %w", err)
 		}
 
 		// fail the problem is not 2FA
 		if !twoFaHeader.Required {
-			dreq, _ := httputil.DumpRequest(req, false)
-			dr, _ := httputil.DumpResponse(resp, true)
-			return nil, fmt.Errorf("unable to authenticate, req: %s, res: %s", string(dreq), string(dr))
+			return nil, c.tokenError(resp, creds, false)
 		}
+		resp.Body.Close()
 
 		resp, err = c.resolve2FA(ctx, twoFaHeader, tokenReqBody)
 		if err != nil {
-			return nil, err
+			var netErr *NetworkError
+			if errors.As(err, &netErr) {
+				return nil, err
+			}
+			return nil, fmt.Errorf("%w: %w", ErrTwoFactorRequired, err)
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			return nil, c.tokenError(resp, creds, true)
 		}
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		dr, _ := httputil.DumpResponse(resp, true)
-		return nil, fmt.Errorf("unable to authorize request: %s", dr)
+		return nil, c.tokenError(resp, creds, false)
 	}
 
 	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
 	if err != nil {
-		return nil, err
+		return nil, &NetworkError{Op: "reading token", Err: err}
 	}
 
-	s, err := ParseSessionFromBody(body)
+	s, err := parseSessionFromBody(body, c.now())
 	if err != nil {
 		return nil, err
 	}
 	s.ClientId = c.ClientId
 	s.WSSID = c.WSSID
-	log.Infow("Resolved session", "sessionCreds", s)
+	s.RefreshOtpToken = c.RefreshOtpToken
+	if claims, err := s.Claims(); err == nil {
+		if len(s.Scopes) == 0 {
+			s.Scopes = claims.Scopes
+		}
+		if skew, ok := s.ClockSkew(); ok && skew.Abs() > maxClockSkew {
+			c.log.Warnw("Local clock is out of sync with Wealthsimple", "skew", skew.String())
+		}
+	}
+	if len(s.Scopes) == 0 {
+		// the token response didn't say, assume we got what we asked for
+		s.Scopes = creds.RequestedScopes()
+	}
+	s.ProfileName = creds.ConfiguredProfile()
+	c.log.Infow("Resolved session", "session", s)
 	return s, nil
 
 }
 
+// Revoke invalidates the refresh token of the session, and with it
+// every access token issued from it
+func (c *client) Revoke(ctx context.Context, sess *types.Session) error {
+	clientId := sess.ClientId
+	if clientId == "" {
+		clientId = c.ClientId
+	}
+	body, err := json.Marshal(map[string]string{
+		"token":     sess.RefreshToken,
+		"client_id": clientId,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := c.newRequest(ctx, endpoints.AuthRevoke, bytes.NewBuffer(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+sess.AccessToken)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return &NetworkError{Op: "revoking session", Err: err}
+	}
+
+	c.log.Infow("Revoked session", "respStatus", resp.Status)
+	switch resp.StatusCode {
+	case http.StatusOK:
+		resp.Body.Close()
+		return nil
+	case http.StatusTooManyRequests:
+		return newAuthError(resp, ErrRateLimited, c.now())
+	default:
+		return newAuthError(resp, ErrUnexpectedResponse, c.now())
+	}
+}
+
 func (c *client) resolve2FA(ctx context.Context, twoFaHeader types.TwoFactorAuthRequest, authPayload []byte) (*http.Response, error) {
-	log.Infow("Handling 2FA", "2FAHeader", twoFaHeader)
+	c.log.Infow("Handling 2FA", "twoFactor", twoFaHeader)
 
 	// acutally authorize the token with this request
-	req, err := http.NewRequestWithContext(ctx, endpoints.AuthToken.Method, endpoints.AuthToken.String(), bytes.NewBuffer(authPayload))
+	req, err := c.newRequest(ctx, endpoints.AuthToken, bytes.NewBuffer(authPayload))
 	if err != nil {
 		return nil, err
 	}
@@ -282,7 +320,7 @@ func (c *client) resolve2FA(ctx context.Context, twoFaHeader types.TwoFactorAuth
 
 	resp, err := c.client.Do(req)
 	if err != nil {
-		return nil, err
+		return nil, &NetworkError{Op: "requesting token with 2FA code", Err: err}
 	}
 
 	if resp.StatusCode == http.StatusOK && c.ShouldRemember2FA {
@@ -292,33 +330,56 @@ func (c *client) resolve2FA(ctx context.Context, twoFaHeader types.TwoFactorAuth
 	return resp, nil
 }
 
+// maxClockSkew is how far the local clock can drift from the server's
+// before the user is warned about it
+const maxClockSkew = time.Minute
+
 func (c *client) RoundTrip(req *http.Request) (*http.Response, error) {
 	ExtendHeaders(&req.Header, c.WSSID, c.RefreshOtpToken)
+	if c.userAgent != "" {
+		req.Header.Set("User-Agent", c.userAgent)
+	}
+	if c.limiter != nil {
+		if err := c.limiter.Wait(req.Context()); err != nil {
+			return nil, err
+		}
+	}
 	return c.transport.RoundTrip(req)
 }
 
+// newRequest creates a request for the route on the configured endpoints
+func (c *client) newRequest(ctx context.Context, r endpoints.Route, body io.Reader) (*http.Request, error) {
+	return http.NewRequestWithContext(ctx, r.Method, c.endpoints.URL(r).String(), body)
+}
+
 func ParseSessionFromBody(body []byte) (*types.Session, error) {
+	return parseSessionFromBody(body, time.Now())
+}
+
+func parseSessionFromBody(body []byte, now time.Time) (*types.Session, error) {
 	type serializedInput struct {
 		AccessToken      string `json:"access_token"`
 		RefreshToken     string `json:"refresh_token"`
 		ExpiresInSeconds int    `json:"expires_in"`
+		Scope            string `json:"scope"`
 	}
 	var st serializedInput
 	err := json.Unmarshal(body, &st)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("%w: invalid token response: %w", ErrUnexpectedResponse, err)
 	}
 
 	if st.AccessToken == "" || st.ExpiresInSeconds == 0 {
-		return nil, fmt.Errorf("unable to parse session (%+v), %s", st, string(body))
+		return nil, fmt.Errorf("%w: token response is missing the access token or its expiry", ErrUnexpectedResponse)
 	}
 
-	exp := time.Now().Add(time.Duration(st.ExpiresInSeconds) * time.Second)
+	exp := now.Add(time.Duration(st.ExpiresInSeconds) * time.Second)
 	return &types.Session{
 		AccessToken:  st.AccessToken,
 		RefreshToken: st.RefreshToken,
 		Expiry:       &exp,
 		SessionId:    uuid.Must(uuid.NewRandom()).String(),
+		Scopes:       types.ParseScopes(st.Scope),
 	}, nil
 
 }
@@ -5,7 +5,9 @@ import (
 	"errors"
 	"io"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
 	"testing"
 	"time"
 
@@ -59,13 +61,8 @@ func Test_Client_2FA(t *testing.T) {
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
 			g := NewWithT(t)
-			c := &client{
-				ShouldRemember2FA: tc.rememberMe,
-				RefreshOtpToken:   tc.remeberMeToken,
-			}
-			newFromExisting(c)
 			callCount := 0
-			c.codeFetcher = types.TwoFactorCodeFetcherFunc(func(twoFaHeader types.TwoFactorAuthRequest) (string, error) {
+			codeFetcher := types.TwoFactorCodeFetcherFunc(func(twoFaHeader types.TwoFactorAuthRequest) (string, error) {
 				g.Expect(twoFaHeader.AuthenticatedClaim).To(Equal(testAuthClaim))
 				if tc.codeFetchErr != nil {
 					return "", tc.codeFetchErr
@@ -74,7 +71,7 @@ func Test_Client_2FA(t *testing.T) {
 			})
 
 			// handle the first request call
-			c.transport = httputil.RoundTripperFunc(func(r *http.Request) (*http.Response, error) {
+			transport := httputil.RoundTripperFunc(func(r *http.Request) (*http.Response, error) {
 				g.Expect(r.URL.Host).To(ContainSubstring(endpoints.RootHostname))
 				g.Expect(r.URL.Path).To(Equal(endpoints.AuthToken.Path))
 
@@ -94,6 +91,13 @@ func Test_Client_2FA(t *testing.T) {
 				}, nil
 			})
 
+			c := NewClientFromSession(&types.Session{RefreshOtpToken: tc.remeberMeToken},
+				WithRemember2FA(tc.rememberMe),
+				WithCodeFetcher(codeFetcher),
+				WithRateLimiter(nil),
+				WithTransport(transport),
+			).(*client)
+
 			// trigger
 			resp, err := c.resolve2FA(ctx, types.TwoFactorAuthRequest{
 				AuthenticatedClaim: testAuthClaim,
@@ -117,6 +121,64 @@ func Test_Client_2FA(t *testing.T) {
 	}
 }
 
+// closeTracker records whether a response body was closed
+type closeTracker struct {
+	io.Reader
+	closed bool
+}
+
+func (c *closeTracker) Close() error {
+	c.closed = true
+	return nil
+}
+
+func Test_Client_Authenticate_Malformed2FAClosesBody(t *testing.T) {
+	g := NewWithT(t)
+	body := &closeTracker{Reader: strings.NewReader(`{"error":"invalid_grant"}`)}
+
+	c := NewClientFromSession(&types.Session{WSSID: "someWssid"},
+		WithClientId("someClientId"),
+		WithRateLimiter(nil),
+		WithTransport(httputil.RoundTripperFunc(func(r *http.Request) (*http.Response, error) {
+			headers := http.Header{}
+			headers.Add("x-wealthsimple-otp-required", "true")
+			return &http.Response{StatusCode: http.StatusUnauthorized, Header: headers, Body: body}, nil
+		})),
+	)
+
+	_, err := c.Authenticate(context.Background(), types.PasswordCredentials{Username: "someone", Password: "somePassword"})
+	g.Expect(err).To(MatchError(ErrUnexpectedResponse))
+	g.Expect(body.closed).To(BeTrue())
+}
+
+// wrappedCreds is a credential type the authenticator doesn't know of
+type wrappedCreds struct {
+	types.PasswordCredentials
+}
+
+func Test_Client_Authenticate_ProfileName(t *testing.T) {
+	g := NewWithT(t)
+	transport := httputil.RoundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		g.Expect(r.URL.Path).To(Equal(endpoints.AuthToken.Path))
+		g.Expect(r.Header.Get("x-ws-profile")).To(Equal("trade"))
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{},
+			Body:       io.NopCloser(strings.NewReader(`{"access_token":"someAccessToken","refresh_token":"someRefreshToken","expires_in":3600}`)),
+		}, nil
+	})
+	c := NewClientFromSession(&types.Session{WSSID: "someWssid", ClientId: "someClientId"},
+		WithRateLimiter(nil),
+		WithTransport(transport),
+	)
+
+	sess, err := c.Authenticate(context.Background(), wrappedCreds{types.PasswordCredentials{
+		Username: "someone", Password: "somePassword", ProfileName: "trade",
+	}})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(sess.ProfileName).To(Equal("trade"))
+}
+
 func Test_ParseSessionFromBody(t *testing.T) {
 	g := NewWithT(t)
 	// setup
@@ -130,4 +192,38 @@ func Test_ParseSessionFromBody(t *testing.T) {
 	g.Expect(sess.RefreshToken).ToNot(BeEmpty())
 	g.Expect(sess.AccessToken).ToNot(BeEmpty())
 	g.Expect(time.Until(*sess.Expiry)).Should(BeNumerically("~", 3500*time.Second, 3700*time.Second))
+	g.Expect(sess.Scopes).To(Equal([]types.Scope{types.ScopeInvestRead, types.ScopeTradeRead, types.ScopeTaxRead}))
+}
+
+func Test_Client_Options(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+	now := time.Date(2024, 5, 7, 3, 46, 16, 0, time.UTC)
+	apiBase, _ := url.Parse("http://localhost:8080/ws")
+
+	c := NewClient(
+		WithBaseURLs(nil, apiBase),
+		WithClock(func() time.Time { return now }),
+		WithUserAgent("wsfetch-test"),
+		WithRateLimiter(nil),
+		WithTransport(httputil.RoundTripperFunc(func(r *http.Request) (*http.Response, error) {
+			g.Expect(r.URL.String()).To(Equal("http://localhost:8080/ws" + endpoints.AuthRevoke.Path))
+			g.Expect(r.Header.Get("User-Agent")).To(Equal("wsfetch-test"))
+			// the retry date is read against the clock of the client
+			return &http.Response{
+				StatusCode: http.StatusTooManyRequests,
+				Header:     http.Header{"Retry-After": []string{now.Add(30 * time.Second).Format(http.TimeFormat)}},
+				Body:       http.NoBody,
+			}, nil
+		})),
+	)
+
+	err := c.Revoke(ctx, &types.Session{RefreshToken: "someRefreshToken"})
+	var authErr *AuthError
+	g.Expect(errors.As(err, &authErr)).To(BeTrue())
+	g.Expect(authErr.RetryAfter).To(Equal(30 * time.Second))
+
+	sess, err := parseSessionFromBody([]byte(`{"access_token":"a","expires_in":60}`), now)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(*sess.Expiry).To(Equal(now.Add(time.Minute)))
 }
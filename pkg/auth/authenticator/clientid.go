@@ -0,0 +1,286 @@
+package authenticator
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/vpnda/wsfetch/pkg/endpoints"
+)
+
+// DefaultClientIdMaxAge is how long a cached client id is used
+// before being discovered again
+const DefaultClientIdMaxAge = 7 * 24 * time.Hour
+
+var (
+	// appBundleRe finds the main app bundle in the login page
+	appBundleRe = regexp.MustCompile(`(?i)<script[^>]*src="([^"]*/app-[a-f0-9]+\.js)"`)
+
+	// scriptRe finds every script of the login page
+	scriptRe = regexp.MustCompile(`(?i)<script[^>]*src="([^"]+\.js)"`)
+
+	// clientIdRes are tried in order against the page and scripts,
+	// from the most to the least specific
+	clientIdRes = []*regexp.Regexp{
+		regexp.MustCompile(`(?i)production:.*?clientId:"([a-f0-9]+)"`),
+		regexp.MustCompile(`(?i)clientId\s*[:=]\s*["']([a-f0-9]{32,})["']`),
+		regexp.MustCompile(`(?i)["']?client_id["']?\s*[:=]\s*["']([a-f0-9]{32,})["']`),
+	}
+)
+
+// ClientIdCache persists the client id once discovered
+type ClientIdCache interface {
+	LoadClientId() (clientId string, discoveredAt time.Time, ok bool)
+	StoreClientId(clientId string, discoveredAt time.Time) error
+}
+
+// DiscoveryFailure is a single failed client id discovery step
+type DiscoveryFailure struct {
+	// Strategy that failed, eg app-bundle
+	Strategy string
+	// Step of the strategy that failed, eg fetching the login page
+	Step string
+	Err  error
+}
+
+func (f DiscoveryFailure) Error() string {
+	return fmt.Sprintf("%s: %s: %s", f.Strategy, f.Step, f.Err)
+}
+
+func (f DiscoveryFailure) Unwrap() error {
+	return f.Err
+}
+
+// ClientIdDiscoveryError is returned when no strategy was able to
+// find the client id, it lists why each of them failed
+type ClientIdDiscoveryError struct {
+	Failures []DiscoveryFailure
+}
+
+func (e *ClientIdDiscoveryError) Error() string {
+	msgs := make([]string, len(e.Failures))
+	for i, f := range e.Failures {
+		msgs[i] = f.Error()
+	}
+	return fmt.Sprintf("unable to discover clientId (%s), set it explicitly with --client-id or WSFETCH_CLIENT_ID",
+		strings.Join(msgs, "; "))
+}
+
+func (e *ClientIdDiscoveryError) Unwrap() []error {
+	errs := make([]error, len(e.Failures))
+	for i, f := range e.Failures {
+		errs[i] = f
+	}
+	return errs
+}
+
+// clientIdStrategy finds the client id given the body of the login page
+type clientIdStrategy struct {
+	name string
+	find func(ctx context.Context, c *client, page *url.URL, body string) (string, *DiscoveryFailure)
+}
+
+var clientIdStrategies = []clientIdStrategy{
+	{name: "app-bundle", find: findClientIdInAppBundle},
+	{name: "scripts", find: findClientIdInScripts},
+	{name: "login-page", find: findClientIdInPage},
+}
+
+// fetchClientIdIfNotSet resolves the client id, trying in order an explicit
+// value, a fresh cached value and every discovery strategy. A stale cached
+// value is used as a last resort.
+func (c *client) fetchClientIdIfNotSet(ctx context.Context) error {
+	if c.ClientId != "" {
+		return nil
+	}
+
+	var cached string
+	if c.clientIdCache != nil {
+		id, discoveredAt, ok := c.clientIdCache.LoadClientId()
+		if ok && c.now().Sub(discoveredAt) < c.clientIdMaxAge {
+			c.ClientId = id
+			c.log.Infow("Using cached clientId", "clientId", id, "discoveredAt", discoveredAt)
+			return nil
+		}
+		cached = id
+	}
+
+	id, err := c.discoverClientId(ctx)
+	if err != nil {
+		if cached == "" {
+			return err
+		}
+		c.log.Warnw("Unable to discover clientId, using stale cached one", "clientId", cached, "error", err)
+		c.ClientId = cached
+		return nil
+	}
+
+	c.ClientId = id
+	c.log.Infow("Using clientId", "clientId", c.ClientId)
+	if c.clientIdCache != nil {
+		if err := c.clientIdCache.StoreClientId(id, c.now()); err != nil {
+			c.log.Warnw("Unable to cache clientId", "error", err)
+		}
+	}
+	return nil
+}
+
+func (c *client) discoverClientId(ctx context.Context) (string, error) {
+	req, err := c.newRequest(ctx, endpoints.MyWealthsimpleLoginSplash, nil)
+	if err != nil {
+		return "", err
+	}
+	body, err := c.fetchText(req)
+	if err != nil {
+		return "", &ClientIdDiscoveryError{Failures: []DiscoveryFailure{
+			{Strategy: "all", Step: "fetching login page", Err: err},
+		}}
+	}
+
+	discoveryErr := &ClientIdDiscoveryError{}
+	for _, strategy := range clientIdStrategies {
+		id, failure := strategy.find(ctx, c, req.URL, body)
+		if failure == nil {
+			c.log.Debugw("Discovered clientId", "strategy", strategy.name)
+			return id, nil
+		}
+		failure.Strategy = strategy.name
+		c.log.Debugw("ClientId discovery strategy failed", "strategy", strategy.name, "error", failure)
+		discoveryErr.Failures = append(discoveryErr.Failures, *failure)
+	}
+	return "", discoveryErr
+}
+
+// findClientIdInAppBundle looks for the clientId in the app-*.js bundle
+func findClientIdInAppBundle(ctx context.Context, c *client, page *url.URL, body string) (string, *DiscoveryFailure) {
+	matches := appBundleRe.FindStringSubmatch(body)
+	if len(matches) <= 1 {
+		return "", &DiscoveryFailure{Step: "finding app JS URL in login page", Err: errors.New("no app-*.js script")}
+	}
+	return c.findClientIdInScript(ctx, page, matches[1])
+}
+
+// findClientIdInScripts looks for the clientId in every script of
+// the login page, for when the bundle gets renamed
+func findClientIdInScripts(ctx context.Context, c *client, page *url.URL, body string) (string, *DiscoveryFailure) {
+	scripts := scriptRe.FindAllStringSubmatch(body, -1)
+	if len(scripts) == 0 {
+		return "", &DiscoveryFailure{Step: "finding scripts in login page", Err: errors.New("no scripts")}
+	}
+
+	var last *DiscoveryFailure
+	for _, script := range scripts {
+		id, failure := c.findClientIdInScript(ctx, page, script[1])
+		if failure == nil {
+			return id, nil
+		}
+		last = failure
+	}
+	return "", &DiscoveryFailure{
+		Step: fmt.Sprintf("searching %d scripts", len(scripts)),
+		Err:  fmt.Errorf("last script: %s: %w", last.Step, last.Err),
+	}
+}
+
+// findClientIdInPage looks for the clientId inlined in the login page
+func findClientIdInPage(_ context.Context, _ *client, _ *url.URL, body string) (string, *DiscoveryFailure) {
+	id, ok := matchClientId(body)
+	if !ok {
+		return "", &DiscoveryFailure{Step: "matching clientId in login page", Err: errors.New("no match")}
+	}
+	return id, nil
+}
+
+func (c *client) findClientIdInScript(ctx context.Context, page *url.URL, src string) (string, *DiscoveryFailure) {
+	scriptURL, err := page.Parse(src)
+	if err != nil {
+		return "", &DiscoveryFailure{Step: "parsing script URL " + src, Err: err}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, scriptURL.String(), nil)
+	if err != nil {
+		return "", &DiscoveryFailure{Step: "fetching " + scriptURL.String(), Err: err}
+	}
+	body, err := c.fetchText(req)
+	if err != nil {
+		return "", &DiscoveryFailure{Step: "fetching " + scriptURL.String(), Err: err}
+	}
+
+	id, ok := matchClientId(body)
+	if !ok {
+		return "", &DiscoveryFailure{Step: "matching clientId in " + scriptURL.String(), Err: errors.New("no match")}
+	}
+	return id, nil
+}
+
+func (c *client) fetchText(req *http.Request) (string, error) {
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	bits, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	return string(bits), nil
+}
+
+func matchClientId(s string) (string, bool) {
+	for _, re := range clientIdRes {
+		if matches := re.FindStringSubmatch(s); len(matches) > 1 {
+			return matches[1], true
+		}
+	}
+	return "", false
+}
+
+// FileClientIdCache caches the client id in a JSON file
+type FileClientIdCache struct {
+	Path string
+}
+
+var _ ClientIdCache = &FileClientIdCache{}
+
+type cachedClientId struct {
+	ClientId     string    `json:"clientId"`
+	DiscoveredAt time.Time `json:"discoveredAt"`
+}
+
+// LoadClientId implements ClientIdCache.
+func (f *FileClientIdCache) LoadClientId() (string, time.Time, bool) {
+	bits, err := os.ReadFile(f.Path)
+	if err != nil {
+		return "", time.Time{}, false
+	}
+	var cached cachedClientId
+	if err := json.Unmarshal(bits, &cached); err != nil || cached.ClientId == "" {
+		return "", time.Time{}, false
+	}
+	return cached.ClientId, cached.DiscoveredAt, true
+}
+
+// StoreClientId implements ClientIdCache.
+func (f *FileClientIdCache) StoreClientId(clientId string, discoveredAt time.Time) error {
+	bits, err := json.Marshal(cachedClientId{ClientId: clientId, DiscoveredAt: discoveredAt})
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(f.Path), 0o700); err != nil {
+		return err
+	}
+	return os.WriteFile(f.Path, bits, 0o600)
+}
@@ -0,0 +1,139 @@
+package authenticator
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/samber/lo"
+	"github.com/vpnda/wsfetch/internal/httputil"
+)
+
+func Test_Client_FetchClientId(t *testing.T) {
+	const (
+		testClientId  = "e123edb34b8bb66baeefbeef07275cc5"
+		testCachedId  = "0000edb34b8bb66baeefbeef07275cc5"
+		bundleScript  = `<script src="https://cdn.example.com/app-0123abcd.js"></script>`
+		renamedScript = `<script defer src="/assets/main.js"></script>`
+		jsWithId      = `var a={production:{clientId:"` + testClientId + `"}}`
+	)
+	now := time.Date(2024, 5, 7, 0, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name          string
+		loginPage     string
+		scripts       map[string]string
+		cachedAt      *time.Time
+		expectedId    string
+		expectedSteps []string
+	}{
+		{
+			name:       "app bundle",
+			loginPage:  bundleScript,
+			scripts:    map[string]string{"/app-0123abcd.js": jsWithId},
+			expectedId: testClientId,
+		},
+		{
+			name:       "renamed bundle",
+			loginPage:  renamedScript,
+			scripts:    map[string]string{"/assets/main.js": `window.config = {clientId: "` + testClientId + `"}`},
+			expectedId: testClientId,
+		},
+		{
+			name:       "inlined in login page",
+			loginPage:  `<script>window.__CONFIG__={"client_id":"` + testClientId + `"}</script>`,
+			expectedId: testClientId,
+		},
+		{
+			name:       "fresh cache",
+			cachedAt:   lo.ToPtr(now.Add(-time.Hour)),
+			expectedId: testCachedId,
+		},
+		{
+			name:       "stale cache refreshed",
+			loginPage:  bundleScript,
+			scripts:    map[string]string{"/app-0123abcd.js": jsWithId},
+			cachedAt:   lo.ToPtr(now.Add(-30 * 24 * time.Hour)),
+			expectedId: testClientId,
+		},
+		{
+			name:       "stale cache used as last resort",
+			loginPage:  "<html></html>",
+			cachedAt:   lo.ToPtr(now.Add(-30 * 24 * time.Hour)),
+			expectedId: testCachedId,
+		},
+		{
+			name:      "every strategy fails",
+			loginPage: renamedScript,
+			expectedSteps: []string{
+				"app-bundle: finding app JS URL in login page",
+				"scripts: searching 1 scripts",
+				"login-page: matching clientId in login page",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			cache := &FileClientIdCache{Path: filepath.Join(t.TempDir(), "clientid.json")}
+			if tc.cachedAt != nil {
+				g.Expect(cache.StoreClientId(testCachedId, *tc.cachedAt)).To(Succeed())
+			}
+
+			c := NewClient(
+				WithClock(func() time.Time { return now }),
+				WithClientIdCache(cache, DefaultClientIdMaxAge),
+				WithRateLimiter(nil),
+				WithTransport(httputil.RoundTripperFunc(func(r *http.Request) (*http.Response, error) {
+					body, found := tc.loginPage, true
+					if r.URL.Path != "/app/login" {
+						body, found = tc.scripts[r.URL.Path]
+					}
+					if !found {
+						return &http.Response{StatusCode: http.StatusNotFound, Status: "404 Not Found", Body: http.NoBody}, nil
+					}
+					return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(body))}, nil
+				})),
+			).(*client)
+
+			err := c.fetchClientIdIfNotSet(context.Background())
+			if tc.expectedSteps != nil {
+				var discoveryErr *ClientIdDiscoveryError
+				g.Expect(errors.As(err, &discoveryErr)).To(BeTrue())
+				g.Expect(discoveryErr.Failures).To(HaveLen(len(tc.expectedSteps)))
+				for i, step := range tc.expectedSteps {
+					g.Expect(discoveryErr.Failures[i].Error()).To(HavePrefix(step))
+				}
+				g.Expect(err.Error()).To(ContainSubstring("--client-id"))
+				return
+			}
+
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(c.ClientId).To(Equal(tc.expectedId))
+			cachedId, _, ok := cache.LoadClientId()
+			g.Expect(ok).To(BeTrue())
+			g.Expect(cachedId).To(Equal(tc.expectedId))
+		})
+	}
+}
+
+func Test_Client_ClientIdOverride(t *testing.T) {
+	g := NewWithT(t)
+	c := NewClient(
+		WithClientId("someClientId"),
+		WithTransport(httputil.RoundTripperFunc(func(r *http.Request) (*http.Response, error) {
+			t.Fatalf("unexpected request to %s", r.URL)
+			return nil, nil
+		})),
+	).(*client)
+
+	g.Expect(c.fetchClientIdIfNotSet(context.Background())).To(Succeed())
+	g.Expect(c.ClientId).To(Equal("someClientId"))
+}
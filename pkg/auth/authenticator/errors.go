@@ -0,0 +1,118 @@
+package authenticator
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/vpnda/wsfetch/internal/httputil"
+	"github.com/vpnda/wsfetch/pkg/auth/types"
+)
+
+var (
+	// ErrInvalidCredentials is returned when the username or password is rejected
+	ErrInvalidCredentials = errors.New("invalid username or password")
+
+	// ErrTwoFactorRequired is returned when 2FA is required but no code
+	// could be obtained
+	ErrTwoFactorRequired = errors.New("two factor authentication required")
+
+	// ErrInvalidTwoFactorCode is returned when the 2FA code is rejected
+	ErrInvalidTwoFactorCode = errors.New("invalid two factor code")
+
+	// ErrRefreshTokenExpired is returned when the refresh token of a session
+	// is expired, revoked or was already used, logging in again is needed
+	ErrRefreshTokenExpired = errors.New("refresh token expired or revoked")
+
+	// ErrRateLimited is returned when Wealthsimple throttles us
+	ErrRateLimited = errors.New("rate limited by wealthsimple")
+
+	// ErrUnexpectedResponse is returned for any other failed response
+	ErrUnexpectedResponse = errors.New("unexpected response from wealthsimple")
+)
+
+// AuthError is a failed response of the OAuth endpoints, it wraps one
+// of the sentinel errors above so it can be checked with errors.Is
+type AuthError struct {
+	// Kind is the sentinel error describing the failure
+	Kind error
+
+	StatusCode int
+
+	// Code and Description are the OAuth error and error_description
+	Code        string
+	Description string
+
+	// RetryAfter is how long Wealthsimple asked us to wait, if it did
+	RetryAfter time.Duration
+}
+
+func (e *AuthError) Error() string {
+	msg := fmt.Sprintf("%s (status %d", e.Kind, e.StatusCode)
+	if e.Code != "" {
+		msg += ", " + e.Code
+	}
+	if e.Description != "" {
+		msg += ": " + e.Description
+	}
+	return msg + ")"
+}
+
+func (e *AuthError) Unwrap() error {
+	return e.Kind
+}
+
+// NetworkError is returned when a request couldn't get a response
+type NetworkError struct {
+	// Op describes what was being done, eg requesting token
+	Op  string
+	Err error
+}
+
+func (e *NetworkError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Op, e.Err)
+}
+
+func (e *NetworkError) Unwrap() error {
+	return e.Err
+}
+
+// newAuthError reads the failed response into an AuthError of the given kind
+func newAuthError(resp *http.Response, kind error, now time.Time) *AuthError {
+	defer resp.Body.Close()
+	e := &AuthError{Kind: kind, StatusCode: resp.StatusCode}
+	e.RetryAfter, _ = httputil.RetryAfter(resp.Header, now)
+
+	var oauthErr struct {
+		Error       string `json:"error"`
+		Description string `json:"error_description"`
+	}
+	bits, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if json.Unmarshal(bits, &oauthErr) == nil {
+		e.Code = oauthErr.Error
+		e.Description = oauthErr.Description
+	}
+	return e
+}
+
+// tokenError classifies a failed token request
+func (c *client) tokenError(resp *http.Response, creds AuthPayloadCreator, after2FA bool) error {
+	kind := ErrUnexpectedResponse
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+		kind = ErrRateLimited
+	case resp.StatusCode != http.StatusUnauthorized && resp.StatusCode != http.StatusBadRequest:
+	case after2FA:
+		kind = ErrInvalidTwoFactorCode
+	default:
+		if _, refreshing := creds.(*types.Session); refreshing {
+			kind = ErrRefreshTokenExpired
+		} else {
+			kind = ErrInvalidCredentials
+		}
+	}
+	return newAuthError(resp, kind, c.now())
+}
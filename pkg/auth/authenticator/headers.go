@@ -42,7 +42,7 @@ func Parse2FAHeaders(headers http.Header) (types.TwoFactorAuthRequest, error) {
 
 	if headers.Get("x-wealthsimple-otp-authenticated-claim") == "" ||
 		headers.Get("x-wealthsimple-otp") == "" {
-		return ret, fmt.Errorf("unable to parse auth claim: %v", headers)
+		return ret, fmt.Errorf("%w: 2FA required without an authenticated claim", ErrUnexpectedResponse)
 	}
 
 	ret.AuthenticatedClaim = headers.Get("x-wealthsimple-otp-authenticated-claim")
@@ -0,0 +1,109 @@
+package authenticator
+
+import (
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/vpnda/wsfetch/pkg/auth/types"
+	"github.com/vpnda/wsfetch/pkg/endpoints"
+	"github.com/vpnda/wsfetch/pkg/ratelimit"
+	"go.uber.org/zap"
+)
+
+// Option configures the authenticator client
+type Option func(*client)
+
+// WithLogger sets the logger used by the client, nothing is logged by default
+func WithLogger(l *zap.Logger) Option {
+	return func(c *client) {
+		c.log = l.Sugar()
+	}
+}
+
+// WithTransport sets the transport requests are sent through,
+// defaults to http.DefaultTransport
+func WithTransport(rt http.RoundTripper) Option {
+	return func(c *client) {
+		c.transport = rt
+	}
+}
+
+// WithRateLimiter sets the limiter every request waits on, defaults
+// to ratelimit.Auth which is shared by the whole process. A nil
+// limiter disables rate limiting.
+func WithRateLimiter(l *ratelimit.Limiter) Option {
+	return func(c *client) {
+		c.limiter = l
+	}
+}
+
+// WithCodeFetcher sets how 2FA codes are obtained, defaults to
+// prompting on the command line
+func WithCodeFetcher(f types.TwoFactorCodeFetcher) Option {
+	return func(c *client) {
+		c.codeFetcher = f
+	}
+}
+
+// WithRemember2FA configures whether Wealthsimple is asked to remember
+// the device after a successful 2FA, defaults to true
+func WithRemember2FA(remember bool) Option {
+	return func(c *client) {
+		c.ShouldRemember2FA = remember
+	}
+}
+
+// WithEndpoints sets where requests are sent, defaults to endpoints.Default
+func WithEndpoints(cfg endpoints.Config) Option {
+	return func(c *client) {
+		c.endpoints = cfg
+	}
+}
+
+// WithBaseURLs overrides the scheme and host of the my.wealthsimple.com
+// and api.production.wealthsimple.com endpoints, a nil URL keeps the
+// configured one
+func WithBaseURLs(myWealthsimple *url.URL, api *url.URL) Option {
+	return func(c *client) {
+		if myWealthsimple != nil {
+			c.endpoints.MyWealthsimple = *myWealthsimple
+		}
+		if api != nil {
+			c.endpoints.Api = *api
+		}
+	}
+}
+
+// WithClock sets the clock used to compute session expiries and
+// request dates, defaults to time.Now
+func WithClock(now func() time.Time) Option {
+	return func(c *client) {
+		c.now = now
+	}
+}
+
+// WithUserAgent sets the User-Agent header sent on every request
+func WithUserAgent(ua string) Option {
+	return func(c *client) {
+		c.userAgent = ua
+	}
+}
+
+// WithClientId sets the client id explicitly, skipping its discovery
+func WithClientId(clientId string) Option {
+	return func(c *client) {
+		if clientId != "" {
+			c.ClientId = clientId
+		}
+	}
+}
+
+// WithClientIdCache sets where discovered client ids are cached and
+// for how long they are trusted
+func WithClientIdCache(cache ClientIdCache, maxAge time.Duration) Option {
+	return func(c *client) {
+		c.clientIdCache = cache
+		c.clientIdMaxAge = maxAge
+	}
+}
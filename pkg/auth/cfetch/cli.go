@@ -15,7 +15,7 @@ type cli struct {
 
 func NewCli() *cli {
 	return &cli{
-		out: os.Stdout,
+		out: os.Stderr,
 		in:  os.Stdin,
 	}
 }
@@ -0,0 +1,9 @@
+package creds
+
+import "errors"
+
+var (
+	// ErrSessionExpired is returned by fetchers that can't refresh
+	// their session once it expires
+	ErrSessionExpired = errors.New("session has expired")
+)
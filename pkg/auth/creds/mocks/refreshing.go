@@ -0,0 +1,86 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: refreshing.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	types "github.com/vpnda/wsfetch/pkg/auth/types"
+)
+
+// MockSessionFetcher is a mock of SessionFetcher interface.
+type MockSessionFetcher struct {
+	ctrl     *gomock.Controller
+	recorder *MockSessionFetcherMockRecorder
+}
+
+// MockSessionFetcherMockRecorder is the mock recorder for MockSessionFetcher.
+type MockSessionFetcherMockRecorder struct {
+	mock *MockSessionFetcher
+}
+
+// NewMockSessionFetcher creates a new mock instance.
+func NewMockSessionFetcher(ctrl *gomock.Controller) *MockSessionFetcher {
+	mock := &MockSessionFetcher{ctrl: ctrl}
+	mock.recorder = &MockSessionFetcherMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSessionFetcher) EXPECT() *MockSessionFetcherMockRecorder {
+	return m.recorder
+}
+
+// GetSession mocks base method.
+func (m *MockSessionFetcher) GetSession(arg0 context.Context) (*types.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSession", arg0)
+	ret0, _ := ret[0].(*types.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSession indicates an expected call of GetSession.
+func (mr *MockSessionFetcherMockRecorder) GetSession(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSession", reflect.TypeOf((*MockSessionFetcher)(nil).GetSession), arg0)
+}
+
+// MockInvalidator is a mock of Invalidator interface.
+type MockInvalidator struct {
+	ctrl     *gomock.Controller
+	recorder *MockInvalidatorMockRecorder
+}
+
+// MockInvalidatorMockRecorder is the mock recorder for MockInvalidator.
+type MockInvalidatorMockRecorder struct {
+	mock *MockInvalidator
+}
+
+// NewMockInvalidator creates a new mock instance.
+func NewMockInvalidator(ctrl *gomock.Controller) *MockInvalidator {
+	mock := &MockInvalidator{ctrl: ctrl}
+	mock.recorder = &MockInvalidatorMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockInvalidator) EXPECT() *MockInvalidatorMockRecorder {
+	return m.recorder
+}
+
+// Invalidate mocks base method.
+func (m *MockInvalidator) Invalidate(arg0 *types.Session) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Invalidate", arg0)
+}
+
+// Invalidate indicates an expected call of Invalidate.
+func (mr *MockInvalidatorMockRecorder) Invalidate(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Invalidate", reflect.TypeOf((*MockInvalidator)(nil).Invalidate), arg0)
+}
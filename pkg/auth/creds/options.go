@@ -0,0 +1,36 @@
+package creds
+
+import (
+	"time"
+
+	"github.com/vpnda/wsfetch/pkg/auth/authenticator"
+	"go.uber.org/zap"
+)
+
+// Option configures the default fetcher
+type Option func(*defaultFetcher)
+
+// WithLogger sets the logger of the fetcher and of the authenticator
+// it refreshes sessions with
+func WithLogger(l *zap.Logger) Option {
+	return func(df *defaultFetcher) {
+		df.log = l.Sugar()
+		df.authOptions = append(df.authOptions, authenticator.WithLogger(l))
+	}
+}
+
+// WithAuthenticatorOptions sets the options of the authenticator
+// used to refresh sessions
+func WithAuthenticatorOptions(opts ...authenticator.Option) Option {
+	return func(df *defaultFetcher) {
+		df.authOptions = append(df.authOptions, opts...)
+	}
+}
+
+// WithRefreshTimeout bounds how long a re-authentication may take,
+// DefaultRefreshTimeout by default
+func WithRefreshTimeout(d time.Duration) Option {
+	return func(df *defaultFetcher) {
+		df.refreshTimeout = d
+	}
+}
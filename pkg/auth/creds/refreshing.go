@@ -2,63 +2,213 @@ package creds
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"sync"
 	"time"
 
 	"github.com/vpnda/wsfetch/pkg/auth/authenticator"
 	"github.com/vpnda/wsfetch/pkg/auth/types"
+	"github.com/vpnda/wsfetch/pkg/logging"
+	"go.uber.org/zap"
 )
 
+//go:generate mockgen.sh refreshing.go SessionFetcher,Invalidator
+
 // SessionFetcher fetches an active session
 type SessionFetcher interface {
 	GetSession(context.Context) (*types.Session, error)
 }
 
+// Invalidator is implemented by fetchers able to forget a session
+// that Wealthsimple no longer accepts, forcing the next GetSession
+// to re-authenticate
+type Invalidator interface {
+	Invalidate(*types.Session)
+}
+
 // DefaultFetcher must not be copied, it should be passed
-// by reference
+// by reference. It is safe for concurrent use, only one
+// re-authentication is in flight at any given time and
+// every caller waiting on it shares its result.
 type defaultFetcher struct {
-	creds        authenticator.AuthPayloadCreator
+	creds authenticator.AuthPayloadCreator
+
+	// newAuthClient builds the authenticator used to refresh
+	// the session, seeded with the last known session if any
+	newAuthClient func(*types.Session) authenticator.Client
+
+	// authOptions are passed to every authenticator client
+	authOptions []authenticator.Option
+
+	// refreshTimeout bounds a re-authentication, 2FA prompt included
+	refreshTimeout time.Duration
+
+	log *zap.SugaredLogger
+
+	mu           sync.Mutex
 	sessionCache *types.Session
+	inflight     *refreshCall
+}
+
+// refreshCall tracks a single re-authentication shared
+// by all the callers that asked for a session while it ran
+type refreshCall struct {
+	done chan struct{}
+	sess *types.Session
+	err  error
 }
 
+// DefaultRefreshTimeout is how long a re-authentication may take before
+// it is abandoned, long enough for a user to answer a 2FA prompt
+const DefaultRefreshTimeout = 5 * time.Minute
+
 var (
 	_ SessionFetcher = &defaultFetcher{}
+	_ Invalidator    = &defaultFetcher{}
 )
 
-func NewDefaultFetcher(pc types.PasswordCredentials) SessionFetcher {
-	return &defaultFetcher{
+func NewDefaultFetcher(pc types.PasswordCredentials, opts ...Option) SessionFetcher {
+	return newDefaultFetcher(&defaultFetcher{
 		creds: pc,
-	}
+	}, opts...)
 }
 
-func NewFetcherFromExistingSession(s *types.Session) SessionFetcher {
-	return &defaultFetcher{
+// NewDefaultFetcherForDevice authenticates with the password credentials
+// reusing a known device ID and remember-me OTP claim, that way Wealthsimple
+// recognizes the device and can skip 2FA
+func NewDefaultFetcherForDevice(pc types.PasswordCredentials, wssid string, otpClaim string, opts ...Option) SessionFetcher {
+	return newDefaultFetcher(&defaultFetcher{
+		creds: pc,
+		sessionCache: &types.Session{
+			WSSID:           wssid,
+			RefreshOtpToken: otpClaim,
+		},
+	}, opts...)
+}
+
+func NewFetcherFromExistingSession(s *types.Session, opts ...Option) SessionFetcher {
+	return newDefaultFetcher(&defaultFetcher{
 		sessionCache: s,
 		creds:        s,
+	}, opts...)
+}
+
+func newDefaultFetcher(df *defaultFetcher, opts ...Option) *defaultFetcher {
+	df.log = logging.Nop().Sugar()
+	df.newAuthClient = df.defaultAuthClient
+	df.refreshTimeout = DefaultRefreshTimeout
+	for _, opt := range opts {
+		opt(df)
+	}
+	return df
+}
+
+func (df *defaultFetcher) defaultAuthClient(s *types.Session) authenticator.Client {
+	if s != nil {
+		return authenticator.NewClientFromSession(s, df.authOptions...)
 	}
+	return authenticator.NewClient(df.authOptions...)
 }
 
 // GetSession gets credentials from a persistent client so it is easier to
 func (df *defaultFetcher) GetSession(ctx context.Context) (*types.Session, error) {
+	df.mu.Lock()
 	if isSessionActive(df.sessionCache) {
-		return df.sessionCache, nil
+		sess := df.sessionCache
+		df.mu.Unlock()
+		return sess, nil
 	}
 
-	var client authenticator.Client
-	if df.sessionCache != nil {
-		client = authenticator.NewClientFromSession(df.sessionCache)
-	} else {
-		client = authenticator.NewClient()
+	call := df.inflight
+	if call == nil {
+		call = &refreshCall{done: make(chan struct{})}
+		df.inflight = call
+		go df.refresh(call, df.sessionCache, df.refreshCreds())
 	}
+	df.mu.Unlock()
+
+	select {
+	case <-call.done:
+		return call.sess, call.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
 
-	sess, err := client.Authenticate(ctx, df.creds)
+// Invalidate marks sess as expired if it is still the cached session.
+// Sessions that were already replaced are ignored, that way concurrent
+// callers that saw the same rejection only trigger a single refresh.
+func (df *defaultFetcher) Invalidate(sess *types.Session) {
+	df.mu.Lock()
+	defer df.mu.Unlock()
+	if sess == nil || df.sessionCache != sess {
+		return
+	}
+	df.log.Debugw("Invalidating session", "session", sess)
+	stale := *sess
+	stale.Expiry = &time.Time{}
+	df.sessionCache = &stale
+}
+
+// refresh authenticates against Wealthsimple and publishes the result
+// to everyone waiting on call. It runs detached from the context of the
+// caller that started it so a cancelled caller doesn't fail the others,
+// bounded by the refresh timeout so a hung request doesn't block them
+// forever.
+func (df *defaultFetcher) refresh(call *refreshCall, last *types.Session, creds authenticator.AuthPayloadCreator) {
+	df.log.Infow("Refreshing session", "profile", creds.Profile())
+	ctx, cancel := context.WithTimeout(context.Background(), df.refreshTimeout)
+	defer cancel()
+	sess, err := df.newAuthClient(last).Authenticate(ctx, creds)
+	if pc, ok := df.passwordFallback(creds, err); ok {
+		df.log.Infow("Refresh token rejected, authenticating with the password", "profile", pc.Profile())
+		sess, err = df.newAuthClient(last).Authenticate(ctx, pc)
+	}
 	if err != nil {
-		return nil, err
+		df.log.Warnw("Unable to refresh session", "error", err)
+		err = fmt.Errorf("unable to refresh session: %w", err)
 	}
 
-	df.sessionCache = sess
-	return sess, nil
+	df.mu.Lock()
+	if err == nil {
+		df.sessionCache = sess
+	}
+	df.inflight = nil
+	df.mu.Unlock()
+
+	call.sess, call.err = sess, err
+	close(call.done)
+}
+
+// refreshCreds returns the credentials used for the next authentication.
+// Refresh tokens are single use, so once a session has been issued its
+// refresh token takes precedence over the ones we were created with.
+// Must be called with df.mu held.
+func (df *defaultFetcher) refreshCreds() authenticator.AuthPayloadCreator {
+	if df.sessionCache != nil && df.sessionCache.RefreshToken != "" {
+		return df.sessionCache
+	}
+	return df.creds
 }
 
+// passwordFallback returns the password credentials the fetcher was
+// created with when the refresh token of creds was rejected, a revoked
+// or expired refresh token would fail every refresh otherwise
+func (df *defaultFetcher) passwordFallback(creds authenticator.AuthPayloadCreator, err error) (types.PasswordCredentials, bool) {
+	if _, refreshing := creds.(*types.Session); !refreshing || !errors.Is(err, authenticator.ErrRefreshTokenExpired) {
+		return types.PasswordCredentials{}, false
+	}
+	pc, ok := df.creds.(types.PasswordCredentials)
+	return pc, ok && pc.Password != ""
+}
+
+// isSessionActive checks the session against both its local expiry and
+// the expiry claimed by its access token, when it is a JWT
 func isSessionActive(session *types.Session) bool {
-	return session != nil && session.Expiry != nil && time.Until(*session.Expiry) > 5*time.Second
+	if session == nil {
+		return false
+	}
+	expiry := session.EffectiveExpiry()
+	return expiry != nil && time.Until(*expiry) > 5*time.Second
 }
@@ -0,0 +1,224 @@
+package creds
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/vpnda/wsfetch/pkg/auth/authenticator"
+	"github.com/vpnda/wsfetch/pkg/auth/types"
+)
+
+type authenticatorFunc func(ctx context.Context, creds authenticator.AuthPayloadCreator) (*types.Session, error)
+
+func (f authenticatorFunc) Authenticate(ctx context.Context, creds authenticator.AuthPayloadCreator) (*types.Session, error) {
+	return f(ctx, creds)
+}
+
+func (f authenticatorFunc) Revoke(context.Context, *types.Session) error {
+	return nil
+}
+
+func Test_DefaultFetcher_SingleFlight(t *testing.T) {
+	var (
+		ctx     = context.Background()
+		expired = time.Now().Add(-time.Hour)
+		valid   = time.Now().Add(time.Hour)
+	)
+
+	testCases := []struct {
+		name    string
+		authErr error
+	}{
+		{
+			name: "concurrent callers share one refresh",
+		},
+		{
+			name:    "concurrent callers share the refresh error",
+			authErr: errors.New("some error"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			var calls atomic.Int32
+			release := make(chan struct{})
+
+			df := NewFetcherFromExistingSession(&types.Session{
+				AccessToken:  "stale",
+				RefreshToken: "someRefreshToken",
+				Expiry:       &expired,
+			}).(*defaultFetcher)
+			df.newAuthClient = func(s *types.Session) authenticator.Client {
+				return authenticatorFunc(func(ctx context.Context, creds authenticator.AuthPayloadCreator) (*types.Session, error) {
+					calls.Add(1)
+					<-release
+					if tc.authErr != nil {
+						return nil, tc.authErr
+					}
+					return &types.Session{
+						AccessToken:  "fresh",
+						RefreshToken: "someOtherRefreshToken",
+						Expiry:       &valid,
+					}, nil
+				})
+			}
+
+			const callers = 10
+			var wg sync.WaitGroup
+			sessions := make([]*types.Session, callers)
+			errs := make([]error, callers)
+			for i := 0; i < callers; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					sessions[i], errs[i] = df.GetSession(ctx)
+				}(i)
+			}
+
+			g.Eventually(calls.Load).Should(BeEquivalentTo(1))
+			close(release)
+			wg.Wait()
+
+			g.Expect(calls.Load()).To(BeEquivalentTo(1))
+			for i := 0; i < callers; i++ {
+				if tc.authErr != nil {
+					g.Expect(errs[i]).To(MatchError(tc.authErr))
+					continue
+				}
+				g.Expect(errs[i]).ToNot(HaveOccurred())
+				g.Expect(sessions[i].AccessToken).To(Equal("fresh"))
+			}
+		})
+	}
+}
+
+func Test_DefaultFetcher_UsesLatestRefreshToken(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+	expired := time.Now().Add(-time.Hour)
+
+	df := NewFetcherFromExistingSession(&types.Session{
+		RefreshToken: "first",
+		Expiry:       &expired,
+	}).(*defaultFetcher)
+
+	var seen []string
+	df.newAuthClient = func(s *types.Session) authenticator.Client {
+		return authenticatorFunc(func(ctx context.Context, creds authenticator.AuthPayloadCreator) (*types.Session, error) {
+			seen = append(seen, creds.(*types.Session).RefreshToken)
+			// hand out an already expired session to force another refresh
+			return &types.Session{
+				RefreshToken: "second",
+				Expiry:       &expired,
+			}, nil
+		})
+	}
+
+	_, err := df.GetSession(ctx)
+	g.Expect(err).ToNot(HaveOccurred())
+	_, err = df.GetSession(ctx)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(seen).To(Equal([]string{"first", "second"}))
+}
+
+func Test_DefaultFetcher_PasswordFallback(t *testing.T) {
+	var (
+		expired = time.Now().Add(-time.Hour)
+		valid   = time.Now().Add(time.Hour)
+	)
+
+	testCases := []struct {
+		name          string
+		fetcher       func() *defaultFetcher
+		expectedErr   error
+		expectedCalls []string
+	}{
+		{
+			name: "password used when the refresh token is rejected",
+			fetcher: func() *defaultFetcher {
+				df := NewDefaultFetcher(types.PasswordCredentials{Username: "someone", Password: "somePassword"}).(*defaultFetcher)
+				df.sessionCache = &types.Session{RefreshToken: "revoked", Expiry: &expired}
+				return df
+			},
+			expectedCalls: []string{"refresh_token", "password"},
+		},
+		{
+			name: "no password to fall back on",
+			fetcher: func() *defaultFetcher {
+				return NewFetcherFromExistingSession(&types.Session{RefreshToken: "revoked", Expiry: &expired}).(*defaultFetcher)
+			},
+			expectedErr:   authenticator.ErrRefreshTokenExpired,
+			expectedCalls: []string{"refresh_token"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			df := tc.fetcher()
+
+			var calls []string
+			df.newAuthClient = func(s *types.Session) authenticator.Client {
+				return authenticatorFunc(func(ctx context.Context, creds authenticator.AuthPayloadCreator) (*types.Session, error) {
+					if _, ok := creds.(*types.Session); ok {
+						calls = append(calls, "refresh_token")
+						return nil, authenticator.ErrRefreshTokenExpired
+					}
+					calls = append(calls, "password")
+					return &types.Session{AccessToken: "fresh", RefreshToken: "someRefreshToken", Expiry: &valid}, nil
+				})
+			}
+
+			sess, err := df.GetSession(context.Background())
+			g.Expect(calls).To(Equal(tc.expectedCalls))
+			if tc.expectedErr != nil {
+				g.Expect(err).To(MatchError(tc.expectedErr))
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(sess.AccessToken).To(Equal("fresh"))
+		})
+	}
+}
+
+func Test_DefaultFetcher_RefreshTimeout(t *testing.T) {
+	g := NewWithT(t)
+	expired := time.Now().Add(-time.Hour)
+
+	df := NewFetcherFromExistingSession(&types.Session{
+		RefreshToken: "someRefreshToken",
+		Expiry:       &expired,
+	}, WithRefreshTimeout(50*time.Millisecond)).(*defaultFetcher)
+	started := make(chan struct{})
+	df.newAuthClient = func(s *types.Session) authenticator.Client {
+		return authenticatorFunc(func(ctx context.Context, creds authenticator.AuthPayloadCreator) (*types.Session, error) {
+			close(started)
+			// a token request that never answers
+			<-ctx.Done()
+			return nil, ctx.Err()
+		})
+	}
+
+	// a waiter gives up when its own context is done, while the
+	// refresh goes on
+	ctx, cancel := context.WithCancel(context.Background())
+	errs := make(chan error, 1)
+	go func() {
+		_, err := df.GetSession(ctx)
+		errs <- err
+	}()
+	<-started
+	cancel()
+	g.Eventually(errs).Should(Receive(MatchError(context.Canceled)))
+
+	// the refresh is abandoned once the timeout elapses
+	_, err := df.GetSession(context.Background())
+	g.Expect(err).To(MatchError(context.DeadlineExceeded))
+	g.Expect(err).To(MatchError(ContainSubstring("unable to refresh session")))
+}
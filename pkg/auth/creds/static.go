@@ -2,7 +2,6 @@ package creds
 
 import (
 	"context"
-	"fmt"
 
 	"github.com/samber/lo"
 	"github.com/vpnda/wsfetch/pkg/auth/types"
@@ -16,8 +15,8 @@ var (
 
 // GetSession gets credentials from a persistent client so it is easier to
 func (t StaticTokenFetcher) GetSession(ctx context.Context) (*types.Session, error) {
-	if isSessionActive(lo.ToPtr(types.Session(t))) {
-		return nil, fmt.Errorf("session has expired")
+	if !isSessionActive(lo.ToPtr(types.Session(t))) {
+		return nil, ErrSessionExpired
 	}
 	return lo.ToPtr(types.Session(t)), nil
 }
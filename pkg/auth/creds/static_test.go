@@ -0,0 +1,52 @@
+package creds
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/vpnda/wsfetch/pkg/auth/types"
+)
+
+func Test_StaticTokenFetcher_GetSession(t *testing.T) {
+	var (
+		expired = time.Now().Add(-time.Hour)
+		valid   = time.Now().Add(time.Hour)
+	)
+
+	testCases := []struct {
+		name        string
+		expiry      *time.Time
+		expectedErr error
+	}{
+		{
+			name:   "active session",
+			expiry: &valid,
+		},
+		{
+			name:        "expired session",
+			expiry:      &expired,
+			expectedErr: ErrSessionExpired,
+		},
+		{
+			name:        "session without expiry",
+			expectedErr: ErrSessionExpired,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			fetcher := StaticTokenFetcher(types.Session{AccessToken: "someAccessToken", Expiry: tc.expiry})
+
+			sess, err := fetcher.GetSession(context.Background())
+			if tc.expectedErr != nil {
+				g.Expect(err).To(MatchError(tc.expectedErr))
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(sess.AccessToken).To(Equal("someAccessToken"))
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package types
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrNotJWT is returned when an access token isn't a JWT, its
+// claims can't be read locally then
+var ErrNotJWT = errors.New("access token is not a JWT")
+
+// TokenClaims are the claims of a JWT access token. They are decoded
+// locally without verifying the signature, so they must only be used
+// to inspect a token we already trust, never to authorize anything.
+type TokenClaims struct {
+	// Subject of the token, normally the identity
+	Subject string
+	// IdentityId is the canonical identity, falls back to the subject
+	IdentityId string
+	// UserId is the canonical user, if present
+	UserId string
+	// Issuer of the token
+	Issuer string
+
+	IssuedAt  *time.Time
+	ExpiresAt *time.Time
+	NotBefore *time.Time
+
+	// Scopes granted to the token
+	Scopes []Scope
+
+	// Profiles maps each profile to its client id
+	Profiles map[string]string
+
+	// Raw holds every claim as decoded
+	Raw map[string]any
+}
+
+// ParseTokenClaims decodes the payload of a JWT without verifying it
+func ParseTokenClaims(token string) (*TokenClaims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrNotJWT
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrNotJWT, err)
+	}
+
+	var raw map[string]any
+	if err := json.Unmarshal(payload, &raw); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrNotJWT, err)
+	}
+
+	claims := &TokenClaims{
+		Subject:    stringClaim(raw, "sub"),
+		IdentityId: stringClaim(raw, "identity_canonical_id"),
+		UserId:     stringClaim(raw, "user_canonical_id"),
+		Issuer:     stringClaim(raw, "iss"),
+		IssuedAt:   timeClaim(raw, "iat"),
+		ExpiresAt:  timeClaim(raw, "exp"),
+		NotBefore:  timeClaim(raw, "nbf"),
+		Profiles:   profilesClaim(raw["profiles"]),
+		Raw:        raw,
+	}
+	if claims.IdentityId == "" {
+		claims.IdentityId = claims.Subject
+	}
+
+	switch scope := raw["scope"].(type) {
+	case string:
+		claims.Scopes = ParseScopes(scope)
+	case []any:
+		claims.Scopes = scopeList(scope)
+	}
+	if scp, ok := raw["scp"].([]any); ok && len(claims.Scopes) == 0 {
+		claims.Scopes = scopeList(scp)
+	}
+	return claims, nil
+}
+
+// Claims decodes the claims of the access token
+func (s *Session) Claims() (*TokenClaims, error) {
+	return ParseTokenClaims(s.AccessToken)
+}
+
+// EffectiveExpiry is the earliest of the locally computed expiry and
+// the expiry claimed by the access token, nil if neither is known
+func (s *Session) EffectiveExpiry() *time.Time {
+	claims, err := s.Claims()
+	if err != nil || claims.ExpiresAt == nil {
+		return s.Expiry
+	}
+	if s.Expiry == nil || claims.ExpiresAt.Before(*s.Expiry) {
+		return claims.ExpiresAt
+	}
+	return s.Expiry
+}
+
+// ClockSkew estimates how far ahead the local clock was from the
+// server's when the session was issued. The local expiry is computed
+// from the local clock while the token one comes from the server, both
+// from the same lifetime. ok is false when it can't be estimated.
+func (s *Session) ClockSkew() (skew time.Duration, ok bool) {
+	claims, err := s.Claims()
+	if err != nil || claims.ExpiresAt == nil || s.Expiry == nil {
+		return 0, false
+	}
+	return s.Expiry.Sub(*claims.ExpiresAt), true
+}
+
+func stringClaim(raw map[string]any, key string) string {
+	s, _ := raw[key].(string)
+	return s
+}
+
+func timeClaim(raw map[string]any, key string) *time.Time {
+	v, ok := raw[key].(float64)
+	if !ok {
+		return nil
+	}
+	t := time.Unix(int64(v), 0)
+	return &t
+}
+
+func scopeList(l []any) []Scope {
+	var scopes []Scope
+	for _, v := range l {
+		if s, ok := v.(string); ok {
+			scopes = append(scopes, Scope(s))
+		}
+	}
+	return scopes
+}
+
+// profilesClaim accepts both {"invest": "client-id"} and the token info
+// shape {"invest": {"default": "client-id"}}
+func profilesClaim(v any) map[string]string {
+	m, ok := v.(map[string]any)
+	if !ok {
+		return nil
+	}
+	profiles := map[string]string{}
+	for profile, value := range m {
+		switch value := value.(type) {
+		case string:
+			profiles[profile] = value
+		case map[string]any:
+			profiles[profile], _ = value["default"].(string)
+		}
+	}
+	return profiles
+}
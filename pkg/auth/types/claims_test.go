@@ -0,0 +1,55 @@
+package types
+
+import (
+	"encoding/base64"
+	"errors"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+func testJWT(payload string) string {
+	enc := base64.RawURLEncoding
+	return enc.EncodeToString([]byte(`{"alg":"RS256"}`)) + "." +
+		enc.EncodeToString([]byte(payload)) + ".someSignature"
+}
+
+func Test_ParseTokenClaims(t *testing.T) {
+	g := NewWithT(t)
+
+	claims, err := ParseTokenClaims(testJWT(`{"sub":"identity-123","iat":1715053576,"exp":1715057176,` +
+		`"scope":"invest.read trade.read","profiles":{"trade":{"default":"user-abc"},"invest":"user-def"}}`))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(claims.IdentityId).To(Equal("identity-123"))
+	g.Expect(claims.IssuedAt.Unix()).To(BeEquivalentTo(1715053576))
+	g.Expect(claims.ExpiresAt.Unix()).To(BeEquivalentTo(1715057176))
+	g.Expect(claims.Scopes).To(Equal([]Scope{ScopeInvestRead, ScopeTradeRead}))
+	g.Expect(claims.Profiles).To(Equal(map[string]string{"trade": "user-abc", "invest": "user-def"}))
+
+	_, err = ParseTokenClaims("8lahDQ_5ock11Tjvf6tR_pfEEDbddPeaMtTHAo9S4Ds")
+	g.Expect(errors.Is(err, ErrNotJWT)).To(BeTrue())
+	_, err = ParseTokenClaims("eyJhbGciOiJSUzI1NiJ9.eyJzd.sig")
+	g.Expect(errors.Is(err, ErrNotJWT)).To(BeTrue())
+}
+
+func Test_Session_EffectiveExpiry(t *testing.T) {
+	g := NewWithT(t)
+	claimed := time.Unix(1715057176, 0)
+	later := claimed.Add(2 * time.Minute)
+
+	sess := &Session{AccessToken: testJWT(`{"exp":1715057176}`)}
+	g.Expect(*sess.EffectiveExpiry()).To(Equal(claimed))
+
+	// the local clock ran 2 minutes ahead of the server
+	sess.Expiry = &later
+	g.Expect(*sess.EffectiveExpiry()).To(Equal(claimed))
+	skew, ok := sess.ClockSkew()
+	g.Expect(ok).To(BeTrue())
+	g.Expect(skew).To(Equal(2 * time.Minute))
+
+	opaque := &Session{AccessToken: "opaque", Expiry: &later}
+	g.Expect(*opaque.EffectiveExpiry()).To(Equal(later))
+	_, ok = opaque.ClockSkew()
+	g.Expect(ok).To(BeFalse())
+}
@@ -1,11 +1,22 @@
 package types
 
-import "encoding/json"
+import (
+	"encoding/json"
+
+	"go.uber.org/zap/zapcore"
+)
 
 // PasswordCredentials represents the credentials that customers use
 type PasswordCredentials struct {
 	Username string
 	Password string
+
+	// Scopes requested for the session, DefaultScopes when empty
+	Scopes []Scope
+
+	// ProfileName is the Wealthsimple profile (eg invest, trade)
+	// to authenticate to, left undefined when empty
+	ProfileName string
 }
 
 func (pc PasswordCredentials) AuthPayload(clientId string) ([]byte, error) {
@@ -15,13 +26,36 @@ func (pc PasswordCredentials) AuthPayload(clientId string) ([]byte, error) {
 		"password":       pc.Password,
 		"skip_provision": true,
 		"otp_claim":      nil,
-		// TODO: remove unecessary permissions here
-		"scope": "invest.read trade.read tax.read",
+		"scope":          FormatScopes(pc.RequestedScopes()),
 		// magic string from WS
 		"client_id": clientId,
 	})
 }
 
 func (s PasswordCredentials) Profile() string {
+	if s.ProfileName != "" {
+		return s.ProfileName
+	}
 	return "undefined"
 }
+
+// ConfiguredProfile returns ProfileName, empty when left undefined
+func (pc PasswordCredentials) ConfiguredProfile() string {
+	return pc.ProfileName
+}
+
+// RequestedScopes returns the scopes asked for when authenticating
+func (pc PasswordCredentials) RequestedScopes() []Scope {
+	if len(pc.Scopes) != 0 {
+		return pc.Scopes
+	}
+	return DefaultScopes
+}
+
+// MarshalLogObject logs the credentials without the password or username
+func (pc PasswordCredentials) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("grantType", "password")
+	enc.AddBool("hasUsername", pc.Username != "")
+	enc.AddString("scope", FormatScopes(pc.RequestedScopes()))
+	return nil
+}
@@ -0,0 +1,39 @@
+package types
+
+import (
+	"strings"
+
+	"github.com/samber/lo"
+)
+
+// Scope is an OAuth scope granted to a session
+type Scope string
+
+const (
+	ScopeInvestRead Scope = "invest.read"
+	ScopeTradeRead  Scope = "trade.read"
+	ScopeTaxRead    Scope = "tax.read"
+)
+
+// DefaultScopes are requested when the credentials don't ask
+// for specific scopes
+var DefaultScopes = []Scope{ScopeInvestRead, ScopeTradeRead, ScopeTaxRead}
+
+// FormatScopes joins the scopes the way the OAuth endpoints expect them
+func FormatScopes(scopes []Scope) string {
+	return strings.Join(lo.Map(scopes, func(s Scope, _ int) string {
+		return string(s)
+	}), " ")
+}
+
+// ParseScopes splits a space separated list of scopes
+func ParseScopes(s string) []Scope {
+	return lo.Map(strings.Fields(s), func(s string, _ int) Scope {
+		return Scope(s)
+	})
+}
+
+// MissingScopes returns the required scopes that aren't granted
+func MissingScopes(granted []Scope, required ...Scope) []Scope {
+	return lo.Without(required, granted...)
+}
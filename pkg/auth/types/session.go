@@ -3,6 +3,8 @@ package types
 import (
 	"encoding/json"
 	"time"
+
+	"go.uber.org/zap/zapcore"
 )
 
 type Session struct {
@@ -27,16 +29,64 @@ type Session struct {
 
 	// Expiry
 	Expiry *time.Time
+
+	// Scopes granted to the session, empty for sessions
+	// stored before scopes were tracked
+	Scopes []Scope `json:",omitempty"`
+
+	// ProfileName is the Wealthsimple profile the session
+	// refreshes into, invest when empty
+	ProfileName string `json:",omitempty"`
 }
 
 func (s *Session) AuthPayload(clientId string) ([]byte, error) {
-	return json.Marshal(map[string]interface{}{
+	payload := map[string]interface{}{
 		"grant_type":    "refresh_token",
 		"refresh_token": s.RefreshToken,
 		"client_id":     clientId,
-	})
+	}
+	// refreshing must not widen a narrowly scoped session
+	if len(s.Scopes) != 0 {
+		payload["scope"] = FormatScopes(s.Scopes)
+	}
+	return json.Marshal(payload)
 }
 
 func (s *Session) Profile() string {
+	if s.ProfileName != "" {
+		return s.ProfileName
+	}
 	return "invest"
 }
+
+// ConfiguredProfile returns ProfileName, carried over to the
+// refreshed session
+func (s *Session) ConfiguredProfile() string {
+	return s.ProfileName
+}
+
+// RequestedScopes returns the scopes asked for when refreshing
+func (s *Session) RequestedScopes() []Scope {
+	return s.Scopes
+}
+
+// HasScopes reports whether every scope is granted. Sessions that don't
+// track their scopes are assumed to have them all.
+func (s *Session) HasScopes(scopes ...Scope) bool {
+	return len(s.Scopes) == 0 || len(MissingScopes(s.Scopes, scopes...)) == 0
+}
+
+// MarshalLogObject logs the session without any of its tokens
+func (s *Session) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("sessionId", s.SessionId)
+	enc.AddString("clientId", s.ClientId)
+	enc.AddString("wssid", s.WSSID)
+	enc.AddBool("hasRefreshToken", s.RefreshToken != "")
+	enc.AddBool("hasOtpClaim", s.RefreshOtpToken != "")
+	enc.AddString("scope", FormatScopes(s.Scopes))
+	enc.AddString("profile", s.Profile())
+	if s.Expiry != nil {
+		enc.AddTime("expiry", *s.Expiry)
+	}
+	return nil
+}
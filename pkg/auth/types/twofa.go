@@ -1,6 +1,10 @@
 package types
 
-import "net/http"
+import (
+	"net/http"
+
+	"go.uber.org/zap/zapcore"
+)
 
 // feches the 2FA code given a claim. The claim is
 // in JWT Format
@@ -26,3 +30,10 @@ type TwoFactorAuthRequest struct {
 func (t TwoFactorAuthRequest) InjectToHeaders(headers *http.Header) {
 	headers.Add("x-wealthsimple-otp-authenticated-claim", t.AuthenticatedClaim)
 }
+
+// MarshalLogObject logs the request without the authenticated claim
+func (t TwoFactorAuthRequest) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddBool("required", t.Required)
+	enc.AddString("method", t.Method)
+	return nil
+}
@@ -1,34 +1,55 @@
+// Package base is the HTTP client authenticated requests to Wealthsimple
+// are sent with. It hydrates the web session of my.wealthsimple.com,
+// recovers from rejected sessions and retries failed GraphQL queries.
+//
+// Requests go through a chain of middlewares wrapping the transport:
+// rate limiting, logging, then the ones given with WithMiddleware such
+// as tracing or recording. Retries aren't one of them on purpose. A
+// retry may wait for a minute when Wealthsimple asks for it, and must
+// then send a session that is still valid: the session is fetched again
+// before each attempt, refreshed when rejected and the jar hydrated with
+// it. A transport only sees the request as it was first authenticated,
+// so retries live in Do, around the session handling, and every attempt
+// goes through the whole chain, being traced and recorded like any other
+// request.
 package base
 
 import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
-	"net/http/cookiejar"
-	ghttputil "net/http/httputil"
 	"net/url"
+	"time"
 
 	"github.com/samber/lo"
 	"github.com/vpnda/wsfetch/internal/httputil"
+	"github.com/vpnda/wsfetch/pkg/auth/authenticator"
 	"github.com/vpnda/wsfetch/pkg/auth/creds"
 	"github.com/vpnda/wsfetch/pkg/auth/types"
 	"github.com/vpnda/wsfetch/pkg/endpoints"
+	"github.com/vpnda/wsfetch/pkg/logging"
+	"github.com/vpnda/wsfetch/pkg/ratelimit"
 	"go.uber.org/zap"
 )
 
-func DefaultAuthClient(pc types.PasswordCredentials) *Wealthsimple {
-	return AuthClientFromFetcher(creds.NewDefaultFetcher(pc))
+func DefaultAuthClient(pc types.PasswordCredentials, opts ...Option) *Wealthsimple {
+	c := newWealthsimple(opts...)
+	c.Fetcher = creds.NewDefaultFetcher(pc, c.fetcherOptions()...)
+	return c
 }
 
-func AuthClientFromSession(session *types.Session) *Wealthsimple {
-	return AuthClientFromFetcher(creds.NewFetcherFromExistingSession(session))
+func AuthClientFromSession(session *types.Session, opts ...Option) *Wealthsimple {
+	c := newWealthsimple(opts...)
+	c.Fetcher = creds.NewFetcherFromExistingSession(session, c.fetcherOptions()...)
+	return c
 }
 
-func StatictAuthClient(session types.Session) *Wealthsimple {
-	return AuthClientFromFetcher(creds.StaticTokenFetcher(session))
+func StatictAuthClient(session types.Session, opts ...Option) *Wealthsimple {
+	return AuthClientFromFetcher(creds.StaticTokenFetcher(session), opts...)
 }
 
 type WsProfile string
@@ -46,18 +67,39 @@ type Wealthsimple struct {
 	// internal http client
 	delegate *http.Client
 
-	// cooke jar
-	Jar http.CookieJar
+	// cookie jar of the web session, emptied when the session
+	// is rejected
+	jar *sessionJar
 
 	// Profile to authenticate to Wealthsimple, defaults
 	// to invest
 	Profile WsProfile
-}
 
-var (
-	log               = lo.Must(zap.NewProduction()).Sugar()
-	myWealthsimpleUrl = lo.Must(url.Parse(endpoints.MyWeathSimple))
-)
+	// endpoints requests are sent to
+	endpoints endpoints.Config
+
+	// transport sending the requests once they went through
+	// the rate limiter, the logger and middlewares in order
+	transport   http.RoundTripper
+	middlewares []Middleware
+
+	// options of the authenticator used by fetchers created
+	// along with the client
+	authOptions []authenticator.Option
+
+	// retry is the policy applied to failed GraphQL queries
+	retry RetryPolicy
+
+	// sleep waits between retries, swapped in tests
+	sleep func(context.Context, time.Duration) error
+
+	// limiters requests wait on, shared by the whole process
+	// unless overridden
+	authLimiter    *ratelimit.Limiter
+	graphqlLimiter *ratelimit.Limiter
+
+	log *zap.SugaredLogger
+}
 
 type TokenInformation struct {
 	UserId            string
@@ -65,13 +107,31 @@ type TokenInformation struct {
 	ProfileToClientId map[WsProfile]string
 }
 
+// TokenInformationFromClaims returns the identity the claims of a JWT
+// access token name, false when they don't name one
+func TokenInformationFromClaims(claims *types.TokenClaims) (*TokenInformation, bool) {
+	if claims.IdentityId == "" {
+		return nil, false
+	}
+	prfToClientId := map[WsProfile]string{}
+	for k, v := range claims.Profiles {
+		prfToClientId[WsProfile(k)] = v
+	}
+	return &TokenInformation{
+		UserId:            claims.UserId,
+		IdentityId:        claims.IdentityId,
+		ProfileToClientId: prfToClientId,
+	}, true
+}
+
 func (c *Wealthsimple) GetTokenInformation(ctx context.Context) (*TokenInformation, error) {
 	sess, err := c.Fetcher.GetSession(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to retrieve credentials: %w", err)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, endpoints.AuthTokenInfo.Method, endpoints.AuthTokenInfo.String(), nil)
+	req, err := http.NewRequestWithContext(ctx, endpoints.AuthTokenInfo.Method,
+		c.endpoints.URL(endpoints.AuthTokenInfo).String(), nil)
 	if err != nil {
 		return nil, err
 	}
@@ -79,12 +139,13 @@ func (c *Wealthsimple) GetTokenInformation(ctx context.Context) (*TokenInformati
 	c.populateStandardAndAuthHeaders(req, sess)
 	res, err := c.delegate.Do(req)
 	if err != nil {
-		return nil, err
+		return nil, &NetworkError{Op: "fetch token information", Err: err}
 	}
 
 	if res.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("cannot fetch client identifiers: %d", res.StatusCode)
+		return nil, newStatusError(res)
 	}
+	defer res.Body.Close()
 
 	bodyBits, err := io.ReadAll(res.Body)
 	if err != nil {
@@ -100,7 +161,7 @@ func (c *Wealthsimple) GetTokenInformation(ctx context.Context) (*TokenInformati
 	}
 	err = json.Unmarshal(bodyBits, &jsonResponse)
 	if err != nil {
-		return nil, fmt.Errorf("invalid response body: %s, error: %w", string(bodyBits), err)
+		return nil, fmt.Errorf("%w: invalid token information: %w", ErrUnexpectedResponse, err)
 	}
 
 	prfToClientId := map[WsProfile]string{}
@@ -115,19 +176,131 @@ func (c *Wealthsimple) GetTokenInformation(ctx context.Context) (*TokenInformati
 	}, nil
 }
 
+// Do sends an authenticated request to Wealthsimple. If the server rejects
+// the session, either with a 401 or a GraphQL authentication error, the
+// cached session is invalidated, refreshed once and the request replayed.
+// GraphQL queries failing with a transient error are retried following
+// the client's RetryPolicy.
 func (c *Wealthsimple) Do(r *http.Request) (*http.Response, error) {
+	getBody, err := replayableBody(r)
+	if err != nil {
+		return nil, fmt.Errorf("unable to buffer request body: %w", err)
+	}
+
+	operation, isQuery := graphqlOperation(getBody)
+	if !isQuery || c.retry.MaxAttempts <= 1 {
+		return c.doAuthenticated(r, getBody)
+	}
+
+	start := time.Now()
+	for attempt := 1; ; attempt++ {
+		res, err := c.doAuthenticated(r, getBody)
+		delay, retry := c.retry.retryDelay(attempt, time.Since(start), err)
+		if !retry {
+			return res, err
+		}
+
+		c.log.Infow("Retrying GraphQL query",
+			"operation", operation, "attempt", attempt, "delay", delay, "cause", err)
+		if err := c.sleep(r.Context(), delay); err != nil {
+			return nil, err
+		}
+		if r, err = cloneWithBody(r, getBody); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// doAuthenticated sends r once, recovering from a rejected session
+func (c *Wealthsimple) doAuthenticated(r *http.Request, getBody func() (io.ReadCloser, error)) (*http.Response, error) {
 	sess, err := c.Fetcher.GetSession(r.Context())
 	if err != nil {
 		return nil, fmt.Errorf("failed to retrieve credentials: %w", err)
 	}
 
-	err = c.hydrateSessionJar(r.Context(), r.URL, sess)
+	res, err := c.do(r, sess)
+	if err != nil && !errors.Is(err, errSessionRejected) {
+		return nil, err
+	}
+	if err == nil && !isAuthFailure(res) {
+		return checkStatus(res)
+	}
+
+	invalidator, ok := c.Fetcher.(creds.Invalidator)
+	if !ok {
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrUnauthorized, err)
+		}
+		return checkStatus(res)
+	}
+	if res != nil {
+		res.Body.Close()
+	}
+
+	c.log.Infow("Session rejected by Wealthsimple, refreshing", "targetHost", r.URL.Host)
+	invalidator.Invalidate(sess)
+	c.resetSessionJar()
+
+	sess, err = c.Fetcher.GetSession(r.Context())
+	if err != nil {
+		return nil, fmt.Errorf("failed to refresh credentials: %w", err)
+	}
+
+	replay, err := cloneWithBody(r, getBody)
+	if err != nil {
+		return nil, err
+	}
+	res, err = c.do(replay, sess)
+	if errors.Is(err, errSessionRejected) {
+		return nil, fmt.Errorf("%w: %w", ErrUnauthorized, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return checkStatus(res)
+}
+
+// cloneWithBody copies r with a fresh copy of its body
+func cloneWithBody(r *http.Request, getBody func() (io.ReadCloser, error)) (*http.Request, error) {
+	clone := r.Clone(r.Context())
+	body, err := getBody()
+	if err != nil {
+		return nil, err
+	}
+	clone.Body = body
+	return clone, nil
+}
+
+// checkStatus turns failed responses into a StatusError
+func checkStatus(res *http.Response) (*http.Response, error) {
+	if res.StatusCode >= http.StatusBadRequest {
+		return nil, newStatusError(res)
+	}
+	return res, nil
+}
+
+func (c *Wealthsimple) do(r *http.Request, sess *types.Session) (*http.Response, error) {
+	err := c.hydrateSessionJar(r.Context(), r.URL, sess)
 	if err != nil {
 		return nil, fmt.Errorf("error hydrating session jar: %w", err)
 	}
 
+	// the client adds the cookies of the jar to the request it sends, a
+	// copy is sent so replays don't carry the cookies of a stale session
+	r = r.Clone(r.Context())
 	c.populateStandardAndAuthHeaders(r, sess)
-	return c.delegate.Do(r)
+	res, err := c.delegate.Do(r)
+	if err != nil {
+		return nil, &NetworkError{Op: r.Method + " " + r.URL.Path, Err: err}
+	}
+	return res, nil
+}
+
+// resetSessionJar drops the _session_id cookie, along with the other
+// cookies of the jar, so the next request hydrates the jar again with
+// the refreshed access token
+func (c *Wealthsimple) resetSessionJar() {
+	c.jar.reset()
 }
 
 func (c *Wealthsimple) populateStandardAndAuthHeaders(req *http.Request, sess *types.Session) {
@@ -138,13 +311,13 @@ func (c *Wealthsimple) populateStandardAndAuthHeaders(req *http.Request, sess *t
 
 // TODO refactor this into a wrapper of th do method
 func (c *Wealthsimple) hydrateSessionJar(ctx context.Context, originalUrl *url.URL, sess *types.Session) error {
-	if originalUrl.Host != endpoints.MyWeathSimple {
-		log.Infow("Skipping hydration", "targetHost", originalUrl.Host)
+	if !c.endpoints.IsMyWealthsimple(originalUrl) {
+		c.log.Debugw("Skipping hydration", "targetHost", originalUrl.Host)
 		return nil
 	}
 
 	// If session ID is already in the cookie jar exit early
-	if myWsCookes := c.Jar.Cookies(myWealthsimpleUrl); len(myWsCookes) != 0 {
+	if myWsCookes := c.jar.Cookies(c.endpoints.URL(endpoints.MyWealthsimpleSession)); len(myWsCookes) != 0 {
 		_, sessionIdFound := lo.Find(myWsCookes, func(ck *http.Cookie) bool {
 			return ck.Name == "_session_id"
 		})
@@ -164,7 +337,7 @@ func (c *Wealthsimple) hydrateSessionJar(ctx context.Context, originalUrl *url.U
 	}
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost,
-		endpoints.MyWealthsimpleSession.String(), bytes.NewBuffer(serializedData))
+		c.endpoints.URL(endpoints.MyWealthsimpleSession).String(), bytes.NewBuffer(serializedData))
 	if err != nil {
 		return err
 	}
@@ -172,36 +345,76 @@ func (c *Wealthsimple) hydrateSessionJar(ctx context.Context, originalUrl *url.U
 	c.populateStandardAndAuthHeaders(req, sess)
 	res, err := c.delegate.Do(req)
 	if err != nil {
-		return err
+		return &NetworkError{Op: "hydrate session", Err: err}
+	}
+
+	if res.StatusCode == http.StatusUnauthorized {
+		res.Body.Close()
+		return errSessionRejected
 	}
 
 	if res.StatusCode != http.StatusOK {
-		d, _ := ghttputil.DumpResponse(res, true)
-		return fmt.Errorf("response has invalid code: %s", string(d))
+		return newStatusError(res)
 	}
 
+	res.Body.Close()
 	return nil
 }
 
-func AuthClientFromFetcher(fetcher creds.SessionFetcher) *Wealthsimple {
-	jar := lo.Must(cookiejar.New(nil))
-	return &Wealthsimple{
-		Fetcher: fetcher,
-		Jar:     jar,
+func AuthClientFromFetcher(fetcher creds.SessionFetcher, opts ...Option) *Wealthsimple {
+	c := newWealthsimple(opts...)
+	c.Fetcher = fetcher
+	return c
+}
+
+func newWealthsimple(opts ...Option) *Wealthsimple {
+	jar := newSessionJar()
+	c := &Wealthsimple{
+		jar: jar,
 		delegate: &http.Client{
 			Jar: jar,
-			// Uncomment the following lines for debugging HTTP requests/responses
-			// Transport: httputil.RoundTripperFunc(func(r *http.Request) (*http.Response, error) {
-			// 	d, _ := ghttputil.DumpRequest(r, true)
-			// 	fmt.Println(string(d))
-			// 	res, err := http.DefaultTransport.RoundTrip(r)
-			// 	if err == nil {
-			// 		d, _ := ghttputil.DumpResponse(res, true)
-			// 		fmt.Println(string(d))
-			// 	}
-			// 	return res, err
-			// }),
 		},
-		Profile: Invest,
+		Profile:        Invest,
+		endpoints:      endpoints.Default,
+		transport:      http.DefaultTransport,
+		retry:          DefaultRetryPolicy,
+		sleep:          sleepContext,
+		authLimiter:    ratelimit.Auth,
+		graphqlLimiter: ratelimit.GraphQL,
+		log:            logging.Nop().Sugar(),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	c.delegate.Transport = Chain(c.transport, append([]Middleware{
+		RateLimitMiddleware(c.limiterFor),
+		LoggingMiddleware(c.log.Desugar()),
+	}, c.middlewares...)...)
+	return c
+}
+
+// Endpoints returns where the client sends its requests
+func (c *Wealthsimple) Endpoints() endpoints.Config {
+	return c.endpoints
+}
+
+// limiterFor picks the budget of r, everything but GraphQL
+// requests counts against the authentication budget
+func (c *Wealthsimple) limiterFor(r *http.Request) *ratelimit.Limiter {
+	if c.endpoints.Is(r.URL, endpoints.MyWealthsimpleGetGraphQl) {
+		return c.graphqlLimiter
+	}
+	return c.authLimiter
+}
+
+// fetcherOptions are the options passed to the fetchers created for this client
+func (c *Wealthsimple) fetcherOptions() []creds.Option {
+	return []creds.Option{
+		creds.WithLogger(c.log.Desugar()),
+		creds.WithAuthenticatorOptions(append([]authenticator.Option{
+			authenticator.WithRateLimiter(c.authLimiter),
+			authenticator.WithEndpoints(c.endpoints),
+			authenticator.WithTransport(Chain(c.transport, c.middlewares...)),
+		}, c.authOptions...)...),
 	}
 }
@@ -0,0 +1,461 @@
+package base
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/vpnda/wsfetch/internal/httputil"
+	"github.com/vpnda/wsfetch/pkg/auth/creds"
+	"github.com/vpnda/wsfetch/pkg/auth/types"
+	"github.com/vpnda/wsfetch/pkg/endpoints"
+)
+
+type fakeFetcher struct {
+	sessions    []*types.Session
+	invalidated []*types.Session
+}
+
+func (f *fakeFetcher) GetSession(context.Context) (*types.Session, error) {
+	return f.sessions[len(f.invalidated)], nil
+}
+
+func (f *fakeFetcher) Invalidate(s *types.Session) {
+	f.invalidated = append(f.invalidated, s)
+}
+
+func Test_Wealthsimple_Do_RecoversFromAuthFailure(t *testing.T) {
+	const graphqlPayload = `{"query":"query FetchAllAccountFinancials { id }"}`
+	expiry := time.Now().Add(time.Hour)
+
+	testCases := []struct {
+		name         string
+		rejection    func() *http.Response
+		expectReplay bool
+	}{
+		{
+			name: "401 status",
+			rejection: func() *http.Response {
+				return &http.Response{StatusCode: http.StatusUnauthorized, Body: http.NoBody}
+			},
+			expectReplay: true,
+		},
+		{
+			name: "graphql authentication error",
+			rejection: func() *http.Response {
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Header:     http.Header{"Content-Type": []string{"application/json"}},
+					Body:       io.NopCloser(strings.NewReader(`{"errors":[{"message":"nope","extensions":{"code":"UNAUTHENTICATED"}}]}`)),
+				}
+			},
+			expectReplay: true,
+		},
+		{
+			name: "graphql unrelated error",
+			rejection: func() *http.Response {
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Header:     http.Header{"Content-Type": []string{"application/json"}},
+					Body:       io.NopCloser(strings.NewReader(`{"errors":[{"message":"bad field"}]}`)),
+				}
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			fetcher := &fakeFetcher{sessions: []*types.Session{
+				{AccessToken: "stale", Expiry: &expiry},
+				{AccessToken: "fresh", Expiry: &expiry},
+			}}
+			ws := AuthClientFromFetcher(fetcher)
+
+			var sessionHydrations, graphqlCalls int
+			ws.delegate.Transport = httputil.RoundTripperFunc(func(r *http.Request) (*http.Response, error) {
+				if r.URL.Path == endpoints.MyWealthsimpleSession.Path {
+					sessionHydrations++
+					return &http.Response{
+						StatusCode: http.StatusOK,
+						Header:     http.Header{"Set-Cookie": []string{"_session_id=abc; Path=/"}},
+						Body:       http.NoBody,
+						Request:    r,
+					}, nil
+				}
+
+				graphqlCalls++
+				body, err := io.ReadAll(r.Body)
+				g.Expect(err).ToNot(HaveOccurred())
+				g.Expect(string(body)).To(Equal(graphqlPayload))
+				if r.Header.Get("Authorization") == "Bearer stale" {
+					return tc.rejection(), nil
+				}
+				return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+			})
+
+			req, err := http.NewRequest(http.MethodPost, endpoints.MyWealthsimpleGetGraphQl.String(),
+				bytes.NewBufferString(graphqlPayload))
+			g.Expect(err).ToNot(HaveOccurred())
+
+			res, err := ws.Do(req)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(res.StatusCode).To(Equal(http.StatusOK))
+
+			if !tc.expectReplay {
+				g.Expect(graphqlCalls).To(Equal(1))
+				g.Expect(fetcher.invalidated).To(BeEmpty())
+				body, _ := io.ReadAll(res.Body)
+				g.Expect(string(body)).To(ContainSubstring("bad field"))
+				return
+			}
+
+			g.Expect(graphqlCalls).To(Equal(2))
+			g.Expect(fetcher.invalidated).To(HaveLen(1))
+			g.Expect(fetcher.invalidated[0].AccessToken).To(Equal("stale"))
+			// the session cookie was reset so the jar got hydrated again
+			g.Expect(sessionHydrations).To(Equal(2))
+		})
+	}
+}
+
+func Test_Wealthsimple_Do_ResetsSessionCookie(t *testing.T) {
+	testCases := []struct {
+		name      string
+		setCookie string
+	}{
+		{name: "host cookie", setCookie: "_session_id=%s; Path=/"},
+		{name: "domain cookie", setCookie: "_session_id=%s; Domain=wealthsimple.com; Path=/"},
+		{name: "path cookie", setCookie: "_session_id=%s; Path=/graphql"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			expiry := time.Now().Add(time.Hour)
+			fetcher := &fakeFetcher{sessions: []*types.Session{
+				{AccessToken: "stale", Expiry: &expiry},
+				{AccessToken: "fresh", Expiry: &expiry},
+			}}
+			ws := AuthClientFromFetcher(fetcher)
+
+			var sessionIds []string
+			ws.delegate.Transport = httputil.RoundTripperFunc(func(r *http.Request) (*http.Response, error) {
+				if r.URL.Path == endpoints.MyWealthsimpleSession.Path {
+					token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+					return &http.Response{
+						StatusCode: http.StatusOK,
+						Header:     http.Header{"Set-Cookie": []string{fmt.Sprintf(tc.setCookie, token)}},
+						Body:       http.NoBody,
+						Request:    r,
+					}, nil
+				}
+				cookie, err := r.Cookie("_session_id")
+				g.Expect(err).ToNot(HaveOccurred())
+				sessionIds = append(sessionIds, cookie.Value)
+				if cookie.Value == "stale" {
+					return &http.Response{StatusCode: http.StatusUnauthorized, Body: http.NoBody}, nil
+				}
+				return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+			})
+
+			req, err := http.NewRequest(http.MethodPost, endpoints.MyWealthsimpleGetGraphQl.String(), http.NoBody)
+			g.Expect(err).ToNot(HaveOccurred())
+			_, err = ws.Do(req)
+			g.Expect(err).ToNot(HaveOccurred())
+
+			// the replay only carries the session of the fresh token
+			g.Expect(sessionIds).To(Equal([]string{"stale", "fresh"}))
+		})
+	}
+}
+
+func Test_Wealthsimple_Do_StatusErrors(t *testing.T) {
+	expiry := time.Now().Add(time.Hour)
+
+	testCases := []struct {
+		name       string
+		statusCode int
+		header     http.Header
+		expected   error
+		retryAfter time.Duration
+	}{
+		{
+			name:       "still unauthorized after refresh",
+			statusCode: http.StatusUnauthorized,
+			expected:   ErrUnauthorized,
+		},
+		{
+			name:       "forbidden",
+			statusCode: http.StatusForbidden,
+			expected:   ErrForbidden,
+		},
+		{
+			name:       "rate limited",
+			statusCode: http.StatusTooManyRequests,
+			header:     http.Header{"Retry-After": []string{"30"}},
+			expected:   ErrRateLimited,
+			retryAfter: 30 * time.Second,
+		},
+		{
+			name:       "server error",
+			statusCode: http.StatusBadGateway,
+			expected:   ErrServerError,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			fetcher := &fakeFetcher{sessions: []*types.Session{
+				{AccessToken: "stale", Expiry: &expiry},
+				{AccessToken: "fresh", Expiry: &expiry},
+			}}
+			ws := AuthClientFromFetcher(fetcher)
+			ws.delegate.Transport = httputil.RoundTripperFunc(func(r *http.Request) (*http.Response, error) {
+				if r.URL.Path == endpoints.MyWealthsimpleSession.Path {
+					return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Request: r}, nil
+				}
+				return &http.Response{
+					StatusCode: tc.statusCode,
+					Status:     http.StatusText(tc.statusCode),
+					Header:     tc.header,
+					Body:       io.NopCloser(strings.NewReader("secret details")),
+					Request:    r,
+				}, nil
+			})
+
+			req, err := http.NewRequest(http.MethodPost, endpoints.MyWealthsimpleGetGraphQl.String(), http.NoBody)
+			g.Expect(err).ToNot(HaveOccurred())
+
+			_, err = ws.Do(req)
+			g.Expect(err).To(MatchError(tc.expected))
+
+			var statusErr *StatusError
+			g.Expect(errors.As(err, &statusErr)).To(BeTrue())
+			g.Expect(statusErr.StatusCode).To(Equal(tc.statusCode))
+			g.Expect(statusErr.RetryAfter).To(Equal(tc.retryAfter))
+			g.Expect(statusErr.Error()).ToNot(ContainSubstring("secret details"))
+		})
+	}
+}
+
+func Test_Wealthsimple_Do_Retries(t *testing.T) {
+	const (
+		queryPayload    = `{"operationName":"FetchActivityFeedItems","query":"query FetchActivityFeedItems { id }"}`
+		mutationPayload = `{"operationName":"CreateTransfer","query":"mutation CreateTransfer { id }"}`
+	)
+	expiry := time.Now().Add(time.Hour)
+
+	testCases := []struct {
+		name          string
+		payload       string
+		failures      []func() (*http.Response, error)
+		policy        RetryPolicy
+		expectedCalls int
+		expectedErr   error
+		minDelay      time.Duration
+	}{
+		{
+			name:    "query recovers from a bad gateway",
+			payload: queryPayload,
+			failures: []func() (*http.Response, error){
+				func() (*http.Response, error) {
+					return &http.Response{StatusCode: http.StatusBadGateway, Body: http.NoBody}, nil
+				},
+			},
+			policy:        DefaultRetryPolicy,
+			expectedCalls: 2,
+		},
+		{
+			name:    "query recovers from a connection reset",
+			payload: queryPayload,
+			failures: []func() (*http.Response, error){
+				func() (*http.Response, error) { return nil, errors.New("connection reset by peer") },
+			},
+			policy:        DefaultRetryPolicy,
+			expectedCalls: 2,
+		},
+		{
+			name:    "retry after is honoured",
+			payload: queryPayload,
+			failures: []func() (*http.Response, error){
+				func() (*http.Response, error) {
+					return &http.Response{
+						StatusCode: http.StatusTooManyRequests,
+						Header:     http.Header{"Retry-After": []string{"20"}},
+						Body:       http.NoBody,
+					}, nil
+				},
+			},
+			policy:        DefaultRetryPolicy,
+			expectedCalls: 2,
+			minDelay:      20 * time.Second,
+		},
+		{
+			name:    "retry after past the elapsed budget gives up",
+			payload: queryPayload,
+			failures: []func() (*http.Response, error){
+				func() (*http.Response, error) {
+					return &http.Response{
+						StatusCode: http.StatusTooManyRequests,
+						Header:     http.Header{"Retry-After": []string{"120"}},
+						Body:       http.NoBody,
+					}, nil
+				},
+			},
+			policy:        DefaultRetryPolicy,
+			expectedCalls: 1,
+			expectedErr:   ErrRateLimited,
+		},
+		{
+			name:    "attempts are capped",
+			payload: queryPayload,
+			failures: []func() (*http.Response, error){
+				func() (*http.Response, error) {
+					return &http.Response{StatusCode: http.StatusServiceUnavailable, Body: http.NoBody}, nil
+				},
+				func() (*http.Response, error) {
+					return &http.Response{StatusCode: http.StatusServiceUnavailable, Body: http.NoBody}, nil
+				},
+			},
+			policy:        RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond},
+			expectedCalls: 2,
+			expectedErr:   ErrServerError,
+		},
+		{
+			name:    "mutations are not retried",
+			payload: mutationPayload,
+			failures: []func() (*http.Response, error){
+				func() (*http.Response, error) {
+					return &http.Response{StatusCode: http.StatusBadGateway, Body: http.NoBody}, nil
+				},
+			},
+			policy:        DefaultRetryPolicy,
+			expectedCalls: 1,
+			expectedErr:   ErrServerError,
+		},
+		{
+			name:    "client errors are not retried",
+			payload: queryPayload,
+			failures: []func() (*http.Response, error){
+				func() (*http.Response, error) {
+					return &http.Response{StatusCode: http.StatusBadRequest, Body: http.NoBody}, nil
+				},
+			},
+			policy:        DefaultRetryPolicy,
+			expectedCalls: 1,
+			expectedErr:   ErrUnexpectedResponse,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			ws := AuthClientFromFetcher(creds.StaticTokenFetcher(types.Session{
+				AccessToken: "someAccessToken",
+				Expiry:      &expiry,
+			}), WithRetryPolicy(tc.policy))
+
+			var delays []time.Duration
+			ws.sleep = func(_ context.Context, d time.Duration) error {
+				delays = append(delays, d)
+				return nil
+			}
+
+			var calls int
+			ws.delegate.Transport = httputil.RoundTripperFunc(func(r *http.Request) (*http.Response, error) {
+				if r.URL.Path == endpoints.MyWealthsimpleSession.Path {
+					return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Request: r}, nil
+				}
+
+				calls++
+				body, err := io.ReadAll(r.Body)
+				g.Expect(err).ToNot(HaveOccurred())
+				g.Expect(string(body)).To(Equal(tc.payload))
+
+				if calls <= len(tc.failures) {
+					res, err := tc.failures[calls-1]()
+					if res != nil {
+						res.Request = r
+					}
+					return res, err
+				}
+				return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Request: r}, nil
+			})
+
+			req, err := http.NewRequest(http.MethodPost, endpoints.MyWealthsimpleGetGraphQl.String(),
+				bytes.NewBufferString(tc.payload))
+			g.Expect(err).ToNot(HaveOccurred())
+
+			res, err := ws.Do(req)
+			g.Expect(calls).To(Equal(tc.expectedCalls))
+			g.Expect(delays).To(HaveLen(tc.expectedCalls - 1))
+			if tc.expectedErr != nil {
+				g.Expect(err).To(MatchError(tc.expectedErr))
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(res.StatusCode).To(Equal(http.StatusOK))
+			for _, d := range delays {
+				g.Expect(d).To(BeNumerically(">=", tc.minDelay))
+			}
+		})
+	}
+}
+
+func Test_Wealthsimple_Endpoints(t *testing.T) {
+	g := NewWithT(t)
+	expiry := time.Now().Add(time.Hour)
+
+	var paths []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		switch r.URL.Path {
+		case "/ws" + endpoints.AuthTokenInfo.Path:
+			_, _ = w.Write([]byte(`{"identity_canonical_id":"identity-1","user_canonical_id":"user-1"}`))
+		case "/ws" + endpoints.MyWealthsimpleSession.Path:
+			http.SetCookie(w, &http.Cookie{Name: "_session_id", Value: "someSessionId", Path: "/"})
+		case "/ws" + endpoints.MyWealthsimpleGetGraphQl.Path:
+			_, _ = w.Write([]byte(`{"data":{}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	cfg, err := endpoints.ParseBase(srv.URL + "/ws")
+	g.Expect(err).ToNot(HaveOccurred())
+	ws := StatictAuthClient(types.Session{AccessToken: "someAccessToken", Expiry: &expiry},
+		WithEndpoints(cfg),
+		WithRateLimiters(nil, nil),
+	)
+
+	info, err := ws.GetTokenInformation(context.Background())
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(info.IdentityId).To(Equal("identity-1"))
+
+	for i := 0; i < 2; i++ {
+		req, err := http.NewRequest(http.MethodPost, cfg.URL(endpoints.MyWealthsimpleGetGraphQl).String(),
+			strings.NewReader(`{"query":"query FetchIdentity { id }"}`))
+		g.Expect(err).ToNot(HaveOccurred())
+		_, err = ws.Do(req)
+		g.Expect(err).ToNot(HaveOccurred())
+	}
+
+	// the web session is hydrated once then kept in the jar
+	g.Expect(paths).To(Equal([]string{
+		"/ws" + endpoints.AuthTokenInfo.Path,
+		"/ws" + endpoints.MyWealthsimpleSession.Path,
+		"/ws" + endpoints.MyWealthsimpleGetGraphQl.Path,
+		"/ws" + endpoints.MyWealthsimpleGetGraphQl.Path,
+	}))
+}
@@ -0,0 +1,100 @@
+package base
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/vpnda/wsfetch/internal/httputil"
+	"github.com/vpnda/wsfetch/pkg/auth/authenticator"
+)
+
+var (
+	// ErrUnauthorized is returned when Wealthsimple keeps rejecting
+	// the session, even after refreshing it
+	ErrUnauthorized = errors.New("unauthorized by wealthsimple")
+
+	// ErrForbidden is returned when the session isn't allowed to
+	// access the resource
+	ErrForbidden = errors.New("forbidden by wealthsimple")
+
+	// ErrNotFound is returned when the resource doesn't exist
+	ErrNotFound = errors.New("not found")
+
+	// ErrServerError is returned when Wealthsimple fails to serve the request
+	ErrServerError = errors.New("wealthsimple server error")
+
+	// ErrRateLimited is returned when Wealthsimple throttles us, it is
+	// the same error the authenticator returns
+	ErrRateLimited = authenticator.ErrRateLimited
+
+	// ErrUnexpectedResponse is returned for any other failed response
+	ErrUnexpectedResponse = authenticator.ErrUnexpectedResponse
+)
+
+// NetworkError is returned when a request couldn't get a response
+type NetworkError = authenticator.NetworkError
+
+// StatusError is a response with a failed status code, it wraps one of
+// the sentinel errors above so it can be checked with errors.Is. The
+// body is kept for debugging but left out of the message as it may hold
+// personal information.
+type StatusError struct {
+	Kind       error
+	Method     string
+	URL        string
+	StatusCode int
+	Status     string
+
+	// RetryAfter is how long Wealthsimple asked us to wait, if it did
+	RetryAfter time.Duration
+
+	// Body holds the start of the response body
+	Body []byte
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s: %s %s returned %s", e.Kind, e.Method, e.URL, e.Status)
+}
+
+func (e *StatusError) Unwrap() error {
+	return e.Kind
+}
+
+// newStatusError consumes the failed response into a StatusError
+func newStatusError(res *http.Response) *StatusError {
+	defer res.Body.Close()
+	e := &StatusError{
+		Kind:       statusKind(res.StatusCode),
+		StatusCode: res.StatusCode,
+		Status:     res.Status,
+	}
+	if res.Request != nil {
+		e.Method = res.Request.Method
+		u := *res.Request.URL
+		u.RawQuery = ""
+		e.URL = u.String()
+	}
+	e.RetryAfter, _ = httputil.RetryAfter(res.Header, time.Now())
+	e.Body, _ = io.ReadAll(io.LimitReader(res.Body, 4<<10))
+	return e
+}
+
+func statusKind(code int) error {
+	switch {
+	case code == http.StatusUnauthorized:
+		return ErrUnauthorized
+	case code == http.StatusForbidden:
+		return ErrForbidden
+	case code == http.StatusNotFound:
+		return ErrNotFound
+	case code == http.StatusTooManyRequests:
+		return ErrRateLimited
+	case code >= http.StatusInternalServerError:
+		return ErrServerError
+	default:
+		return ErrUnexpectedResponse
+	}
+}
@@ -0,0 +1,46 @@
+package base
+
+import (
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"sync"
+
+	"github.com/samber/lo"
+)
+
+// sessionJar is a cookie jar that can be emptied. Expiring a cookie
+// only removes the entry with the same domain and path, so dropping
+// every cookie is the only way to be sure a rejected session isn't
+// sent again, whatever attributes the server set it with.
+type sessionJar struct {
+	mu  sync.Mutex
+	jar *cookiejar.Jar
+}
+
+var _ http.CookieJar = &sessionJar{}
+
+func newSessionJar() *sessionJar {
+	return &sessionJar{jar: lo.Must(cookiejar.New(nil))}
+}
+
+func (j *sessionJar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	j.current().SetCookies(u, cookies)
+}
+
+func (j *sessionJar) Cookies(u *url.URL) []*http.Cookie {
+	return j.current().Cookies(u)
+}
+
+// reset drops every cookie of the jar
+func (j *sessionJar) reset() {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.jar = lo.Must(cookiejar.New(nil))
+}
+
+func (j *sessionJar) current() *cookiejar.Jar {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.jar
+}
@@ -0,0 +1,62 @@
+package base
+
+import (
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/vpnda/wsfetch/internal/httputil"
+	"github.com/vpnda/wsfetch/pkg/ratelimit"
+	"go.uber.org/zap"
+)
+
+// Middleware wraps the transport of the Wealthsimple HTTP client to
+// observe or alter the requests it sends, for logging, tracing, rate
+// limiting or recording them. Retries wrap the chain rather than being
+// part of it, see the package documentation.
+type Middleware func(next http.RoundTripper) http.RoundTripper
+
+// Chain wraps rt with mws, the first middleware is the outermost one
+// and sees requests first
+func Chain(rt http.RoundTripper, mws ...Middleware) http.RoundTripper {
+	for i := len(mws) - 1; i >= 0; i-- {
+		rt = mws[i](rt)
+	}
+	return rt
+}
+
+// TraceMiddleware dumps every request and response to w, with
+// credentials and personal fields redacted
+func TraceMiddleware(w io.Writer) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return &httputil.DumpTransport{R: next, W: w}
+	}
+}
+
+// LoggingMiddleware logs every request along with its
+// status and how long it took at the debug level
+func LoggingMiddleware(l *zap.Logger) Middleware {
+	log := l.Sugar()
+	return func(next http.RoundTripper) http.RoundTripper {
+		return httputil.RoundTripperFunc(func(r *http.Request) (*http.Response, error) {
+			start := time.Now()
+			res, err := next.RoundTrip(r)
+			if err != nil {
+				log.Debugw("Request failed", "method", r.Method, "host", r.URL.Host,
+					"path", r.URL.Path, "duration", time.Since(start), "error", err)
+				return nil, err
+			}
+			log.Debugw("Request sent", "method", r.Method, "host", r.URL.Host,
+				"path", r.URL.Path, "status", res.StatusCode, "duration", time.Since(start))
+			return res, nil
+		})
+	}
+}
+
+// RateLimitMiddleware waits on the limiter picked for each request
+// before sending it, see the ratelimit package
+func RateLimitMiddleware(pick func(*http.Request) *ratelimit.Limiter) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return ratelimit.Transport(next, pick)
+	}
+}
@@ -0,0 +1,82 @@
+package base
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/vpnda/wsfetch/internal/httputil"
+	"github.com/vpnda/wsfetch/pkg/auth/creds"
+	"github.com/vpnda/wsfetch/pkg/auth/types"
+	"github.com/vpnda/wsfetch/pkg/endpoints"
+)
+
+func Test_Wealthsimple_Middleware(t *testing.T) {
+	g := NewWithT(t)
+	expiry := time.Now().Add(time.Hour)
+
+	var order []string
+	tag := func(name string) Middleware {
+		return func(next http.RoundTripper) http.RoundTripper {
+			return httputil.RoundTripperFunc(func(r *http.Request) (*http.Response, error) {
+				order = append(order, name)
+				return next.RoundTrip(r)
+			})
+		}
+	}
+
+	trace := &bytes.Buffer{}
+	ws := AuthClientFromFetcher(creds.StaticTokenFetcher(types.Session{
+		AccessToken: "someAccessToken",
+		Expiry:      &expiry,
+	}),
+		WithRateLimiters(nil, nil),
+		WithMiddleware(tag("first"), tag("second"), TraceMiddleware(trace)),
+		WithTransport(httputil.RoundTripperFunc(func(r *http.Request) (*http.Response, error) {
+			order = append(order, "transport")
+			if r.URL.Path == endpoints.MyWealthsimpleSession.Path {
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Status:     "200 OK",
+					Header:     http.Header{"Set-Cookie": []string{"_session_id=someSessionId; Path=/"}},
+					Body:       http.NoBody,
+					Request:    r,
+				}, nil
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Status:     "200 OK",
+				Header:     http.Header{"Content-Type": []string{"application/json"}},
+				Body: io.NopCloser(strings.NewReader(
+					`{"data":{"identity":{"email":"someone@example.com","firstName":"Jane","id":"identity-1"}}}`)),
+				Request: r,
+			}, nil
+		})),
+	)
+
+	req, err := http.NewRequest(http.MethodPost, endpoints.MyWealthsimpleGetGraphQl.String(),
+		strings.NewReader(`{"operationName":"FetchIdentity","query":"query FetchIdentity { id }"}`))
+	g.Expect(err).ToNot(HaveOccurred())
+	res, err := ws.Do(req)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	// the response body is still readable after being traced
+	body, err := io.ReadAll(res.Body)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(string(body)).To(ContainSubstring("someone@example.com"))
+
+	// one session hydration then the query, both through the whole chain
+	g.Expect(order).To(Equal([]string{"first", "second", "transport", "first", "second", "transport"}))
+
+	out := trace.String()
+	g.Expect(out).To(ContainSubstring("FetchIdentity"))
+	g.Expect(out).To(ContainSubstring("identity-1"))
+	g.Expect(out).ToNot(ContainSubstring("someAccessToken"))
+	g.Expect(out).ToNot(ContainSubstring("someSessionId"))
+	g.Expect(out).ToNot(ContainSubstring("someone@example.com"))
+	g.Expect(out).ToNot(ContainSubstring("Jane"))
+}
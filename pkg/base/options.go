@@ -0,0 +1,72 @@
+package base
+
+import (
+	"net/http"
+
+	"github.com/vpnda/wsfetch/pkg/auth/authenticator"
+	"github.com/vpnda/wsfetch/pkg/endpoints"
+	"github.com/vpnda/wsfetch/pkg/ratelimit"
+	"go.uber.org/zap"
+)
+
+// Option configures a Wealthsimple client
+type Option func(*Wealthsimple)
+
+// WithLogger sets the logger used by the client and by the session
+// fetcher it creates, nothing is logged by default
+func WithLogger(l *zap.Logger) Option {
+	return func(c *Wealthsimple) {
+		c.log = l.Sugar()
+	}
+}
+
+// WithAuthenticatorOptions sets the options of the authenticator used
+// by the session fetcher the client creates
+func WithAuthenticatorOptions(opts ...authenticator.Option) Option {
+	return func(c *Wealthsimple) {
+		c.authOptions = append(c.authOptions, opts...)
+	}
+}
+
+// WithRetryPolicy sets how failed GraphQL queries are retried,
+// DefaultRetryPolicy is used otherwise
+func WithRetryPolicy(p RetryPolicy) Option {
+	return func(c *Wealthsimple) {
+		c.retry = p
+	}
+}
+
+// WithRateLimiters sets the limiters requests wait on, defaults to
+// ratelimit.Auth and ratelimit.GraphQL which are shared by the whole
+// process. A nil limiter disables rate limiting of its requests.
+func WithRateLimiters(auth, graphql *ratelimit.Limiter) Option {
+	return func(c *Wealthsimple) {
+		c.authLimiter = auth
+		c.graphqlLimiter = graphql
+	}
+}
+
+// WithMiddleware appends middlewares wrapping the transport of the
+// client and of the authenticator its session fetcher uses, the first
+// one sees requests first
+func WithMiddleware(mws ...Middleware) Option {
+	return func(c *Wealthsimple) {
+		c.middlewares = append(c.middlewares, mws...)
+	}
+}
+
+// WithTransport sets the transport sending the requests once they went
+// through every middleware, defaults to http.DefaultTransport
+func WithTransport(rt http.RoundTripper) Option {
+	return func(c *Wealthsimple) {
+		c.transport = rt
+	}
+}
+
+// WithEndpoints sets where requests are sent, for the client and the
+// authenticator of its session fetcher, defaults to endpoints.Default
+func WithEndpoints(cfg endpoints.Config) Option {
+	return func(c *Wealthsimple) {
+		c.endpoints = cfg
+	}
+}
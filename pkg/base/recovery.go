@@ -0,0 +1,85 @@
+package base
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/samber/lo"
+)
+
+var (
+	// errSessionRejected is returned when Wealthsimple refuses to
+	// exchange our access token for a web session
+	errSessionRejected = errors.New("session rejected by wealthsimple")
+
+	// graphqlAuthErrorCodes are the extension codes returned in a
+	// GraphQL error when the bearer token is no longer accepted
+	graphqlAuthErrorCodes = []string{
+		"UNAUTHENTICATED",
+		"UNAUTHORIZED",
+		"INVALID_TOKEN",
+	}
+)
+
+// replayableBody returns a function producing fresh copies of the
+// request body so it can be sent again after a refresh
+func replayableBody(r *http.Request) (func() (io.ReadCloser, error), error) {
+	if r.Body == nil || r.Body == http.NoBody {
+		return func() (io.ReadCloser, error) { return http.NoBody, nil }, nil
+	}
+	if r.GetBody != nil {
+		return r.GetBody, nil
+	}
+
+	bits, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+	r.Body.Close()
+	r.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(bits)), nil
+	}
+	r.Body, _ = r.GetBody()
+	return r.GetBody, nil
+}
+
+// isAuthFailure reports whether res was rejected because of our credentials.
+// GraphQL responses are inspected for authentication error codes, in
+// that case the body is buffered and restored so callers can still read it.
+func isAuthFailure(res *http.Response) bool {
+	if res.StatusCode == http.StatusUnauthorized {
+		return true
+	}
+	if res.StatusCode != http.StatusOK ||
+		!strings.Contains(res.Header.Get("Content-Type"), "json") {
+		return false
+	}
+
+	bits, err := io.ReadAll(res.Body)
+	res.Body.Close()
+	res.Body = io.NopCloser(bytes.NewReader(bits))
+	if err != nil {
+		return false
+	}
+
+	var gqlResponse struct {
+		Errors []graphqlError `json:"errors"`
+	}
+	if json.Unmarshal(bits, &gqlResponse) != nil {
+		return false
+	}
+	return lo.SomeBy(gqlResponse.Errors, func(e graphqlError) bool {
+		return lo.Contains(graphqlAuthErrorCodes, strings.ToUpper(e.Extensions.Code))
+	})
+}
+
+type graphqlError struct {
+	Message    string `json:"message"`
+	Extensions struct {
+		Code string `json:"code"`
+	} `json:"extensions"`
+}
@@ -0,0 +1,151 @@
+package base
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// RetryPolicy controls how failed GraphQL queries are retried. Only
+// queries are retried, mutations are never sent twice as they may not
+// be idempotent.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the
+	// first one, a value of 1 or less disables retries
+	MaxAttempts int
+
+	// BaseDelay is the delay before the first retry, it doubles
+	// with every attempt
+	BaseDelay time.Duration
+
+	// MaxDelay caps the delay between two attempts
+	MaxDelay time.Duration
+
+	// MaxElapsed caps the total time spent retrying, no retry is
+	// attempted if waiting would go past it. Zero means no limit.
+	MaxElapsed time.Duration
+}
+
+// DefaultRetryPolicy is used by clients unless WithRetryPolicy is given
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 4,
+	BaseDelay:   500 * time.Millisecond,
+	MaxDelay:    10 * time.Second,
+	MaxElapsed:  time.Minute,
+}
+
+// NoRetry disables retries
+var NoRetry = RetryPolicy{MaxAttempts: 1}
+
+// backoff returns the delay before the given retry, starting at 1, with
+// jitter picked between half and the full exponential delay
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	d := p.BaseDelay << (retry - 1)
+	if d <= 0 || (p.MaxDelay > 0 && d > p.MaxDelay) {
+		d = p.MaxDelay
+	}
+	if d <= 0 {
+		return 0
+	}
+	half := d / 2
+	return half + rand.N(d-half+1)
+}
+
+// retryDelay decides whether the attempt that failed with err can be
+// retried and how long to wait before doing so
+func (p RetryPolicy) retryDelay(attempt int, elapsed time.Duration, err error) (time.Duration, bool) {
+	if attempt >= p.MaxAttempts || !isRetryable(err) {
+		return 0, false
+	}
+
+	delay := p.backoff(attempt)
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) && statusErr.RetryAfter > delay {
+		delay = statusErr.RetryAfter
+	}
+	if p.MaxElapsed > 0 && elapsed+delay > p.MaxElapsed {
+		return 0, false
+	}
+	return delay, true
+}
+
+// isRetryable reports whether err is a transient failure: the request
+// didn't get a response, was rate limited or hit a gateway error.
+// Transport errors stating they aren't temporary are not retried.
+func isRetryable(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if isPermanent(err) {
+		return false
+	}
+
+	var networkErr *NetworkError
+	if errors.As(err, &networkErr) {
+		return true
+	}
+
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) {
+		return false
+	}
+	switch statusErr.StatusCode {
+	case http.StatusTooManyRequests,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// isPermanent reports whether the cause of err states it isn't
+// temporary. Wrappers such as url.Error forward Temporary to the
+// cause, reporting false when it doesn't tell, so only the cause
+// is trusted.
+func isPermanent(err error) bool {
+	cause := err
+	for next := errors.Unwrap(cause); next != nil; next = errors.Unwrap(cause) {
+		cause = next
+	}
+	t, ok := cause.(interface{ Temporary() bool })
+	return ok && !t.Temporary()
+}
+
+// graphqlOperation reads the operation name of the GraphQL payload
+// in body and whether it is a query, as opposed to a mutation or
+// subscription. Bodies that aren't GraphQL payloads aren't queries.
+func graphqlOperation(getBody func() (io.ReadCloser, error)) (string, bool) {
+	body, err := getBody()
+	if err != nil {
+		return "", false
+	}
+	defer body.Close()
+
+	var payload struct {
+		OperationName string `json:"operationName"`
+		Query         string `json:"query"`
+	}
+	if json.NewDecoder(body).Decode(&payload) != nil {
+		return "", false
+	}
+	query := strings.TrimSpace(payload.Query)
+	return payload.OperationName, strings.HasPrefix(query, "query") || strings.HasPrefix(query, "{")
+}
+
+// sleepContext waits for d unless ctx is done first
+func sleepContext(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,335 @@
+// Package cassette records the HTTP interactions with Wealthsimple to a
+// fixtures directory and replays them later, so wsfetch can run offline
+// and tests can exercise real payload shapes without credentials.
+//
+// Requests are matched on their method, URL and, for GraphQL requests,
+// operation name and variables. Variables are normalized: sensitive
+// values are redacted and dates replaced by a placeholder so a replay
+// matches a recording made on another day. Credentials, cookies and
+// personal fields are scrubbed before anything is written to disk.
+package cassette
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vpnda/wsfetch/internal/httputil"
+)
+
+// ErrNoInteraction is returned when replaying a request that wasn't recorded
+var ErrNoInteraction = errors.New("no recorded interaction matches the request")
+
+// NoInteractionError is returned when replaying a request that wasn't
+// recorded, it matches ErrNoInteraction
+type NoInteractionError struct {
+	Request Request
+}
+
+func (e *NoInteractionError) Error() string {
+	return fmt.Sprintf("%s: %s %s %s", ErrNoInteraction, e.Request.Method, e.Request.URL, e.Request.Operation)
+}
+
+func (e *NoInteractionError) Is(target error) bool {
+	return target == ErrNoInteraction
+}
+
+// Temporary tells retrying clients that replaying the request again
+// won't help
+func (e *NoInteractionError) Temporary() bool {
+	return false
+}
+
+// timePlaceholder replaces dates and timestamps in GraphQL variables
+const timePlaceholder = "[TIME]"
+
+// recordedHeaders are the only response headers kept in a recording
+var recordedHeaders = []string{"Content-Type", "Retry-After"}
+
+// Interaction is a recorded request along with its response
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+// Request identifies a recorded request
+type Request struct {
+	Method    string          `json:"method"`
+	URL       string          `json:"url"`
+	Operation string          `json:"operation,omitempty"`
+	Variables json.RawMessage `json:"variables,omitempty"`
+}
+
+// Response is a recorded response, JSON bodies are kept as is
+// to keep fixtures readable, any other body as text
+type Response struct {
+	StatusCode int             `json:"statusCode"`
+	Header     http.Header     `json:"header,omitempty"`
+	JSON       json.RawMessage `json:"json,omitempty"`
+	Body       string          `json:"body,omitempty"`
+}
+
+// key is what requests are matched on, variables are compacted
+// as recordings are indented
+func (r Request) key() string {
+	variables := &bytes.Buffer{}
+	if len(r.Variables) != 0 && json.Compact(variables, r.Variables) != nil {
+		variables.Reset()
+		variables.Write(r.Variables)
+	}
+	return strings.Join([]string{r.Method, r.URL, r.Operation, variables.String()}, " ")
+}
+
+// Recorder writes interactions, scrubbed, to a directory. They are
+// numbered in the order they were made across every transport of the
+// recorder, so the clients of a process record to the same directory
+// without overwriting each other and are replayed in order.
+type Recorder struct {
+	dir string
+
+	mu  sync.Mutex
+	seq int
+}
+
+// NewRecorder returns a recorder writing to dir. The directory is
+// created when the first interaction is recorded, interactions already
+// in it are kept.
+func NewRecorder(dir string) *Recorder {
+	return &Recorder{dir: dir, seq: -1}
+}
+
+// Transport returns a transport sending requests through next and
+// recording every interaction
+func (rec *Recorder) Transport(next http.RoundTripper) http.RoundTripper {
+	return &recordingTransport{rec: rec, next: next}
+}
+
+type recordingTransport struct {
+	rec  *Recorder
+	next http.RoundTripper
+}
+
+func (t *recordingTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	req, err := newRequest(r)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := t.next.RoundTrip(r)
+	if err != nil {
+		return nil, err
+	}
+
+	bits, err := io.ReadAll(res.Body)
+	res.Body.Close()
+	res.Body = io.NopCloser(bytes.NewReader(bits))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := t.rec.save(Interaction{Request: req, Response: newResponse(res, bits)}); err != nil {
+		return nil, fmt.Errorf("unable to record interaction: %w", err)
+	}
+	return res, nil
+}
+
+func (rec *Recorder) save(i Interaction) error {
+	bits, err := json.MarshalIndent(i, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	if rec.seq < 0 {
+		if err := os.MkdirAll(rec.dir, 0700); err != nil {
+			return err
+		}
+		existing, err := filepath.Glob(filepath.Join(rec.dir, "*.json"))
+		if err != nil {
+			return err
+		}
+		rec.seq = len(existing)
+	}
+	rec.seq++
+	name := fmt.Sprintf("%04d-%s.json", rec.seq, slug(i.Request))
+	return os.WriteFile(filepath.Join(rec.dir, name), append(bits, '\n'), 0600)
+}
+
+// NewReplayer returns a transport answering requests with the
+// interactions recorded in dir, it never reaches the network. Requests
+// recorded several times are answered in the recorded order, the last
+// response being repeated once they are exhausted.
+func NewReplayer(dir string) (http.RoundTripper, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no recorded interaction found in %s", dir)
+	}
+	sort.Strings(files)
+
+	rep := &replayer{interactions: map[string][]Interaction{}}
+	for _, f := range files {
+		bits, err := os.ReadFile(f)
+		if err != nil {
+			return nil, err
+		}
+		var i Interaction
+		if err := json.Unmarshal(bits, &i); err != nil {
+			return nil, fmt.Errorf("invalid interaction %s: %w", f, err)
+		}
+		key := i.Request.key()
+		rep.interactions[key] = append(rep.interactions[key], i)
+	}
+	return rep, nil
+}
+
+type replayer struct {
+	mu           sync.Mutex
+	interactions map[string][]Interaction
+}
+
+func (rep *replayer) RoundTrip(r *http.Request) (*http.Response, error) {
+	req, err := newRequest(r)
+	if err != nil {
+		return nil, err
+	}
+
+	rep.mu.Lock()
+	queue := rep.interactions[req.key()]
+	if len(queue) == 0 {
+		rep.mu.Unlock()
+		return nil, &NoInteractionError{Request: req}
+	}
+	i := queue[0]
+	if len(queue) > 1 {
+		rep.interactions[req.key()] = queue[1:]
+	}
+	rep.mu.Unlock()
+
+	body := i.Response.Body
+	if len(i.Response.JSON) != 0 {
+		body = string(i.Response.JSON)
+	}
+	header := i.Response.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	return &http.Response{
+		StatusCode:    i.Response.StatusCode,
+		Status:        fmt.Sprintf("%d %s", i.Response.StatusCode, http.StatusText(i.Response.StatusCode)),
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(strings.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       r,
+	}, nil
+}
+
+// newRequest builds the normalized request r is matched on, the body
+// of r is read and put back
+func newRequest(r *http.Request) (Request, error) {
+	u := *r.URL
+	u.User = nil
+	u.Fragment = ""
+	u.RawQuery = u.Query().Encode()
+	req := Request{Method: r.Method, URL: u.String()}
+
+	if r.Body == nil || r.Body == http.NoBody {
+		return req, nil
+	}
+	bits, err := io.ReadAll(r.Body)
+	r.Body.Close()
+	r.Body = io.NopCloser(bytes.NewReader(bits))
+	if err != nil {
+		return req, err
+	}
+
+	var payload struct {
+		OperationName string         `json:"operationName"`
+		Query         string         `json:"query"`
+		Variables     map[string]any `json:"variables"`
+	}
+	if json.Unmarshal(bits, &payload) != nil || payload.Query == "" {
+		return req, nil
+	}
+	req.Operation = payload.OperationName
+	if len(payload.Variables) != 0 {
+		// maps are marshalled with sorted keys, giving a canonical form
+		req.Variables, err = json.Marshal(normalize(httputil.RedactJSON(payload.Variables)))
+	}
+	return req, err
+}
+
+func newResponse(res *http.Response, body []byte) Response {
+	recorded := Response{StatusCode: res.StatusCode, Header: http.Header{}}
+	for _, h := range recordedHeaders {
+		if v := res.Header.Values(h); len(v) != 0 {
+			recorded.Header[h] = v
+		}
+	}
+
+	var v any
+	if json.Unmarshal(body, &v) == nil {
+		recorded.JSON, _ = json.MarshalIndent(httputil.RedactJSON(v), "", "  ")
+	} else {
+		recorded.Body = string(body)
+	}
+	return recorded
+}
+
+// normalize replaces dates and timestamps, which change from a run to
+// another, with a placeholder
+func normalize(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, child := range v {
+			v[k] = normalize(child)
+		}
+	case []any:
+		for i, child := range v {
+			v[i] = normalize(child)
+		}
+	case string:
+		if isTime(v) {
+			return timePlaceholder
+		}
+	}
+	return v
+}
+
+func isTime(s string) bool {
+	for _, layout := range []string{time.RFC3339Nano, time.DateOnly} {
+		if _, err := time.Parse(layout, s); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+var slugCleaner = regexp.MustCompile(`[^a-zA-Z0-9]+`)
+
+// slug names the file of a recorded request
+func slug(r Request) string {
+	name := r.Operation
+	if name == "" {
+		u, _ := url.Parse(r.URL)
+		name = u.Path
+	}
+	return strings.ToLower(r.Method) + "-" + strings.Trim(slugCleaner.ReplaceAllString(name, "-"), "-")
+}
@@ -0,0 +1,149 @@
+package cassette
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/vpnda/wsfetch/internal/httputil"
+	"github.com/vpnda/wsfetch/pkg/auth/authenticator"
+	"github.com/vpnda/wsfetch/pkg/base"
+	"github.com/vpnda/wsfetch/pkg/client"
+	"github.com/vpnda/wsfetch/pkg/wstest"
+)
+
+func Test_RecordReplay(t *testing.T) {
+	g := NewWithT(t)
+	dir := filepath.Join(t.TempDir(), "fixtures")
+
+	var calls int
+	recorder := NewRecorder(dir).Transport(httputil.RoundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		calls++
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header: http.Header{
+				"Content-Type": []string{"application/json"},
+				"Set-Cookie":   []string{"_session_id=someSessionId"},
+			},
+			Body: io.NopCloser(strings.NewReader(
+				`{"access_token":"someAccessToken","data":{"identity":{"email":"someone@example.com","id":"identity-1"}}}`)),
+			Request: r,
+		}, nil
+	}))
+
+	send := func(rt http.RoundTripper, body string) (*http.Response, error) {
+		req, err := http.NewRequest(http.MethodPost, "https://my.wealthsimple.com/graphql", strings.NewReader(body))
+		g.Expect(err).ToNot(HaveOccurred())
+		req.Header.Set("Authorization", "Bearer someAccessToken")
+		return rt.RoundTrip(req)
+	}
+
+	res, err := send(recorder, `{"operationName":"FetchIdentity","query":"query FetchIdentity { id }",`+
+		`"variables":{"id":"identity-1","startDate":"2024-05-01T00:00:00Z"}}`)
+	g.Expect(err).ToNot(HaveOccurred())
+	body, _ := io.ReadAll(res.Body)
+	g.Expect(string(body)).To(ContainSubstring("someone@example.com"))
+	g.Expect(calls).To(Equal(1))
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(files).To(HaveLen(1))
+	g.Expect(filepath.Base(files[0])).To(Equal("0001-post-FetchIdentity.json"))
+	recorded, err := os.ReadFile(files[0])
+	g.Expect(err).ToNot(HaveOccurred())
+	for _, secret := range []string{"someAccessToken", "someSessionId", "someone@example.com"} {
+		g.Expect(string(recorded)).ToNot(ContainSubstring(secret))
+	}
+
+	replayer, err := NewReplayer(dir)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	// recorded on another day, the dates are normalized away
+	res, err = send(replayer, `{"operationName":"FetchIdentity","query":"query FetchIdentity { id }",`+
+		`"variables":{"startDate":"2024-06-12T00:00:00Z","id":"identity-1"}}`)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(res.StatusCode).To(Equal(http.StatusOK))
+	body, _ = io.ReadAll(res.Body)
+	g.Expect(string(body)).To(ContainSubstring("identity-1"))
+	g.Expect(calls).To(Equal(1))
+
+	_, err = send(replayer, `{"operationName":"FetchIdentity","query":"query FetchIdentity { id }",`+
+		`"variables":{"id":"identity-2"}}`)
+	g.Expect(errors.Is(err, ErrNoInteraction)).To(BeTrue())
+}
+
+func Test_Recorder_SharedAcrossTransports(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	srv := wstest.NewServer()
+	defer srv.Close()
+	endpoints := srv.Endpoints()
+
+	var sent int
+	network := httputil.RoundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		sent++
+		return http.DefaultTransport.RoundTrip(r)
+	})
+
+	// like the CLI, the authenticator and the client wrap their own
+	// transport with the same recorder
+	recorder := NewRecorder(dir)
+	ws := base.DefaultAuthClient(srv.Credentials(),
+		base.WithEndpoints(endpoints),
+		base.WithRateLimiters(nil, nil),
+		base.WithRetryPolicy(base.NoRetry),
+		base.WithTransport(network),
+		base.WithMiddleware(recorder.Transport),
+		base.WithAuthenticatorOptions(
+			authenticator.WithTransport(recorder.Transport(network)),
+			authenticator.WithRateLimiter(nil),
+		),
+	)
+	c, err := client.NewClient(ctx, ws)
+	g.Expect(err).ToNot(HaveOccurred())
+	accounts, err := c.GetAccounts(ctx)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	// the session is refreshed, interleaving auth and GraphQL requests
+	srv.ExpireAccessTokens()
+	_, err = c.GetAccounts(ctx)
+	g.Expect(err).ToNot(HaveOccurred())
+	sess, err := ws.Fetcher.GetSession(ctx)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	// every interaction is kept, numbered in the order it was made
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(files).To(HaveLen(sent))
+	for i, f := range files {
+		g.Expect(filepath.Base(f)).To(HavePrefix(fmt.Sprintf("%04d-", i+1)))
+	}
+	g.Expect(strings.Join(files, " ")).To(ContainSubstring("-post-v1-oauth-v2-token.json"))
+	g.Expect(strings.Join(files, " ")).To(ContainSubstring("-post-FetchAllAccountFinancials.json"))
+
+	srv.Close()
+	replayer, err := NewReplayer(dir)
+	g.Expect(err).ToNot(HaveOccurred())
+	replayed, err := client.NewClient(ctx, base.AuthClientFromSession(sess,
+		base.WithEndpoints(endpoints),
+		base.WithRateLimiters(nil, nil),
+		base.WithRetryPolicy(base.NoRetry),
+		base.WithTransport(replayer),
+	))
+	g.Expect(err).ToNot(HaveOccurred())
+	for i := 0; i < 2; i++ {
+		replayedAccounts, err := replayed.GetAccounts(ctx)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(replayedAccounts).To(HaveLen(len(accounts)))
+		g.Expect(replayedAccounts[0].Id).To(Equal(accounts[0].Id))
+	}
+}
@@ -4,26 +4,44 @@ import (
 	"context"
 
 	"github.com/samber/lo"
+	"github.com/vpnda/wsfetch/pkg/auth/types"
 	"github.com/vpnda/wsfetch/pkg/client/generated"
 )
 
-func (c *client) GetAccounts(ctx context.Context) ([]generated.AccountWithFinancials, error) {
-	accounts, err := generated.FetchAllAccountFinancials(
-		ctx,
-		c.tradeClient,
-		c.Identities.IdentityId,
-		nil,
-		lo.ToPtr(25),
-		nil,
-	)
+// pageSize is the number of items fetched per page of a connection
+const pageSize = 25
 
-	if err != nil {
+func (c *client) GetAccounts(ctx context.Context) ([]generated.AccountWithFinancials, error) {
+	if err := c.requireScopes(ctx, "GetAccounts", types.ScopeTradeRead); err != nil {
 		return nil, err
 	}
 
 	var accountList []generated.AccountWithFinancials
-	for _, e := range accounts.GetIdentity().Accounts.Edges {
-		accountList = append(accountList, e.Node.AccountWithFinancials)
+	var cursor *string
+	for {
+		accounts, err := generated.FetchAllAccountFinancials(
+			ctx,
+			c.tradeClient,
+			c.Identities.IdentityId,
+			nil,
+			lo.ToPtr(pageSize),
+			cursor,
+		)
+		if err != nil {
+			return nil, wrapError("GetAccounts", err)
+		}
+
+		conn := accounts.GetIdentity().Accounts
+		for _, e := range conn.Edges {
+			accountList = append(accountList, e.Node.AccountWithFinancials)
+		}
+		if !conn.PageInfo.HasNextPage {
+			break
+		}
+		if conn.PageInfo.EndCursor == "" {
+			return nil, wrapError("GetAccounts", ErrIncompleteList)
+		}
+		cursor = lo.ToPtr(conn.PageInfo.EndCursor)
 	}
 	return accountList, nil
 }
@@ -5,35 +5,51 @@ import (
 	"time"
 
 	"github.com/samber/lo"
+	"github.com/vpnda/wsfetch/pkg/auth/types"
 	"github.com/vpnda/wsfetch/pkg/client/generated"
 )
 
 // GetActivities implements Client.
 func (c *client) GetActivities(ctx context.Context, accountIds []AccountId, from *time.Time, until *time.Time) (map[AccountId][]generated.Activity, error) {
+	if err := c.requireScopes(ctx, "GetActivities", types.ScopeTradeRead); err != nil {
+		return nil, err
+	}
+
 	// Convert AccountId slice to string slice
 	accountIdStrs := make([]string, len(accountIds))
 	for i, id := range accountIds {
 		accountIdStrs[i] = string(id)
 	}
 
-	res, err := generated.FetchActivityFeedItems(ctx, c.tradeClient, lo.ToPtr(25), nil, &generated.ActivityCondition{
+	condition := &generated.ActivityCondition{
 		AccountIds: accountIdStrs,
 		StartDate:  from,
 		EndDate:    until,
-	}, []generated.ActivitiesOrderBy{generated.ActivitiesOrderByOccurredAtDesc})
-	if err != nil {
-		return nil, err
 	}
+	order := []generated.ActivitiesOrderBy{generated.ActivitiesOrderByOccurredAtDesc}
 
-	// Convert slice to map
+	// Follow the cursor until every page is fetched, a window
+	// holding more activities than a page would be cut short otherwise
 	result := make(map[AccountId][]generated.Activity)
-	for _, e := range res.GetActivityFeedItems().GetEdges() {
-		nd := e.GetNode()
-		id := AccountId(nd.GetAccountId())
-		if _, ok := result[id]; !ok {
-			result[id] = []generated.Activity{}
+	var cursor *string
+	for {
+		res, err := generated.FetchActivityFeedItems(ctx, c.tradeClient, lo.ToPtr(pageSize), cursor, condition, order)
+		if err != nil {
+			return nil, wrapError("GetActivities", err)
+		}
+		feed := res.GetActivityFeedItems()
+		for _, e := range feed.GetEdges() {
+			nd := e.GetNode()
+			id := AccountId(nd.GetAccountId())
+			result[id] = append(result[id], nd.Activity)
+		}
+		if !feed.PageInfo.HasNextPage {
+			break
+		}
+		if feed.PageInfo.EndCursor == "" {
+			return nil, wrapError("GetActivities", ErrIncompleteList)
 		}
-		result[id] = append(result[id], nd.Activity)
+		cursor = lo.ToPtr(feed.PageInfo.EndCursor)
 	}
 
 	return result, nil
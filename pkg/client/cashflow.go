@@ -0,0 +1,123 @@
+package client
+
+import (
+	"fmt"
+
+	"github.com/samber/lo"
+	"github.com/vpnda/wsfetch/pkg/client/generated"
+	"github.com/vpnda/wsfetch/pkg/money"
+)
+
+// Direction tells whether an activity brings cash into the account or
+// takes it out
+type Direction string
+
+const (
+	Inflow  Direction = "inflow"
+	Outflow Direction = "outflow"
+	// NoCashFlow is the direction of activities moving assets
+	// but no cash, such as crypto transfers
+	NoCashFlow Direction = "none"
+)
+
+// SignConvention tells which direction is printed as positive
+type SignConvention string
+
+const (
+	// InflowPositive prints money received as positive, the way
+	// account statements do
+	InflowPositive SignConvention = "inflow"
+	// OutflowPositive prints money spent as positive, the way
+	// expense reports do
+	OutflowPositive SignConvention = "outflow"
+)
+
+// ParseSignConvention reads a sign convention, inflow or outflow
+func ParseSignConvention(s string) (SignConvention, error) {
+	switch c := SignConvention(s); c {
+	case InflowPositive, OutflowPositive:
+		return c, nil
+	}
+	return "", fmt.Errorf("invalid sign convention %q, expected %s or %s", s, InflowPositive, OutflowPositive)
+}
+
+// Flow is the cash an activity moves in or out of its account
+type Flow struct {
+	Direction Direction
+
+	// Amount is positive for inflows, negative for outflows and
+	// zero when no cash moves, in the currency of the activity
+	Amount money.Money
+}
+
+// Signed returns the amount signed according to the convention
+func (f Flow) Signed(c SignConvention) money.Decimal {
+	if c == OutflowPositive {
+		return f.Amount.Amount.Neg()
+	}
+	return f.Amount.Amount
+}
+
+// CashFlow returns the cash the activity moves. The direction is decided
+// by the activity type and subtype, the amount sign Wealthsimple sends is
+// only relied upon for activities whose type doesn't tell, such as funds
+// conversions.
+func CashFlow(act *generated.Activity) Flow {
+	direction := cashFlowDirection(act)
+	amount := act.Amount.Abs()
+	switch direction {
+	case Outflow:
+		amount = amount.Neg()
+	case NoCashFlow:
+		amount = money.Zero
+	}
+	return Flow{
+		Direction: direction,
+		Amount:    money.NewMoney(amount, lo.FromPtr(act.Currency)),
+	}
+}
+
+func cashFlowDirection(act *generated.Activity) Direction {
+	switch act.Type {
+	case generated.ActivityTypeDeposit,
+		generated.ActivityTypeDiySell,
+		generated.ActivityTypeManagedSell,
+		generated.ActivityTypeInterest,
+		generated.ActivityTypeDividend,
+		generated.ActivityTypeRefund,
+		generated.ActivityTypePromotion,
+		generated.ActivityTypeReferral:
+		return Inflow
+	case generated.ActivityTypeWithdrawal,
+		generated.ActivityTypeDiyBuy,
+		generated.ActivityTypeManagedBuy,
+		generated.ActivityTypeNonResidentTax:
+		return Outflow
+	case generated.ActivityTypeInternalTransfer:
+		// the source account is the one the cash leaves
+		if act.SubType == generated.ActivitySubtypeSource {
+			return Outflow
+		}
+	case generated.ActivityTypeInstitutionalTransferIntent:
+		switch act.SubType {
+		case generated.ActivitySubtypeTransferOut:
+			return Outflow
+		case generated.ActivitySubtypeTransferIn:
+			return Inflow
+		}
+	case generated.ActivityTypeP2pPayment:
+		switch act.SubType {
+		case generated.ActivitySubtypeSend:
+			return Outflow
+		case generated.ActivitySubtypeSendReceived:
+			return Inflow
+		}
+	case generated.ActivityTypeCryptoTransfer:
+		return NoCashFlow
+	}
+
+	if act.AmountSign == generated.AmountSignNegative {
+		return Outflow
+	}
+	return Inflow
+}
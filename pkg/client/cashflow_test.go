@@ -0,0 +1,262 @@
+package client
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/samber/lo"
+	"github.com/vpnda/wsfetch/pkg/client/generated"
+	"github.com/vpnda/wsfetch/pkg/money"
+	"github.com/vpnda/wsfetch/pkg/wstest"
+)
+
+func Test_CashFlow(t *testing.T) {
+	testCases := []struct {
+		name              string
+		activityType      generated.ActivityType
+		subtype           generated.ActivitySubtype
+		sign              generated.AmountSign
+		amount            string
+		expectedDirection Direction
+		expectedAmount    string
+	}{
+		{
+			name:              "sell",
+			activityType:      generated.ActivityTypeDiySell,
+			subtype:           "MARKET_ORDER",
+			sign:              generated.AmountSignPositive,
+			amount:            "160.00",
+			expectedDirection: Inflow,
+			expectedAmount:    "160.00",
+		},
+		{
+			name:              "managed buy",
+			activityType:      generated.ActivityTypeManagedBuy,
+			sign:              generated.AmountSignNegative,
+			amount:            "250.00",
+			expectedDirection: Outflow,
+			expectedAmount:    "-250.00",
+		},
+		{
+			name:              "managed sell",
+			activityType:      generated.ActivityTypeManagedSell,
+			sign:              generated.AmountSignPositive,
+			amount:            "250.00",
+			expectedDirection: Inflow,
+			expectedAmount:    "250.00",
+		},
+		{
+			name:              "deposit sent with a negative sign",
+			activityType:      generated.ActivityTypeDeposit,
+			subtype:           generated.ActivitySubtypeEft,
+			sign:              generated.AmountSignNegative,
+			amount:            "1000.00",
+			expectedDirection: Inflow,
+			expectedAmount:    "1000.00",
+		},
+		{
+			name:              "non-resident tax sent with a positive sign",
+			activityType:      generated.ActivityTypeNonResidentTax,
+			sign:              generated.AmountSignPositive,
+			amount:            "1.85",
+			expectedDirection: Outflow,
+			expectedAmount:    "-1.85",
+		},
+		{
+			name:              "refund",
+			activityType:      generated.ActivityTypeRefund,
+			subtype:           generated.ActivitySubtypeTransferFeeRefund,
+			sign:              generated.AmountSignPositive,
+			amount:            "150.00",
+			expectedDirection: Inflow,
+			expectedAmount:    "150.00",
+		},
+		{
+			name:              "promotion",
+			activityType:      generated.ActivityTypePromotion,
+			subtype:           generated.ActivitySubtypeIncentiveBonus,
+			sign:              generated.AmountSignPositive,
+			amount:            "25.00",
+			expectedDirection: Inflow,
+			expectedAmount:    "25.00",
+		},
+		{
+			name:              "referral",
+			activityType:      generated.ActivityTypeReferral,
+			sign:              generated.AmountSignPositive,
+			amount:            "25.00",
+			expectedDirection: Inflow,
+			expectedAmount:    "25.00",
+		},
+		{
+			name:              "internal transfer received",
+			activityType:      generated.ActivityTypeInternalTransfer,
+			sign:              generated.AmountSignPositive,
+			amount:            "500.00",
+			expectedDirection: Inflow,
+			expectedAmount:    "500.00",
+		},
+		{
+			name:              "internal transfer without subtype sent",
+			activityType:      generated.ActivityTypeInternalTransfer,
+			sign:              generated.AmountSignNegative,
+			amount:            "500.00",
+			expectedDirection: Outflow,
+			expectedAmount:    "-500.00",
+		},
+		{
+			name:              "institutional transfer out",
+			activityType:      generated.ActivityTypeInstitutionalTransferIntent,
+			subtype:           generated.ActivitySubtypeTransferOut,
+			sign:              generated.AmountSignPositive,
+			amount:            "5000.00",
+			expectedDirection: Outflow,
+			expectedAmount:    "-5000.00",
+		},
+		{
+			name:              "institutional transfer in",
+			activityType:      generated.ActivityTypeInstitutionalTransferIntent,
+			subtype:           generated.ActivitySubtypeTransferIn,
+			sign:              generated.AmountSignNegative,
+			amount:            "5000.00",
+			expectedDirection: Inflow,
+			expectedAmount:    "5000.00",
+		},
+		{
+			name:              "institutional transfer without subtype",
+			activityType:      generated.ActivityTypeInstitutionalTransferIntent,
+			sign:              generated.AmountSignNegative,
+			amount:            "5000.00",
+			expectedDirection: Outflow,
+			expectedAmount:    "-5000.00",
+		},
+		{
+			name:              "payment received",
+			activityType:      generated.ActivityTypeP2pPayment,
+			subtype:           generated.ActivitySubtypeSendReceived,
+			sign:              generated.AmountSignPositive,
+			amount:            "20.00",
+			expectedDirection: Inflow,
+			expectedAmount:    "20.00",
+		},
+		{
+			name:              "payment without subtype",
+			activityType:      generated.ActivityTypeP2pPayment,
+			sign:              generated.AmountSignNegative,
+			amount:            "20.00",
+			expectedDirection: Outflow,
+			expectedAmount:    "-20.00",
+		},
+		{
+			name:              "funds conversion bought",
+			activityType:      generated.ActivityTypeFundsConversion,
+			sign:              generated.AmountSignPositive,
+			amount:            "100.00",
+			expectedDirection: Inflow,
+			expectedAmount:    "100.00",
+		},
+		{
+			name:              "funds conversion sold",
+			activityType:      generated.ActivityTypeFundsConversion,
+			sign:              generated.AmountSignNegative,
+			amount:            "136.50",
+			expectedDirection: Outflow,
+			expectedAmount:    "-136.50",
+		},
+		{
+			name:              "crypto transfer",
+			activityType:      generated.ActivityTypeCryptoTransfer,
+			sign:              generated.AmountSignNegative,
+			amount:            "0.05",
+			expectedDirection: NoCashFlow,
+			expectedAmount:    "0",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			flow := CashFlow(&generated.Activity{
+				Type:       tc.activityType,
+				SubType:    tc.subtype,
+				AmountSign: tc.sign,
+				Amount:     money.MustParse(tc.amount),
+				Currency:   lo.ToPtr("CAD"),
+			})
+			g.Expect(flow.Direction).To(Equal(tc.expectedDirection))
+			g.Expect(flow.Amount.Amount.String()).To(Equal(tc.expectedAmount))
+			g.Expect(flow.Amount.Currency).To(Equal("CAD"))
+		})
+	}
+}
+
+func Test_CashFlow_Fixtures(t *testing.T) {
+	g := NewWithT(t)
+	expected := map[string]string{
+		"act-1": "-301.50", // buy
+		"act-2": "12.34",   // dividend
+		"act-3": "-500.00", // internal transfer out
+		"act-4": "1000.00", // e-transfer deposit
+		"act-5": "-85.20",  // bill payment
+		"act-6": "0.42",    // stock lending interest
+		"act-7": "-20.00",  // payment sent
+	}
+
+	activities := wstest.DefaultFixtures().Activities
+	g.Expect(activities).To(HaveLen(len(expected)))
+	for _, act := range activities {
+		g.Expect(CashFlow(&act).Amount.String()).To(Equal(expected[*act.CanonicalId]+" CAD"), *act.CanonicalId)
+	}
+}
+
+func Test_Flow_Signed(t *testing.T) {
+	testCases := []struct {
+		name       string
+		convention SignConvention
+		act        generated.Activity
+		expected   string
+	}{
+		{
+			name:       "deposit, inflow positive",
+			convention: InflowPositive,
+			act:        generated.Activity{Type: generated.ActivityTypeDeposit, Amount: money.MustParse("100.00")},
+			expected:   "100.00",
+		},
+		{
+			name:       "deposit, outflow positive",
+			convention: OutflowPositive,
+			act:        generated.Activity{Type: generated.ActivityTypeDeposit, Amount: money.MustParse("100.00")},
+			expected:   "-100.00",
+		},
+		{
+			name:       "buy, outflow positive",
+			convention: OutflowPositive,
+			act:        generated.Activity{Type: generated.ActivityTypeDiyBuy, Amount: money.MustParse("301.50")},
+			expected:   "301.50",
+		},
+		{
+			name:       "negative amount sent for a withdrawal",
+			convention: InflowPositive,
+			act:        generated.Activity{Type: generated.ActivityTypeWithdrawal, Amount: money.MustParse("-85.20")},
+			expected:   "-85.20",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			g.Expect(CashFlow(&tc.act).Signed(tc.convention).String()).To(Equal(tc.expected))
+		})
+	}
+}
+
+func Test_ParseSignConvention(t *testing.T) {
+	g := NewWithT(t)
+
+	c, err := ParseSignConvention("outflow")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(c).To(Equal(OutflowPositive))
+
+	_, err = ParseSignConvention("expense")
+	g.Expect(err).To(HaveOccurred())
+}
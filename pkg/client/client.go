@@ -6,6 +6,8 @@ import (
 	"time"
 
 	"github.com/Khan/genqlient/graphql"
+	"github.com/vpnda/wsfetch/pkg/auth/creds"
+	"github.com/vpnda/wsfetch/pkg/auth/types"
 	"github.com/vpnda/wsfetch/pkg/base"
 	"github.com/vpnda/wsfetch/pkg/client/generated"
 	"github.com/vpnda/wsfetch/pkg/endpoints"
@@ -14,6 +16,8 @@ import (
 type AccountId string
 type SecuritySymbol string
 
+//go:generate mockgen.sh client.go Client
+
 // Client is able to make requests to Wealthsimple using graphql queries
 type Client interface {
 	GetAccount(ctx context.Context, accountId string) (*generated.AccountWithFinancials, error)
@@ -32,6 +36,10 @@ type client struct {
 	// Trade Client
 	tradeClient graphql.Client
 
+	// Fetcher of the session the requests are sent with,
+	// used to check its scopes
+	sessions creds.SessionFetcher
+
 	// Profile or User id, normally in the form
 	// user-psde1sas14
 	Identities *base.TokenInformation
@@ -49,14 +57,47 @@ type cachingClient struct {
 }
 
 func NewClient(ctx context.Context, c *base.Wealthsimple) (Client, error) {
-	cids, err := c.GetTokenInformation(ctx)
+	cids, err := identities(ctx, c)
 	if err != nil {
 		return nil, fmt.Errorf("unable to fetch profile id: %w", err)
 	}
 	tradeInnerClient := *c
 	tradeInnerClient.Profile = base.Trade
 	return &client{
-		tradeClient: graphql.NewClient(endpoints.MyWealthsimpleGetGraphQl.String(), &tradeInnerClient),
+		tradeClient: graphql.NewClient(c.Endpoints().URL(endpoints.MyWealthsimpleGetGraphQl).String(), &tradeInnerClient),
+		sessions:    c.Fetcher,
 		Identities:  cids,
 	}, nil
 }
+
+// identities reads the identity from the claims of the access token,
+// asking Wealthsimple for it only when the token isn't a JWT naming one
+func identities(ctx context.Context, c *base.Wealthsimple) (*base.TokenInformation, error) {
+	sess, err := c.Fetcher.GetSession(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if claims, err := types.ParseTokenClaims(sess.AccessToken); err == nil {
+		if info, ok := base.TokenInformationFromClaims(claims); ok {
+			return info, nil
+		}
+	}
+	return c.GetTokenInformation(ctx)
+}
+
+// requireScopes fails with an InsufficientScopeError when the
+// session wasn't granted every scope the operation needs
+func (c *client) requireScopes(ctx context.Context, operation string, scopes ...types.Scope) error {
+	sess, err := c.sessions.GetSession(ctx)
+	if err != nil {
+		return err
+	}
+	if sess.HasScopes(scopes...) {
+		return nil
+	}
+	return &InsufficientScopeError{
+		Operation: operation,
+		Required:  types.MissingScopes(sess.Scopes, scopes...),
+		Granted:   sess.Scopes,
+	}
+}
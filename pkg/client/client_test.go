@@ -0,0 +1,187 @@
+package client
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/Khan/genqlient/graphql"
+	. "github.com/onsi/gomega"
+	"github.com/samber/lo"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"github.com/vpnda/wsfetch/pkg/auth/creds"
+	"github.com/vpnda/wsfetch/pkg/auth/types"
+	"github.com/vpnda/wsfetch/pkg/base"
+	"github.com/vpnda/wsfetch/pkg/endpoints"
+	"github.com/vpnda/wsfetch/pkg/wstest"
+)
+
+func Test_Client_RequiresScopes(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+	expiry := time.Now().Add(time.Hour)
+
+	c := &client{
+		sessions: creds.StaticTokenFetcher(types.Session{
+			AccessToken: "someAccessToken",
+			Expiry:      &expiry,
+			Scopes:      []types.Scope{types.ScopeTaxRead},
+		}),
+	}
+
+	_, err := c.GetAccounts(ctx)
+	var scopeErr *InsufficientScopeError
+	g.Expect(errors.As(err, &scopeErr)).To(BeTrue())
+	g.Expect(scopeErr.Operation).To(Equal("GetAccounts"))
+	g.Expect(scopeErr.Required).To(Equal([]types.Scope{types.ScopeTradeRead}))
+	g.Expect(scopeErr.Granted).To(Equal([]types.Scope{types.ScopeTaxRead}))
+
+	_, err = c.GetActivities(ctx, []AccountId{"someAccount"}, nil, nil)
+	g.Expect(errors.As(err, &scopeErr)).To(BeTrue())
+
+	_, err = c.GetSecurityMarketData(ctx, "someSecurity")
+	g.Expect(errors.As(err, &scopeErr)).To(BeTrue())
+}
+
+func Test_NewClient_Identities(t *testing.T) {
+	claims := base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"identity-1","user_canonical_id":"user-1","profiles":{"trade":{"default":"user-1-trade"}}}`))
+
+	testCases := []struct {
+		name             string
+		accessToken      string
+		expectedIdentity string
+		expectedRequests int
+	}{
+		{
+			name:             "identity from the token claims",
+			accessToken:      "e30." + claims + ".",
+			expectedIdentity: "identity-1",
+		},
+		{
+			name:             "identity fetched for opaque tokens",
+			accessToken:      "someAccessToken",
+			expectedIdentity: "identity-2",
+			expectedRequests: 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			ctx := context.Background()
+			expiry := time.Now().Add(time.Hour)
+
+			var requests int
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				g.Expect(r.URL.Path).To(Equal(endpoints.AuthTokenInfo.Path))
+				fmt.Fprint(w, `{"identity_canonical_id":"identity-2","profiles":{"trade":{"default":"user-2-trade"}}}`)
+			}))
+			defer srv.Close()
+			apiBase, _ := url.Parse(srv.URL)
+
+			ws := base.AuthClientFromFetcher(creds.StaticTokenFetcher(types.Session{
+				AccessToken: tc.accessToken,
+				Expiry:      &expiry,
+			}), base.WithEndpoints(endpoints.ForBase(apiBase)))
+			c, err := NewClient(ctx, ws)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(c.(*client).Identities.IdentityId).To(Equal(tc.expectedIdentity))
+			g.Expect(c.(*client).Identities.ProfileToClientId).To(HaveKey(base.WsProfile(base.Trade)))
+			g.Expect(requests).To(Equal(tc.expectedRequests))
+		})
+	}
+}
+
+func Test_wrapError(t *testing.T) {
+	g := NewWithT(t)
+
+	err := wrapError("GetAccounts", gqlerror.List{{Message: "bad field"}})
+	var graphqlErr *GraphQLError
+	g.Expect(errors.As(err, &graphqlErr)).To(BeTrue())
+	g.Expect(graphqlErr.Operation).To(Equal("GetAccounts"))
+	g.Expect(graphqlErr.Errors).To(HaveLen(1))
+
+	cause := errors.New("connection reset")
+	err = wrapError("GetAccounts", cause)
+	g.Expect(errors.As(err, &graphqlErr)).To(BeFalse())
+	g.Expect(err).To(MatchError(cause))
+}
+
+func Test_Client_Pagination(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	// more accounts and RRSP activities than fit on a page
+	f := wstest.DefaultFixtures()
+	for i := range 2 * pageSize {
+		a := f.Account("rrsp-def456")
+		id := fmt.Sprintf("rrsp-%03d", i)
+		a.AccountCore.Id, a.AccountFinancials.Id = id, id
+		f.Accounts = append(f.Accounts, a)
+
+		act := f.Activity("act-7")
+		act.CanonicalId = lo.ToPtr(fmt.Sprintf("act-7-%03d", i))
+		act.OccurredAt = lo.ToPtr(act.OccurredAt.Add(-time.Duration(i+1) * time.Hour))
+		f.Activities = append(f.Activities, act)
+	}
+
+	srv := wstest.NewServer(wstest.WithFixtures(f))
+	defer srv.Close()
+	c, err := NewClient(ctx, base.DefaultAuthClient(srv.Credentials(),
+		base.WithEndpoints(srv.Endpoints()),
+		base.WithRateLimiters(nil, nil),
+		base.WithRetryPolicy(base.NoRetry),
+	))
+	g.Expect(err).ToNot(HaveOccurred())
+
+	accounts, err := c.GetAccounts(ctx)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(accounts).To(HaveLen(len(f.Accounts)))
+	g.Expect(accounts[len(accounts)-1].Id).To(Equal("rrsp-049"))
+	g.Expect(srv.Requests(endpoints.MyWealthsimpleGetGraphQl)).To(Equal(3))
+
+	activities, err := c.GetActivities(ctx, []AccountId{"tfsa-abc123", "rrsp-def456"}, nil, nil)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(activities["tfsa-abc123"]).To(HaveLen(6))
+	rrsp := activities["rrsp-def456"]
+	g.Expect(rrsp).To(HaveLen(1 + 2*pageSize))
+	g.Expect(*rrsp[0].CanonicalId).To(Equal("act-7"))
+	g.Expect(*rrsp[len(rrsp)-1].CanonicalId).To(Equal("act-7-049"))
+	g.Expect(srv.Requests(endpoints.MyWealthsimpleGetGraphQl)).To(Equal(6))
+}
+
+func Test_Client_IncompleteList(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+	expiry := time.Now().Add(time.Hour)
+
+	// a page announcing more items without the cursor to fetch them
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"data":{"activityFeedItems":{"edges":[],"pageInfo":{"hasNextPage":true,"endCursor":""}},`+
+			`"identity":{"id":"identity-1","accounts":{"edges":[],"pageInfo":{"hasNextPage":true,"endCursor":""}}}}}`)
+	}))
+	defer srv.Close()
+
+	c := &client{
+		tradeClient: graphql.NewClient(srv.URL, srv.Client()),
+		sessions: creds.StaticTokenFetcher(types.Session{
+			AccessToken: "someAccessToken",
+			Expiry:      &expiry,
+			Scopes:      []types.Scope{types.ScopeTradeRead},
+		}),
+		Identities: &base.TokenInformation{IdentityId: "identity-1"},
+	}
+
+	_, err := c.GetActivities(ctx, []AccountId{"tfsa-abc123"}, nil, nil)
+	g.Expect(err).To(MatchError(ErrIncompleteList))
+	_, err = c.GetAccounts(ctx)
+	g.Expect(err).To(MatchError(ErrIncompleteList))
+}
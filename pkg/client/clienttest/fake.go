@@ -0,0 +1,226 @@
+// Package clienttest provides test doubles of client.Client so code
+// taking a client can be unit tested without credentials.
+//
+// Fake is an in-memory client answering from seeded accounts,
+// activities and securities, with injectable errors and latency.
+// Interactions can be asserted with the gomock mocks generated in
+// pkg/client/mocks and pkg/auth/creds/mocks instead.
+package clienttest
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/samber/lo"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"github.com/vpnda/wsfetch/pkg/client"
+	"github.com/vpnda/wsfetch/pkg/client/generated"
+	"github.com/vpnda/wsfetch/pkg/wstest"
+)
+
+// Methods of client.Client, used to inject errors and count calls
+const (
+	GetAccount            = "GetAccount"
+	GetAccounts           = "GetAccounts"
+	GetActivities         = "GetActivities"
+	GetSecurityMarketData = "GetSecurityMarketData"
+)
+
+var _ client.Client = &Fake{}
+
+// Fake is an in-memory client.Client, it is safe for concurrent use
+type Fake struct {
+	mu         sync.Mutex
+	accounts   []generated.AccountWithFinancials
+	activities []generated.Activity
+	securities []generated.SecurityMarketData
+	errs       map[string]error
+	latency    time.Duration
+	calls      map[string]int
+}
+
+// Option configures the fake
+type Option func(*Fake)
+
+// WithAccounts seeds the accounts returned by GetAccount and GetAccounts
+func WithAccounts(accounts ...generated.AccountWithFinancials) Option {
+	return func(f *Fake) {
+		f.accounts = append(f.accounts, accounts...)
+	}
+}
+
+// WithActivities seeds the activities returned by GetActivities
+func WithActivities(activities ...generated.Activity) Option {
+	return func(f *Fake) {
+		f.activities = append(f.activities, activities...)
+	}
+}
+
+// WithSecurities seeds the market data returned by GetSecurityMarketData
+func WithSecurities(securities ...generated.SecurityMarketData) Option {
+	return func(f *Fake) {
+		f.securities = append(f.securities, securities...)
+	}
+}
+
+// WithFixtures seeds the data served by the wstest fake server, use
+// wstest.DefaultFixtures for a ready made set
+func WithFixtures(fixtures wstest.Fixtures) Option {
+	return func(f *Fake) {
+		WithAccounts(fixtures.Accounts...)(f)
+		WithActivities(fixtures.Activities...)(f)
+		WithSecurities(fixtures.Securities...)(f)
+	}
+}
+
+// WithError makes every call to method fail with err
+func WithError(method string, err error) Option {
+	return func(f *Fake) {
+		f.errs[method] = err
+	}
+}
+
+// WithLatency delays every call by d, calls return early with the
+// context error when it is done first
+func WithLatency(d time.Duration) Option {
+	return func(f *Fake) {
+		f.latency = d
+	}
+}
+
+// NewFake creates a fake client, empty unless seeded
+func NewFake(opts ...Option) *Fake {
+	f := &Fake{
+		errs:  map[string]error{},
+		calls: map[string]int{},
+	}
+	for _, opt := range opts {
+		opt(f)
+	}
+	return f
+}
+
+// SetError makes the following calls to method fail with err, a nil
+// error makes them succeed again
+func (f *Fake) SetError(method string, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err == nil {
+		delete(f.errs, method)
+		return
+	}
+	f.errs[method] = err
+}
+
+// SetLatency changes the delay of the following calls
+func (f *Fake) SetLatency(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.latency = d
+}
+
+// Calls returns how many times method was called
+func (f *Fake) Calls(method string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.calls[method]
+}
+
+// call records a call to method, waits for the latency and returns
+// the error injected for the method if any
+func (f *Fake) call(ctx context.Context, method string) error {
+	f.mu.Lock()
+	f.calls[method]++
+	latency, err := f.latency, f.errs[method]
+	f.mu.Unlock()
+
+	if latency > 0 {
+		t := time.NewTimer(latency)
+		defer t.Stop()
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return err
+}
+
+// GetAccount implements client.Client.
+func (f *Fake) GetAccount(ctx context.Context, accountId string) (*generated.AccountWithFinancials, error) {
+	if err := f.call(ctx, GetAccount); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	account, ok := lo.Find(f.accounts, func(a generated.AccountWithFinancials) bool {
+		return a.Id == accountId
+	})
+	if !ok {
+		return nil, client.ErrNoAccountFound
+	}
+	return &account, nil
+}
+
+// GetAccounts implements client.Client.
+func (f *Fake) GetAccounts(ctx context.Context) ([]generated.AccountWithFinancials, error) {
+	if err := f.call(ctx, GetAccounts); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return slices.Clone(f.accounts), nil
+}
+
+// GetActivities implements client.Client. Activities are filtered the way
+// Wealthsimple does, the date range being inclusive, and sorted from the
+// most recent.
+func (f *Fake) GetActivities(ctx context.Context, accountIds []client.AccountId, from *time.Time, until *time.Time) (map[client.AccountId][]generated.Activity, error) {
+	if err := f.call(ctx, GetActivities); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	activities := lo.Filter(f.activities, func(a generated.Activity, _ int) bool {
+		occurredAt := lo.FromPtr(a.OccurredAt)
+		return (len(accountIds) == 0 || lo.Contains(accountIds, client.AccountId(a.AccountId))) &&
+			(from == nil || !occurredAt.Before(*from)) &&
+			(until == nil || !occurredAt.After(*until))
+	})
+	f.mu.Unlock()
+
+	slices.SortStableFunc(activities, func(a, b generated.Activity) int {
+		return lo.FromPtr(b.OccurredAt).Compare(lo.FromPtr(a.OccurredAt))
+	})
+	result := make(map[client.AccountId][]generated.Activity)
+	for _, a := range activities {
+		id := client.AccountId(a.AccountId)
+		result[id] = append(result[id], a)
+	}
+	return result, nil
+}
+
+// GetSecurityMarketData implements client.Client. Unknown securities fail
+// with a client.GraphQLError, like they do against Wealthsimple.
+func (f *Fake) GetSecurityMarketData(ctx context.Context, securityID string) (*generated.SecurityMarketData, error) {
+	if err := f.call(ctx, GetSecurityMarketData); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	security, ok := lo.Find(f.securities, func(s generated.SecurityMarketData) bool {
+		return s.Id == securityID
+	})
+	if !ok {
+		return nil, &client.GraphQLError{
+			Operation: GetSecurityMarketData,
+			Errors:    gqlerror.List{gqlerror.Errorf("security %s not found", securityID)},
+		}
+	}
+	return &security, nil
+}
@@ -0,0 +1,125 @@
+package clienttest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/gomega"
+	"github.com/samber/lo"
+	"github.com/vpnda/wsfetch/pkg/client"
+	"github.com/vpnda/wsfetch/pkg/client/generated"
+	"github.com/vpnda/wsfetch/pkg/client/mocks"
+	"github.com/vpnda/wsfetch/pkg/wstest"
+)
+
+func canonicalIds(activities []generated.Activity) []string {
+	return lo.Map(activities, func(a generated.Activity, _ int) string { return lo.FromPtr(a.CanonicalId) })
+}
+
+func Test_Fake_GetActivities(t *testing.T) {
+	from := time.Date(2024, 4, 28, 9, 0, 0, 0, time.UTC)
+	until := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name       string
+		accountIds []client.AccountId
+		from       *time.Time
+		until      *time.Time
+		expected   map[client.AccountId][]string
+	}{
+		{
+			name: "everything",
+			expected: map[client.AccountId][]string{
+				"tfsa-abc123": {"act-1", "act-2", "act-3", "act-4", "act-5", "act-6"},
+				"rrsp-def456": {"act-7"},
+			},
+		},
+		{
+			name:       "single account",
+			accountIds: []client.AccountId{"rrsp-def456"},
+			expected: map[client.AccountId][]string{
+				"rrsp-def456": {"act-7"},
+			},
+		},
+		{
+			name:  "inclusive date range",
+			from:  &from,
+			until: &until,
+			expected: map[client.AccountId][]string{
+				"tfsa-abc123": {"act-2", "act-3", "act-4", "act-5"},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			f := NewFake(WithFixtures(wstest.DefaultFixtures()))
+
+			activities, err := f.GetActivities(context.Background(), tc.accountIds, tc.from, tc.until)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(lo.MapValues(activities, func(a []generated.Activity, _ client.AccountId) []string {
+				return canonicalIds(a)
+			})).To(Equal(tc.expected))
+		})
+	}
+}
+
+func Test_Fake_Lookups(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+	f := NewFake(WithFixtures(wstest.DefaultFixtures()))
+
+	account, err := f.GetAccount(ctx, "rrsp-def456")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(account.Nickname).To(Equal(lo.ToPtr("Retirement")))
+
+	_, err = f.GetAccount(ctx, "unknown")
+	g.Expect(err).To(MatchError(client.ErrNoAccountFound))
+
+	security, err := f.GetSecurityMarketData(ctx, "sec-s-aapl")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(security.Id).To(Equal("sec-s-aapl"))
+
+	_, err = f.GetSecurityMarketData(ctx, "unknown")
+	var gqlErr *client.GraphQLError
+	g.Expect(errors.As(err, &gqlErr)).To(BeTrue())
+
+	g.Expect(f.Calls(GetAccount)).To(Equal(2))
+	g.Expect(f.Calls(GetSecurityMarketData)).To(Equal(2))
+}
+
+func Test_Fake_InjectedFailures(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+	boom := errors.New("boom")
+	f := NewFake(WithError(GetAccounts, boom))
+
+	_, err := f.GetAccounts(ctx)
+	g.Expect(err).To(MatchError(boom))
+
+	f.SetError(GetAccounts, nil)
+	_, err = f.GetAccounts(ctx)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	f.SetLatency(time.Hour)
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	_, err = f.GetAccounts(ctx)
+	g.Expect(err).To(MatchError(context.DeadlineExceeded))
+}
+
+func Test_MockClient(t *testing.T) {
+	g := NewWithT(t)
+	ctrl := gomock.NewController(t)
+
+	m := mocks.NewMockClient(ctrl)
+	m.EXPECT().GetAccount(gomock.Any(), "tfsa-abc123").Return(nil, client.ErrNoAccountFound)
+
+	var c client.Client = m
+	_, err := c.GetAccount(context.Background(), "tfsa-abc123")
+	g.Expect(err).To(MatchError(client.ErrNoAccountFound))
+}
@@ -3,51 +3,15 @@ package client
 import (
 	"context"
 	"fmt"
-	"strconv"
 	"strings"
 
 	"github.com/samber/lo"
 	"github.com/vpnda/wsfetch/pkg/client/generated"
+	"github.com/vpnda/wsfetch/pkg/money"
 	"golang.org/x/text/cases"
 	"golang.org/x/text/language"
 )
 
-var (
-	outflowActities = []generated.ActivityType{
-		generated.ActivityTypeWithdrawal,
-		generated.ActivityTypeDiyBuy,
-		generated.ActivityTypeManagedBuy,
-	}
-	inflowActivities = []generated.ActivityType{
-		generated.ActivityTypeDeposit,
-		generated.ActivityTypeDiySell,
-		generated.ActivityTypeManagedSell,
-		generated.ActivityTypeInterest,
-		generated.ActivityTypeDividend,
-		generated.ActivityTypeRefund,
-	}
-)
-
-// GetFormattedAmount returns a formatted amount for the given activity
-// negative are considered income, positive are considered outflows or expenses
-func GetFormattedAmount(act *generated.Activity) string {
-	prefix := ""
-	// We have to introspectively check the type of the account
-	// as the hints from wealthsimple aren't great
-
-	if lo.Contains(outflowActities, act.Type) {
-		// DO NOTHING
-	} else if lo.Contains(inflowActivities, act.Type) ||
-		act.AmountSign == generated.AmountSignNegative {
-		prefix = "-"
-	} else if act.Type == generated.ActivityTypeP2pPayment {
-		if act.SubType == generated.ActivitySubtypeSend {
-			prefix = "-"
-		}
-	}
-	return fmt.Sprintf("%s%s", prefix, act.Amount)
-}
-
 // GetActivityDescription returns a description for the given activity
 func GetActivityDescription(ctx context.Context, c Client, act *generated.Activity) (string, error) {
 	baseDescription := fmt.Sprintf("%s: %s", capitalizeEnum(string(act.Type)), capitalizeEnum(string(act.SubType)))
@@ -71,13 +35,7 @@ func GetActivityDescription(ctx context.Context, c Client, act *generated.Activi
 		}
 		return fmt.Sprintf("Dividend: %s", security), nil
 	case generated.ActivityTypeFundsConversion:
-		fromCurrency := "USD"
-		if *act.Currency == "CAD" {
-			fromCurrency = "USD"
-		} else {
-			fromCurrency = "CAD"
-		}
-		return fmt.Sprintf("Funds converted: %s from %s", *act.Currency, fromCurrency), nil
+		return fundsConversionDescription(act), nil
 	case generated.ActivityTypeNonResidentTax:
 		return "Non-resident tax", nil
 	case generated.ActivityTypeCryptoTransfer:
@@ -141,6 +99,24 @@ func handleDepositWithdrawal(act *generated.Activity) (string, error) {
 
 }
 
+// fundsConversionDescription tells which currency was converted to which,
+// the activity being in the currency bought unless its amount is negative
+func fundsConversionDescription(act *generated.Activity) string {
+	currency := lo.FromPtr(act.Currency)
+	other := lo.FromPtr(act.CounterPartyCurrency)
+	if other == "" {
+		// accounts only hold CAD and USD
+		other = "CAD"
+		if currency == "CAD" {
+			other = "USD"
+		}
+	}
+	if act.AmountSign == generated.AmountSignNegative {
+		return fmt.Sprintf("Funds converted: %s from %s", other, currency)
+	}
+	return fmt.Sprintf("Funds converted: %s from %s", currency, other)
+}
+
 func cryptoActivityDescription(act *generated.Activity) (string, error) {
 	symbol := SecuritySymbol(*act.AssetSymbol)
 	if act.SubType == generated.ActivitySubtypeTransferIn {
@@ -177,11 +153,11 @@ func securityActivityDescription(ctx context.Context, c Client, act *generated.A
 		return "", err
 	}
 
-	assetQuantity, _ := strconv.ParseFloat(act.AssetQuantity, 64)
-	amount, _ := strconv.ParseFloat(act.Amount, 64)
-	price := amount / assetQuantity
-
-	return fmt.Sprintf("%s: %s %g x %s @ %0.2f", verb, action, assetQuantity, security, price), nil
+	price, err := act.Amount.Div(act.AssetQuantity, 2, money.HalfEven)
+	if err != nil {
+		return fmt.Sprintf("%s: %s %s x %s", verb, action, act.AssetQuantity.Trim(), security), nil
+	}
+	return fmt.Sprintf("%s: %s %s x %s @ %s", verb, action, act.AssetQuantity.Trim(), security, price), nil
 }
 
 func internalTransferDescription(ctx context.Context, c Client, act *generated.Activity) (string, error) {
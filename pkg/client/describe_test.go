@@ -0,0 +1,94 @@
+package client
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/samber/lo"
+	"github.com/vpnda/wsfetch/pkg/auth/types"
+	"github.com/vpnda/wsfetch/pkg/base"
+	"github.com/vpnda/wsfetch/pkg/cassette"
+	"github.com/vpnda/wsfetch/pkg/client/generated"
+)
+
+// Test_GetActivityDescription_Recorded describes activities replayed
+// from testdata/describe, recorded with the shapes Wealthsimple returns
+func Test_GetActivityDescription_Recorded(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	replayer, err := cassette.NewReplayer("testdata/describe")
+	g.Expect(err).ToNot(HaveOccurred())
+
+	expiry := time.Now().Add(time.Hour)
+	ws := base.StatictAuthClient(types.Session{AccessToken: "someAccessToken", Expiry: &expiry},
+		base.WithRateLimiters(nil, nil),
+		base.WithTransport(replayer),
+	)
+	c, err := NewClient(ctx, ws)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	const accountId = "tfsa-abc123"
+	activities, err := c.GetActivities(ctx, []AccountId{accountId},
+		lo.ToPtr(time.Now().Add(-30*24*time.Hour)), lo.ToPtr(time.Now()))
+	g.Expect(err).ToNot(HaveOccurred())
+
+	type described struct {
+		Description string
+		Amount      string
+	}
+	var got []described
+	for _, act := range activities[accountId] {
+		desc, err := GetActivityDescription(ctx, c, &act)
+		g.Expect(err).ToNot(HaveOccurred())
+		got = append(got, described{desc, CashFlow(&act).Signed(InflowPositive).String()})
+	}
+
+	g.Expect(got).To(Equal([]described{
+		{"Market Order: buy 2 x NASDAQ:AAPL @ 150.75", "-301.50"},
+		{"Dividend: VFV", "12.34"},
+		{"Transfer out: Transfer to Wealthsimple Retirement", "-500.00"},
+		{"Deposit: Interac e-transfer from Jane Doe ([REDACTED])", "1000.00"},
+		{"Withdrawal: Bill Pay to Hydro ([REDACTED])", "-85.20"},
+		{"Stock Lending Earnings", "0.42"},
+		{"Cash sent to $friend", "-20.00"},
+	}))
+}
+
+func Test_GetActivityDescription_FundsConversion(t *testing.T) {
+	testCases := []struct {
+		name     string
+		act      generated.Activity
+		expected string
+	}{
+		{
+			name: "bought USD",
+			act: generated.Activity{Currency: lo.ToPtr("USD"), CounterPartyCurrency: lo.ToPtr("CAD"),
+				AmountSign: generated.AmountSignPositive},
+			expected: "Funds converted: USD from CAD",
+		},
+		{
+			name: "sold USD",
+			act: generated.Activity{Currency: lo.ToPtr("USD"), CounterPartyCurrency: lo.ToPtr("CAD"),
+				AmountSign: generated.AmountSignNegative},
+			expected: "Funds converted: CAD from USD",
+		},
+		{
+			name:     "no counter party currency",
+			act:      generated.Activity{Currency: lo.ToPtr("CAD"), AmountSign: generated.AmountSignPositive},
+			expected: "Funds converted: CAD from USD",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			tc.act.Type = generated.ActivityTypeFundsConversion
+			desc, err := GetActivityDescription(context.Background(), nil, &tc.act)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(desc).To(Equal(tc.expected))
+		})
+	}
+}
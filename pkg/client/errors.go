@@ -1,8 +1,62 @@
 package client
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"github.com/vpnda/wsfetch/pkg/auth/types"
+)
 
 var (
 	// ErrNoAccountFound is returned when no account is found for the given account ID
 	ErrNoAccountFound = errors.New("no account found for the given account ID")
+
+	// ErrIncompleteList is returned when a page says more items follow
+	// without the cursor to fetch them, rather than returning the
+	// items fetched as if they were all of them
+	ErrIncompleteList = errors.New("more items were reported without a cursor to fetch them")
 )
+
+// InsufficientScopeError is returned when the session lacks the scopes
+// an operation needs, before any request is sent
+type InsufficientScopeError struct {
+	Operation string
+	Required  []types.Scope
+	Granted   []types.Scope
+}
+
+func (e *InsufficientScopeError) Error() string {
+	return fmt.Sprintf("%s requires scopes %q, session was granted %q",
+		e.Operation, types.FormatScopes(e.Required), types.FormatScopes(e.Granted))
+}
+
+// GraphQLError is returned when Wealthsimple answers a query with
+// GraphQL errors instead of data
+type GraphQLError struct {
+	Operation string
+	Errors    gqlerror.List
+}
+
+func (e *GraphQLError) Error() string {
+	return fmt.Sprintf("%s failed: %s", e.Operation, e.Errors.Error())
+}
+
+func (e *GraphQLError) Unwrap() []error {
+	errs := make([]error, len(e.Errors))
+	for i, err := range e.Errors {
+		errs[i] = err
+	}
+	return errs
+}
+
+// wrapError tags err with the operation that failed, GraphQL errors
+// are turned into a GraphQLError so callers can tell them apart
+// from transport failures
+func wrapError(operation string, err error) error {
+	var list gqlerror.List
+	if errors.As(err, &list) {
+		return &GraphQLError{Operation: operation, Errors: list}
+	}
+	return fmt.Errorf("%s failed: %w", operation, err)
+}
@@ -10,6 +10,7 @@ import (
 
 	"github.com/Khan/genqlient/graphql"
 	"github.com/vpnda/wsfetch/internal/marshalling"
+	"github.com/vpnda/wsfetch/pkg/money"
 )
 
 // Account includes the GraphQL fields of Account requested by the fragment Account.
@@ -745,7 +746,7 @@ type AccountCurrentFinancialsNetDepositsMoney struct {
 func (v *AccountCurrentFinancialsNetDepositsMoney) GetTypename() *string { return v.Typename }
 
 // GetAmount returns AccountCurrentFinancialsNetDepositsMoney.Amount, and is useful for accessing the field via an interface.
-func (v *AccountCurrentFinancialsNetDepositsMoney) GetAmount() string { return v.Money.Amount }
+func (v *AccountCurrentFinancialsNetDepositsMoney) GetAmount() money.Decimal { return v.Money.Amount }
 
 // GetCents returns AccountCurrentFinancialsNetDepositsMoney.Cents, and is useful for accessing the field via an interface.
 func (v *AccountCurrentFinancialsNetDepositsMoney) GetCents() int { return v.Money.Cents }
@@ -781,7 +782,7 @@ func (v *AccountCurrentFinancialsNetDepositsMoney) UnmarshalJSON(b []byte) error
 type __premarshalAccountCurrentFinancialsNetDepositsMoney struct {
 	Typename *string `json:"__typename"`
 
-	Amount string `json:"amount"`
+	Amount money.Decimal `json:"amount"`
 
 	Cents int `json:"cents"`
 
@@ -816,7 +817,7 @@ type AccountCurrentFinancialsNetLiquidationValueV2Money struct {
 func (v *AccountCurrentFinancialsNetLiquidationValueV2Money) GetTypename() *string { return v.Typename }
 
 // GetAmount returns AccountCurrentFinancialsNetLiquidationValueV2Money.Amount, and is useful for accessing the field via an interface.
-func (v *AccountCurrentFinancialsNetLiquidationValueV2Money) GetAmount() string {
+func (v *AccountCurrentFinancialsNetLiquidationValueV2Money) GetAmount() money.Decimal {
 	return v.Money.Amount
 }
 
@@ -856,7 +857,7 @@ func (v *AccountCurrentFinancialsNetLiquidationValueV2Money) UnmarshalJSON(b []b
 type __premarshalAccountCurrentFinancialsNetLiquidationValueV2Money struct {
 	Typename *string `json:"__typename"`
 
-	Amount string `json:"amount"`
+	Amount money.Decimal `json:"amount"`
 
 	Cents int `json:"cents"`
 
@@ -998,7 +999,7 @@ type AccountCurrentFinancialsTotalDepositsMoney struct {
 func (v *AccountCurrentFinancialsTotalDepositsMoney) GetTypename() *string { return v.Typename }
 
 // GetAmount returns AccountCurrentFinancialsTotalDepositsMoney.Amount, and is useful for accessing the field via an interface.
-func (v *AccountCurrentFinancialsTotalDepositsMoney) GetAmount() string { return v.Money.Amount }
+func (v *AccountCurrentFinancialsTotalDepositsMoney) GetAmount() money.Decimal { return v.Money.Amount }
 
 // GetCents returns AccountCurrentFinancialsTotalDepositsMoney.Cents, and is useful for accessing the field via an interface.
 func (v *AccountCurrentFinancialsTotalDepositsMoney) GetCents() int { return v.Money.Cents }
@@ -1034,7 +1035,7 @@ func (v *AccountCurrentFinancialsTotalDepositsMoney) UnmarshalJSON(b []byte) err
 type __premarshalAccountCurrentFinancialsTotalDepositsMoney struct {
 	Typename *string `json:"__typename"`
 
-	Amount string `json:"amount"`
+	Amount money.Decimal `json:"amount"`
 
 	Cents int `json:"cents"`
 
@@ -1069,7 +1070,9 @@ type AccountCurrentFinancialsTotalWithdrawalsMoney struct {
 func (v *AccountCurrentFinancialsTotalWithdrawalsMoney) GetTypename() *string { return v.Typename }
 
 // GetAmount returns AccountCurrentFinancialsTotalWithdrawalsMoney.Amount, and is useful for accessing the field via an interface.
-func (v *AccountCurrentFinancialsTotalWithdrawalsMoney) GetAmount() string { return v.Money.Amount }
+func (v *AccountCurrentFinancialsTotalWithdrawalsMoney) GetAmount() money.Decimal {
+	return v.Money.Amount
+}
 
 // GetCents returns AccountCurrentFinancialsTotalWithdrawalsMoney.Cents, and is useful for accessing the field via an interface.
 func (v *AccountCurrentFinancialsTotalWithdrawalsMoney) GetCents() int { return v.Money.Cents }
@@ -1105,7 +1108,7 @@ func (v *AccountCurrentFinancialsTotalWithdrawalsMoney) UnmarshalJSON(b []byte)
 type __premarshalAccountCurrentFinancialsTotalWithdrawalsMoney struct {
 	Typename *string `json:"__typename"`
 
-	Amount string `json:"amount"`
+	Amount money.Decimal `json:"amount"`
 
 	Cents int `json:"cents"`
 
@@ -1299,6 +1302,7 @@ func (v *AccountFinancialsCustodianAccountsCustodianAccount) GetTypename() *stri
 // AccountFinancialsCustodianAccountsCustodianAccountFinancials includes the requested fields of the GraphQL type CustodianAccountFinancials.
 type AccountFinancialsCustodianAccountsCustodianAccountFinancials struct {
 	Current  *AccountFinancialsCustodianAccountsCustodianAccountFinancialsCurrentCustodianAccountCurrentFinancialValues `json:"current"`
+	Balance  []AccountFinancialsCustodianAccountsCustodianAccountFinancialsBalance                                      `json:"balance"`
 	Typename *string                                                                                                    `json:"__typename"`
 }
 
@@ -1307,11 +1311,38 @@ func (v *AccountFinancialsCustodianAccountsCustodianAccountFinancials) GetCurren
 	return v.Current
 }
 
+// GetBalance returns AccountFinancialsCustodianAccountsCustodianAccountFinancials.Balance, and is useful for accessing the field via an interface.
+func (v *AccountFinancialsCustodianAccountsCustodianAccountFinancials) GetBalance() []AccountFinancialsCustodianAccountsCustodianAccountFinancialsBalance {
+	return v.Balance
+}
+
 // GetTypename returns AccountFinancialsCustodianAccountsCustodianAccountFinancials.Typename, and is useful for accessing the field via an interface.
 func (v *AccountFinancialsCustodianAccountsCustodianAccountFinancials) GetTypename() *string {
 	return v.Typename
 }
 
+// AccountFinancialsCustodianAccountsCustodianAccountFinancialsBalance includes the requested fields of the GraphQL type Balance.
+type AccountFinancialsCustodianAccountsCustodianAccountFinancialsBalance struct {
+	Quantity   money.Decimal `json:"quantity"`
+	SecurityId string        `json:"securityId"`
+	Typename   *string       `json:"__typename"`
+}
+
+// GetQuantity returns AccountFinancialsCustodianAccountsCustodianAccountFinancialsBalance.Quantity, and is useful for accessing the field via an interface.
+func (v *AccountFinancialsCustodianAccountsCustodianAccountFinancialsBalance) GetQuantity() money.Decimal {
+	return v.Quantity
+}
+
+// GetSecurityId returns AccountFinancialsCustodianAccountsCustodianAccountFinancialsBalance.SecurityId, and is useful for accessing the field via an interface.
+func (v *AccountFinancialsCustodianAccountsCustodianAccountFinancialsBalance) GetSecurityId() string {
+	return v.SecurityId
+}
+
+// GetTypename returns AccountFinancialsCustodianAccountsCustodianAccountFinancialsBalance.Typename, and is useful for accessing the field via an interface.
+func (v *AccountFinancialsCustodianAccountsCustodianAccountFinancialsBalance) GetTypename() *string {
+	return v.Typename
+}
+
 // AccountFinancialsCustodianAccountsCustodianAccountFinancialsCurrentCustodianAccountCurrentFinancialValues includes the requested fields of the GraphQL type CustodianAccountCurrentFinancialValues.
 type AccountFinancialsCustodianAccountsCustodianAccountFinancialsCurrentCustodianAccountCurrentFinancialValues struct {
 	CustodianAccountCurrentFinancialValues `json:"-"`
@@ -2731,11 +2762,11 @@ type Activity struct {
 	// Type of the AFT transaction
 	AftTransactionType *string `json:"aftTransactionType"`
 	// Amount of the transaction
-	Amount string `json:"amount"`
+	Amount money.Decimal `json:"amount"`
 	// Sign of the amount (positive/negative)
 	AmountSign AmountSign `json:"amountSign"`
 	// Quantity of the asset
-	AssetQuantity string `json:"assetQuantity"`
+	AssetQuantity money.Decimal `json:"assetQuantity"`
 	// Symbol of the asset
 	AssetSymbol *string `json:"assetSymbol"`
 	// Canonical identifier
@@ -2777,7 +2808,7 @@ type Activity struct {
 	// Type of the activity
 	Type ActivityType `json:"type"`
 	// Strike price for options
-	StrikePrice *string `json:"strikePrice"`
+	StrikePrice *money.Decimal `json:"strikePrice"`
 	// Type of contract
 	ContractType *string `json:"contractType"`
 	// Expiry date for contracts
@@ -2785,7 +2816,7 @@ type Activity struct {
 	// Cheque number
 	ChequeNumber *string `json:"chequeNumber"`
 	// Provisional credit amount
-	ProvisionalCreditAmount *string `json:"provisionalCreditAmount"`
+	ProvisionalCreditAmount *money.Decimal `json:"provisionalCreditAmount"`
 	// Primary blocker
 	PrimaryBlocker *string `json:"primaryBlocker"`
 	// Interest rate
@@ -2799,11 +2830,11 @@ type Activity struct {
 	// Currency of the counter party
 	CounterPartyCurrency *string `json:"counterPartyCurrency"`
 	// Counter party currency amount
-	CounterPartyCurrencyAmount *string `json:"counterPartyCurrencyAmount"`
+	CounterPartyCurrencyAmount *money.Decimal `json:"counterPartyCurrencyAmount"`
 	// Name of the counter party
 	CounterPartyName *string `json:"counterPartyName"`
 	// Foreign exchange rate
-	FxRate *string `json:"fxRate"`
+	FxRate *money.Decimal `json:"fxRate"`
 	// Fees associated with the transaction
 	Fees *string `json:"fees"`
 	// Reference information
@@ -2824,13 +2855,13 @@ func (v *Activity) GetAftTransactionCategory() *string { return v.AftTransaction
 func (v *Activity) GetAftTransactionType() *string { return v.AftTransactionType }
 
 // GetAmount returns Activity.Amount, and is useful for accessing the field via an interface.
-func (v *Activity) GetAmount() string { return v.Amount }
+func (v *Activity) GetAmount() money.Decimal { return v.Amount }
 
 // GetAmountSign returns Activity.AmountSign, and is useful for accessing the field via an interface.
 func (v *Activity) GetAmountSign() AmountSign { return v.AmountSign }
 
 // GetAssetQuantity returns Activity.AssetQuantity, and is useful for accessing the field via an interface.
-func (v *Activity) GetAssetQuantity() string { return v.AssetQuantity }
+func (v *Activity) GetAssetQuantity() money.Decimal { return v.AssetQuantity }
 
 // GetAssetSymbol returns Activity.AssetSymbol, and is useful for accessing the field via an interface.
 func (v *Activity) GetAssetSymbol() *string { return v.AssetSymbol }
@@ -2893,7 +2924,7 @@ func (v *Activity) GetSubType() ActivitySubtype { return v.SubType }
 func (v *Activity) GetType() ActivityType { return v.Type }
 
 // GetStrikePrice returns Activity.StrikePrice, and is useful for accessing the field via an interface.
-func (v *Activity) GetStrikePrice() *string { return v.StrikePrice }
+func (v *Activity) GetStrikePrice() *money.Decimal { return v.StrikePrice }
 
 // GetContractType returns Activity.ContractType, and is useful for accessing the field via an interface.
 func (v *Activity) GetContractType() *string { return v.ContractType }
@@ -2905,7 +2936,7 @@ func (v *Activity) GetExpiryDate() *time.Time { return v.ExpiryDate }
 func (v *Activity) GetChequeNumber() *string { return v.ChequeNumber }
 
 // GetProvisionalCreditAmount returns Activity.ProvisionalCreditAmount, and is useful for accessing the field via an interface.
-func (v *Activity) GetProvisionalCreditAmount() *string { return v.ProvisionalCreditAmount }
+func (v *Activity) GetProvisionalCreditAmount() *money.Decimal { return v.ProvisionalCreditAmount }
 
 // GetPrimaryBlocker returns Activity.PrimaryBlocker, and is useful for accessing the field via an interface.
 func (v *Activity) GetPrimaryBlocker() *string { return v.PrimaryBlocker }
@@ -2926,13 +2957,15 @@ func (v *Activity) GetRewardProgram() *string { return v.RewardProgram }
 func (v *Activity) GetCounterPartyCurrency() *string { return v.CounterPartyCurrency }
 
 // GetCounterPartyCurrencyAmount returns Activity.CounterPartyCurrencyAmount, and is useful for accessing the field via an interface.
-func (v *Activity) GetCounterPartyCurrencyAmount() *string { return v.CounterPartyCurrencyAmount }
+func (v *Activity) GetCounterPartyCurrencyAmount() *money.Decimal {
+	return v.CounterPartyCurrencyAmount
+}
 
 // GetCounterPartyName returns Activity.CounterPartyName, and is useful for accessing the field via an interface.
 func (v *Activity) GetCounterPartyName() *string { return v.CounterPartyName }
 
 // GetFxRate returns Activity.FxRate, and is useful for accessing the field via an interface.
-func (v *Activity) GetFxRate() *string { return v.FxRate }
+func (v *Activity) GetFxRate() *money.Decimal { return v.FxRate }
 
 // GetFees returns Activity.Fees, and is useful for accessing the field via an interface.
 func (v *Activity) GetFees() *string { return v.Fees }
@@ -3001,11 +3034,11 @@ type __premarshalActivity struct {
 
 	AftTransactionType *string `json:"aftTransactionType"`
 
-	Amount string `json:"amount"`
+	Amount money.Decimal `json:"amount"`
 
 	AmountSign AmountSign `json:"amountSign"`
 
-	AssetQuantity string `json:"assetQuantity"`
+	AssetQuantity money.Decimal `json:"assetQuantity"`
 
 	AssetSymbol *string `json:"assetSymbol"`
 
@@ -3047,7 +3080,7 @@ type __premarshalActivity struct {
 
 	Type ActivityType `json:"type"`
 
-	StrikePrice *string `json:"strikePrice"`
+	StrikePrice *money.Decimal `json:"strikePrice"`
 
 	ContractType *string `json:"contractType"`
 
@@ -3055,7 +3088,7 @@ type __premarshalActivity struct {
 
 	ChequeNumber *string `json:"chequeNumber"`
 
-	ProvisionalCreditAmount *string `json:"provisionalCreditAmount"`
+	ProvisionalCreditAmount *money.Decimal `json:"provisionalCreditAmount"`
 
 	PrimaryBlocker *string `json:"primaryBlocker"`
 
@@ -3069,11 +3102,11 @@ type __premarshalActivity struct {
 
 	CounterPartyCurrency *string `json:"counterPartyCurrency"`
 
-	CounterPartyCurrencyAmount *string `json:"counterPartyCurrencyAmount"`
+	CounterPartyCurrencyAmount *money.Decimal `json:"counterPartyCurrencyAmount"`
 
 	CounterPartyName *string `json:"counterPartyName"`
 
-	FxRate *string `json:"fxRate"`
+	FxRate *money.Decimal `json:"fxRate"`
 
 	Fees *string `json:"fees"`
 
@@ -3875,7 +3908,7 @@ func (v *CustodianAccountCurrentFinancialValuesDepositsMoney) GetTypename() *str
 }
 
 // GetAmount returns CustodianAccountCurrentFinancialValuesDepositsMoney.Amount, and is useful for accessing the field via an interface.
-func (v *CustodianAccountCurrentFinancialValuesDepositsMoney) GetAmount() string {
+func (v *CustodianAccountCurrentFinancialValuesDepositsMoney) GetAmount() money.Decimal {
 	return v.Money.Amount
 }
 
@@ -3915,7 +3948,7 @@ func (v *CustodianAccountCurrentFinancialValuesDepositsMoney) UnmarshalJSON(b []
 type __premarshalCustodianAccountCurrentFinancialValuesDepositsMoney struct {
 	Typename *string `json:"__typename"`
 
-	Amount string `json:"amount"`
+	Amount money.Decimal `json:"amount"`
 
 	Cents int `json:"cents"`
 
@@ -3952,7 +3985,7 @@ func (v *CustodianAccountCurrentFinancialValuesEarningsMoney) GetTypename() *str
 }
 
 // GetAmount returns CustodianAccountCurrentFinancialValuesEarningsMoney.Amount, and is useful for accessing the field via an interface.
-func (v *CustodianAccountCurrentFinancialValuesEarningsMoney) GetAmount() string {
+func (v *CustodianAccountCurrentFinancialValuesEarningsMoney) GetAmount() money.Decimal {
 	return v.Money.Amount
 }
 
@@ -3992,7 +4025,7 @@ func (v *CustodianAccountCurrentFinancialValuesEarningsMoney) UnmarshalJSON(b []
 type __premarshalCustodianAccountCurrentFinancialValuesEarningsMoney struct {
 	Typename *string `json:"__typename"`
 
-	Amount string `json:"amount"`
+	Amount money.Decimal `json:"amount"`
 
 	Cents int `json:"cents"`
 
@@ -4029,7 +4062,7 @@ func (v *CustodianAccountCurrentFinancialValuesNetDepositsMoney) GetTypename() *
 }
 
 // GetAmount returns CustodianAccountCurrentFinancialValuesNetDepositsMoney.Amount, and is useful for accessing the field via an interface.
-func (v *CustodianAccountCurrentFinancialValuesNetDepositsMoney) GetAmount() string {
+func (v *CustodianAccountCurrentFinancialValuesNetDepositsMoney) GetAmount() money.Decimal {
 	return v.Money.Amount
 }
 
@@ -4069,7 +4102,7 @@ func (v *CustodianAccountCurrentFinancialValuesNetDepositsMoney) UnmarshalJSON(b
 type __premarshalCustodianAccountCurrentFinancialValuesNetDepositsMoney struct {
 	Typename *string `json:"__typename"`
 
-	Amount string `json:"amount"`
+	Amount money.Decimal `json:"amount"`
 
 	Cents int `json:"cents"`
 
@@ -4106,7 +4139,7 @@ func (v *CustodianAccountCurrentFinancialValuesNetLiquidationValueMoney) GetType
 }
 
 // GetAmount returns CustodianAccountCurrentFinancialValuesNetLiquidationValueMoney.Amount, and is useful for accessing the field via an interface.
-func (v *CustodianAccountCurrentFinancialValuesNetLiquidationValueMoney) GetAmount() string {
+func (v *CustodianAccountCurrentFinancialValuesNetLiquidationValueMoney) GetAmount() money.Decimal {
 	return v.Money.Amount
 }
 
@@ -4148,7 +4181,7 @@ func (v *CustodianAccountCurrentFinancialValuesNetLiquidationValueMoney) Unmarsh
 type __premarshalCustodianAccountCurrentFinancialValuesNetLiquidationValueMoney struct {
 	Typename *string `json:"__typename"`
 
-	Amount string `json:"amount"`
+	Amount money.Decimal `json:"amount"`
 
 	Cents int `json:"cents"`
 
@@ -4185,7 +4218,7 @@ func (v *CustodianAccountCurrentFinancialValuesWithdrawalsMoney) GetTypename() *
 }
 
 // GetAmount returns CustodianAccountCurrentFinancialValuesWithdrawalsMoney.Amount, and is useful for accessing the field via an interface.
-func (v *CustodianAccountCurrentFinancialValuesWithdrawalsMoney) GetAmount() string {
+func (v *CustodianAccountCurrentFinancialValuesWithdrawalsMoney) GetAmount() money.Decimal {
 	return v.Money.Amount
 }
 
@@ -4225,7 +4258,7 @@ func (v *CustodianAccountCurrentFinancialValuesWithdrawalsMoney) UnmarshalJSON(b
 type __premarshalCustodianAccountCurrentFinancialValuesWithdrawalsMoney struct {
 	Typename *string `json:"__typename"`
 
-	Amount string `json:"amount"`
+	Amount money.Decimal `json:"amount"`
 
 	Cents int `json:"cents"`
 
@@ -4332,7 +4365,7 @@ func (v *FetchActivityFeedItemsActivityFeedItemsActivityFeedItemConnectionEdgesA
 }
 
 // GetAmount returns FetchActivityFeedItemsActivityFeedItemsActivityFeedItemConnectionEdgesActivityFeedItemEdgeNodeActivityFeedItem.Amount, and is useful for accessing the field via an interface.
-func (v *FetchActivityFeedItemsActivityFeedItemsActivityFeedItemConnectionEdgesActivityFeedItemEdgeNodeActivityFeedItem) GetAmount() string {
+func (v *FetchActivityFeedItemsActivityFeedItemsActivityFeedItemConnectionEdgesActivityFeedItemEdgeNodeActivityFeedItem) GetAmount() money.Decimal {
 	return v.Activity.Amount
 }
 
@@ -4342,7 +4375,7 @@ func (v *FetchActivityFeedItemsActivityFeedItemsActivityFeedItemConnectionEdgesA
 }
 
 // GetAssetQuantity returns FetchActivityFeedItemsActivityFeedItemsActivityFeedItemConnectionEdgesActivityFeedItemEdgeNodeActivityFeedItem.AssetQuantity, and is useful for accessing the field via an interface.
-func (v *FetchActivityFeedItemsActivityFeedItemsActivityFeedItemConnectionEdgesActivityFeedItemEdgeNodeActivityFeedItem) GetAssetQuantity() string {
+func (v *FetchActivityFeedItemsActivityFeedItemsActivityFeedItemConnectionEdgesActivityFeedItemEdgeNodeActivityFeedItem) GetAssetQuantity() money.Decimal {
 	return v.Activity.AssetQuantity
 }
 
@@ -4447,7 +4480,7 @@ func (v *FetchActivityFeedItemsActivityFeedItemsActivityFeedItemConnectionEdgesA
 }
 
 // GetStrikePrice returns FetchActivityFeedItemsActivityFeedItemsActivityFeedItemConnectionEdgesActivityFeedItemEdgeNodeActivityFeedItem.StrikePrice, and is useful for accessing the field via an interface.
-func (v *FetchActivityFeedItemsActivityFeedItemsActivityFeedItemConnectionEdgesActivityFeedItemEdgeNodeActivityFeedItem) GetStrikePrice() *string {
+func (v *FetchActivityFeedItemsActivityFeedItemsActivityFeedItemConnectionEdgesActivityFeedItemEdgeNodeActivityFeedItem) GetStrikePrice() *money.Decimal {
 	return v.Activity.StrikePrice
 }
 
@@ -4467,7 +4500,7 @@ func (v *FetchActivityFeedItemsActivityFeedItemsActivityFeedItemConnectionEdgesA
 }
 
 // GetProvisionalCreditAmount returns FetchActivityFeedItemsActivityFeedItemsActivityFeedItemConnectionEdgesActivityFeedItemEdgeNodeActivityFeedItem.ProvisionalCreditAmount, and is useful for accessing the field via an interface.
-func (v *FetchActivityFeedItemsActivityFeedItemsActivityFeedItemConnectionEdgesActivityFeedItemEdgeNodeActivityFeedItem) GetProvisionalCreditAmount() *string {
+func (v *FetchActivityFeedItemsActivityFeedItemsActivityFeedItemConnectionEdgesActivityFeedItemEdgeNodeActivityFeedItem) GetProvisionalCreditAmount() *money.Decimal {
 	return v.Activity.ProvisionalCreditAmount
 }
 
@@ -4502,7 +4535,7 @@ func (v *FetchActivityFeedItemsActivityFeedItemsActivityFeedItemConnectionEdgesA
 }
 
 // GetCounterPartyCurrencyAmount returns FetchActivityFeedItemsActivityFeedItemsActivityFeedItemConnectionEdgesActivityFeedItemEdgeNodeActivityFeedItem.CounterPartyCurrencyAmount, and is useful for accessing the field via an interface.
-func (v *FetchActivityFeedItemsActivityFeedItemsActivityFeedItemConnectionEdgesActivityFeedItemEdgeNodeActivityFeedItem) GetCounterPartyCurrencyAmount() *string {
+func (v *FetchActivityFeedItemsActivityFeedItemsActivityFeedItemConnectionEdgesActivityFeedItemEdgeNodeActivityFeedItem) GetCounterPartyCurrencyAmount() *money.Decimal {
 	return v.Activity.CounterPartyCurrencyAmount
 }
 
@@ -4512,7 +4545,7 @@ func (v *FetchActivityFeedItemsActivityFeedItemsActivityFeedItemConnectionEdgesA
 }
 
 // GetFxRate returns FetchActivityFeedItemsActivityFeedItemsActivityFeedItemConnectionEdgesActivityFeedItemEdgeNodeActivityFeedItem.FxRate, and is useful for accessing the field via an interface.
-func (v *FetchActivityFeedItemsActivityFeedItemsActivityFeedItemConnectionEdgesActivityFeedItemEdgeNodeActivityFeedItem) GetFxRate() *string {
+func (v *FetchActivityFeedItemsActivityFeedItemsActivityFeedItemConnectionEdgesActivityFeedItemEdgeNodeActivityFeedItem) GetFxRate() *money.Decimal {
 	return v.Activity.FxRate
 }
 
@@ -4562,11 +4595,11 @@ type __premarshalFetchActivityFeedItemsActivityFeedItemsActivityFeedItemConnecti
 
 	AftTransactionType *string `json:"aftTransactionType"`
 
-	Amount string `json:"amount"`
+	Amount money.Decimal `json:"amount"`
 
 	AmountSign AmountSign `json:"amountSign"`
 
-	AssetQuantity string `json:"assetQuantity"`
+	AssetQuantity money.Decimal `json:"assetQuantity"`
 
 	AssetSymbol *string `json:"assetSymbol"`
 
@@ -4608,7 +4641,7 @@ type __premarshalFetchActivityFeedItemsActivityFeedItemsActivityFeedItemConnecti
 
 	Type ActivityType `json:"type"`
 
-	StrikePrice *string `json:"strikePrice"`
+	StrikePrice *money.Decimal `json:"strikePrice"`
 
 	ContractType *string `json:"contractType"`
 
@@ -4616,7 +4649,7 @@ type __premarshalFetchActivityFeedItemsActivityFeedItemsActivityFeedItemConnecti
 
 	ChequeNumber *string `json:"chequeNumber"`
 
-	ProvisionalCreditAmount *string `json:"provisionalCreditAmount"`
+	ProvisionalCreditAmount *money.Decimal `json:"provisionalCreditAmount"`
 
 	PrimaryBlocker *string `json:"primaryBlocker"`
 
@@ -4630,11 +4663,11 @@ type __premarshalFetchActivityFeedItemsActivityFeedItemsActivityFeedItemConnecti
 
 	CounterPartyCurrency *string `json:"counterPartyCurrency"`
 
-	CounterPartyCurrencyAmount *string `json:"counterPartyCurrencyAmount"`
+	CounterPartyCurrencyAmount *money.Decimal `json:"counterPartyCurrencyAmount"`
 
 	CounterPartyName *string `json:"counterPartyName"`
 
-	FxRate *string `json:"fxRate"`
+	FxRate *money.Decimal `json:"fxRate"`
 
 	Fees *string `json:"fees"`
 
@@ -4959,14 +4992,14 @@ func (v *MarginRates) GetTypename() *string { return v.Typename }
 
 // Money includes the GraphQL fields of Money requested by the fragment Money.
 type Money struct {
-	Amount   string  `json:"amount"`
-	Cents    int     `json:"cents"`
-	Currency string  `json:"currency"`
-	Typename *string `json:"__typename"`
+	Amount   money.Decimal `json:"amount"`
+	Cents    int           `json:"cents"`
+	Currency string        `json:"currency"`
+	Typename *string       `json:"__typename"`
 }
 
 // GetAmount returns Money.Amount, and is useful for accessing the field via an interface.
-func (v *Money) GetAmount() string { return v.Amount }
+func (v *Money) GetAmount() money.Decimal { return v.Amount }
 
 // GetCents returns Money.Cents, and is useful for accessing the field via an interface.
 func (v *Money) GetCents() int { return v.Cents }
@@ -5112,37 +5145,37 @@ func (v *SecurityMarketDataMarginRates) __premarshalJSON() (*__premarshalSecurit
 
 // SecurityMarketDataQuote includes the requested fields of the GraphQL type Quote.
 type SecurityMarketDataQuote struct {
-	Bid           string     `json:"bid"`
-	Ask           string     `json:"ask"`
-	Open          string     `json:"open"`
-	High          string     `json:"high"`
-	Low           string     `json:"low"`
-	Volume        int        `json:"volume"`
-	AskSize       int        `json:"askSize"`
-	BidSize       int        `json:"bidSize"`
-	Last          string     `json:"last"`
-	LastSize      int        `json:"lastSize"`
-	QuotedAsOf    *time.Time `json:"-"`
-	QuoteDate     *time.Time `json:"-"`
-	Amount        string     `json:"amount"`
-	PreviousClose string     `json:"previousClose"`
-	Typename      *string    `json:"__typename"`
+	Bid           money.Decimal `json:"bid"`
+	Ask           money.Decimal `json:"ask"`
+	Open          money.Decimal `json:"open"`
+	High          money.Decimal `json:"high"`
+	Low           money.Decimal `json:"low"`
+	Volume        int           `json:"volume"`
+	AskSize       int           `json:"askSize"`
+	BidSize       int           `json:"bidSize"`
+	Last          money.Decimal `json:"last"`
+	LastSize      int           `json:"lastSize"`
+	QuotedAsOf    *time.Time    `json:"-"`
+	QuoteDate     *time.Time    `json:"-"`
+	Amount        money.Decimal `json:"amount"`
+	PreviousClose money.Decimal `json:"previousClose"`
+	Typename      *string       `json:"__typename"`
 }
 
 // GetBid returns SecurityMarketDataQuote.Bid, and is useful for accessing the field via an interface.
-func (v *SecurityMarketDataQuote) GetBid() string { return v.Bid }
+func (v *SecurityMarketDataQuote) GetBid() money.Decimal { return v.Bid }
 
 // GetAsk returns SecurityMarketDataQuote.Ask, and is useful for accessing the field via an interface.
-func (v *SecurityMarketDataQuote) GetAsk() string { return v.Ask }
+func (v *SecurityMarketDataQuote) GetAsk() money.Decimal { return v.Ask }
 
 // GetOpen returns SecurityMarketDataQuote.Open, and is useful for accessing the field via an interface.
-func (v *SecurityMarketDataQuote) GetOpen() string { return v.Open }
+func (v *SecurityMarketDataQuote) GetOpen() money.Decimal { return v.Open }
 
 // GetHigh returns SecurityMarketDataQuote.High, and is useful for accessing the field via an interface.
-func (v *SecurityMarketDataQuote) GetHigh() string { return v.High }
+func (v *SecurityMarketDataQuote) GetHigh() money.Decimal { return v.High }
 
 // GetLow returns SecurityMarketDataQuote.Low, and is useful for accessing the field via an interface.
-func (v *SecurityMarketDataQuote) GetLow() string { return v.Low }
+func (v *SecurityMarketDataQuote) GetLow() money.Decimal { return v.Low }
 
 // GetVolume returns SecurityMarketDataQuote.Volume, and is useful for accessing the field via an interface.
 func (v *SecurityMarketDataQuote) GetVolume() int { return v.Volume }
@@ -5154,7 +5187,7 @@ func (v *SecurityMarketDataQuote) GetAskSize() int { return v.AskSize }
 func (v *SecurityMarketDataQuote) GetBidSize() int { return v.BidSize }
 
 // GetLast returns SecurityMarketDataQuote.Last, and is useful for accessing the field via an interface.
-func (v *SecurityMarketDataQuote) GetLast() string { return v.Last }
+func (v *SecurityMarketDataQuote) GetLast() money.Decimal { return v.Last }
 
 // GetLastSize returns SecurityMarketDataQuote.LastSize, and is useful for accessing the field via an interface.
 func (v *SecurityMarketDataQuote) GetLastSize() int { return v.LastSize }
@@ -5166,10 +5199,10 @@ func (v *SecurityMarketDataQuote) GetQuotedAsOf() *time.Time { return v.QuotedAs
 func (v *SecurityMarketDataQuote) GetQuoteDate() *time.Time { return v.QuoteDate }
 
 // GetAmount returns SecurityMarketDataQuote.Amount, and is useful for accessing the field via an interface.
-func (v *SecurityMarketDataQuote) GetAmount() string { return v.Amount }
+func (v *SecurityMarketDataQuote) GetAmount() money.Decimal { return v.Amount }
 
 // GetPreviousClose returns SecurityMarketDataQuote.PreviousClose, and is useful for accessing the field via an interface.
-func (v *SecurityMarketDataQuote) GetPreviousClose() string { return v.PreviousClose }
+func (v *SecurityMarketDataQuote) GetPreviousClose() money.Decimal { return v.PreviousClose }
 
 // GetTypename returns SecurityMarketDataQuote.Typename, and is useful for accessing the field via an interface.
 func (v *SecurityMarketDataQuote) GetTypename() *string { return v.Typename }
@@ -5224,15 +5257,15 @@ func (v *SecurityMarketDataQuote) UnmarshalJSON(b []byte) error {
 }
 
 type __premarshalSecurityMarketDataQuote struct {
-	Bid string `json:"bid"`
+	Bid money.Decimal `json:"bid"`
 
-	Ask string `json:"ask"`
+	Ask money.Decimal `json:"ask"`
 
-	Open string `json:"open"`
+	Open money.Decimal `json:"open"`
 
-	High string `json:"high"`
+	High money.Decimal `json:"high"`
 
-	Low string `json:"low"`
+	Low money.Decimal `json:"low"`
 
 	Volume int `json:"volume"`
 
@@ -5240,7 +5273,7 @@ type __premarshalSecurityMarketDataQuote struct {
 
 	BidSize int `json:"bidSize"`
 
-	Last string `json:"last"`
+	Last money.Decimal `json:"last"`
 
 	LastSize int `json:"lastSize"`
 
@@ -5248,9 +5281,9 @@ type __premarshalSecurityMarketDataQuote struct {
 
 	QuoteDate json.RawMessage `json:"quoteDate"`
 
-	Amount string `json:"amount"`
+	Amount money.Decimal `json:"amount"`
 
-	PreviousClose string `json:"previousClose"`
+	PreviousClose money.Decimal `json:"previousClose"`
 
 	Typename *string `json:"__typename"`
 }
@@ -5474,7 +5507,7 @@ type SimpleReturnsAmountMoney struct {
 func (v *SimpleReturnsAmountMoney) GetTypename() *string { return v.Typename }
 
 // GetAmount returns SimpleReturnsAmountMoney.Amount, and is useful for accessing the field via an interface.
-func (v *SimpleReturnsAmountMoney) GetAmount() string { return v.Money.Amount }
+func (v *SimpleReturnsAmountMoney) GetAmount() money.Decimal { return v.Money.Amount }
 
 // GetCents returns SimpleReturnsAmountMoney.Cents, and is useful for accessing the field via an interface.
 func (v *SimpleReturnsAmountMoney) GetCents() int { return v.Money.Cents }
@@ -5510,7 +5543,7 @@ func (v *SimpleReturnsAmountMoney) UnmarshalJSON(b []byte) error {
 type __premarshalSimpleReturnsAmountMoney struct {
 	Typename *string `json:"__typename"`
 
-	Amount string `json:"amount"`
+	Amount money.Decimal `json:"amount"`
 
 	Cents int `json:"cents"`
 
@@ -5807,6 +5840,11 @@ fragment AccountFinancials on Account {
 				... CustodianAccountCurrentFinancialValues
 				__typename
 			}
+			balance {
+				quantity
+				securityId
+				__typename
+			}
 			__typename
 		}
 		__typename
@@ -0,0 +1,98 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: client.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	gomock "github.com/golang/mock/gomock"
+	client "github.com/vpnda/wsfetch/pkg/client"
+	generated "github.com/vpnda/wsfetch/pkg/client/generated"
+)
+
+// MockClient is a mock of Client interface.
+type MockClient struct {
+	ctrl     *gomock.Controller
+	recorder *MockClientMockRecorder
+}
+
+// MockClientMockRecorder is the mock recorder for MockClient.
+type MockClientMockRecorder struct {
+	mock *MockClient
+}
+
+// NewMockClient creates a new mock instance.
+func NewMockClient(ctrl *gomock.Controller) *MockClient {
+	mock := &MockClient{ctrl: ctrl}
+	mock.recorder = &MockClientMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockClient) EXPECT() *MockClientMockRecorder {
+	return m.recorder
+}
+
+// GetAccount mocks base method.
+func (m *MockClient) GetAccount(ctx context.Context, accountId string) (*generated.AccountWithFinancials, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAccount", ctx, accountId)
+	ret0, _ := ret[0].(*generated.AccountWithFinancials)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAccount indicates an expected call of GetAccount.
+func (mr *MockClientMockRecorder) GetAccount(ctx, accountId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccount", reflect.TypeOf((*MockClient)(nil).GetAccount), ctx, accountId)
+}
+
+// GetAccounts mocks base method.
+func (m *MockClient) GetAccounts(ctx context.Context) ([]generated.AccountWithFinancials, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAccounts", ctx)
+	ret0, _ := ret[0].([]generated.AccountWithFinancials)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAccounts indicates an expected call of GetAccounts.
+func (mr *MockClientMockRecorder) GetAccounts(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccounts", reflect.TypeOf((*MockClient)(nil).GetAccounts), ctx)
+}
+
+// GetActivities mocks base method.
+func (m *MockClient) GetActivities(ctx context.Context, accountIds []client.AccountId, from, until *time.Time) (map[client.AccountId][]generated.Activity, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetActivities", ctx, accountIds, from, until)
+	ret0, _ := ret[0].(map[client.AccountId][]generated.Activity)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetActivities indicates an expected call of GetActivities.
+func (mr *MockClientMockRecorder) GetActivities(ctx, accountIds, from, until interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetActivities", reflect.TypeOf((*MockClient)(nil).GetActivities), ctx, accountIds, from, until)
+}
+
+// GetSecurityMarketData mocks base method.
+func (m *MockClient) GetSecurityMarketData(ctx context.Context, securityID string) (*generated.SecurityMarketData, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSecurityMarketData", ctx, securityID)
+	ret0, _ := ret[0].(*generated.SecurityMarketData)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSecurityMarketData indicates an expected call of GetSecurityMarketData.
+func (mr *MockClientMockRecorder) GetSecurityMarketData(ctx, securityID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSecurityMarketData", reflect.TypeOf((*MockClient)(nil).GetSecurityMarketData), ctx, securityID)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/vpnda/wsfetch/pkg/auth/types"
 	"github.com/vpnda/wsfetch/pkg/client/generated"
 )
 
@@ -16,9 +17,13 @@ func SecuritySymbolFromMarketData(marketData *generated.SecurityMarketData) (Sec
 }
 
 func (c *client) GetSecurityMarketData(ctx context.Context, securityID string) (*generated.SecurityMarketData, error) {
+	if err := c.requireScopes(ctx, "GetSecurityMarketData", types.ScopeTradeRead); err != nil {
+		return nil, err
+	}
+
 	marketData, err := generated.FetchSecurityMarketData(ctx, c.tradeClient, securityID)
 	if err != nil {
-		return nil, err
+		return nil, wrapError("GetSecurityMarketData", err)
 	}
 
 	return &marketData.Security.SecurityMarketData, nil
@@ -0,0 +1,112 @@
+package client
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/samber/lo"
+	"github.com/vpnda/wsfetch/pkg/client/generated"
+)
+
+// AmbiguousAccountError is returned when a nickname is shared by several
+// accounts, which are then told apart by their ID
+type AmbiguousAccountError struct {
+	Selector string
+	Matches  []string
+}
+
+func (e *AmbiguousAccountError) Error() string {
+	return fmt.Sprintf("account %q is ambiguous, it is the nickname of %s, select one by its ID",
+		e.Selector, strings.Join(e.Matches, ", "))
+}
+
+// IsClosed tells whether an account was closed
+func IsClosed(a *generated.AccountWithFinancials) bool {
+	return a.ClosedAt != nil || strings.EqualFold(a.Status, "closed")
+}
+
+// SelectAccounts returns the accounts matching any of the selectors, all
+// of them when there is none. A selector is an account ID, a nickname or
+// an account type such as SELF_DIRECTED_TFSA, ca_tfsa or tfsa, compared
+// ignoring case. An ID or a nickname selects a single account, a type
+// every account of that type. Closed accounts are left out unless
+// includeClosed is set.
+func SelectAccounts(accounts []generated.AccountWithFinancials, selectors []string, includeClosed bool) ([]generated.AccountWithFinancials, error) {
+	candidates := lo.Filter(accounts, func(a generated.AccountWithFinancials, _ int) bool {
+		return includeClosed || !IsClosed(&a)
+	})
+	if len(selectors) == 0 {
+		return candidates, nil
+	}
+
+	selected := map[string]bool{}
+	for _, selector := range selectors {
+		ids, err := matchAccounts(candidates, selector)
+		if err != nil {
+			return nil, err
+		}
+		if len(ids) == 0 {
+			if closed, _ := matchAccounts(accounts, selector); len(closed) != 0 {
+				return nil, fmt.Errorf("%w: %q is closed, use --include-closed to select it", ErrNoAccountFound, selector)
+			}
+			return nil, fmt.Errorf("%w: %q is not the ID, nickname or type of any of %s",
+				ErrNoAccountFound, selector, describeAccounts(candidates))
+		}
+		for _, id := range ids {
+			selected[id] = true
+		}
+	}
+	// accounts keep the order Wealthsimple lists them in
+	return lo.Filter(candidates, func(a generated.AccountWithFinancials, _ int) bool {
+		return selected[a.Id]
+	}), nil
+}
+
+// matchAccounts returns the IDs of the accounts a selector designates,
+// IDs taking precedence over nicknames and nicknames over types
+func matchAccounts(accounts []generated.AccountWithFinancials, selector string) ([]string, error) {
+	for _, a := range accounts {
+		if strings.EqualFold(a.Id, selector) {
+			return []string{a.Id}, nil
+		}
+	}
+
+	byNickname := lo.Filter(accounts, func(a generated.AccountWithFinancials, _ int) bool {
+		return strings.EqualFold(lo.FromPtr(a.Nickname), selector)
+	})
+	switch len(byNickname) {
+	case 0:
+	case 1:
+		return []string{byNickname[0].Id}, nil
+	default:
+		return nil, &AmbiguousAccountError{
+			Selector: selector,
+			Matches:  lo.Map(byNickname, func(a generated.AccountWithFinancials, _ int) string { return a.Id }),
+		}
+	}
+
+	var ids []string
+	for _, a := range accounts {
+		accountType := lo.FromPtr(a.Type)
+		for _, t := range []string{lo.FromPtr(a.UnifiedAccountType), accountType, strings.TrimPrefix(accountType, "ca_")} {
+			if t != "" && strings.EqualFold(t, selector) {
+				ids = append(ids, a.Id)
+				break
+			}
+		}
+	}
+	return ids, nil
+}
+
+func describeAccounts(accounts []generated.AccountWithFinancials) string {
+	if len(accounts) == 0 {
+		return "no accounts"
+	}
+	return strings.Join(lo.Map(accounts, func(a generated.AccountWithFinancials, _ int) string {
+		desc := []string{lo.FromPtr(a.Type)}
+		if a.Nickname != nil && *a.Nickname != "" {
+			desc = append(desc, fmt.Sprintf("%q", *a.Nickname))
+		}
+		return fmt.Sprintf("%s (%s)", a.Id, strings.Join(desc, " "))
+	}), ", ")
+}
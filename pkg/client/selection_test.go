@@ -0,0 +1,78 @@
+package client
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/samber/lo"
+	"github.com/vpnda/wsfetch/pkg/client/generated"
+	"github.com/vpnda/wsfetch/pkg/wstest"
+)
+
+// selectionAccounts returns the fixture TFSA, nicknamed Savings, and
+// RRSP along with a TFSA sharing the nickname of the RRSP and a closed
+// account
+func selectionAccounts() []generated.AccountWithFinancials {
+	tfsa := wstest.DefaultFixtures().Account("tfsa-abc123")
+	tfsa.Nickname = lo.ToPtr("Savings")
+	rrsp := wstest.DefaultFixtures().Account("rrsp-def456")
+
+	other := wstest.DefaultFixtures().Account("tfsa-abc123")
+	other.AccountCore.Id, other.AccountFinancials.Id = "tfsa-ghi789", "tfsa-ghi789"
+	other.Nickname = lo.ToPtr("Retirement")
+
+	old := wstest.DefaultFixtures().Account("rrsp-def456")
+	old.AccountCore.Id, old.AccountFinancials.Id = "non-registered-old", "non-registered-old"
+	old.Type = lo.ToPtr("ca_non_registered")
+	old.UnifiedAccountType = lo.ToPtr("SELF_DIRECTED_NON_REGISTERED")
+	old.Nickname = lo.ToPtr("Old")
+	old.Status = "closed"
+	old.ClosedAt = lo.ToPtr(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	return []generated.AccountWithFinancials{tfsa, rrsp, other, old}
+}
+
+func Test_SelectAccounts(t *testing.T) {
+	testCases := []struct {
+		name          string
+		selectors     []string
+		includeClosed bool
+		expected      []string
+	}{
+		{"no selector", nil, false, []string{"tfsa-abc123", "rrsp-def456", "tfsa-ghi789"}},
+		{"no selector with closed", nil, true, []string{"tfsa-abc123", "rrsp-def456", "tfsa-ghi789", "non-registered-old"}},
+		{"by id", []string{"RRSP-def456"}, false, []string{"rrsp-def456"}},
+		{"by nickname", []string{"savings"}, false, []string{"tfsa-abc123"}},
+		{"by short type", []string{"tfsa"}, false, []string{"tfsa-abc123", "tfsa-ghi789"}},
+		{"by unified type", []string{"self_directed_rrsp"}, false, []string{"rrsp-def456"}},
+		{"repeated", []string{"rrsp-def456", "Savings", "ca_rrsp"}, false, []string{"tfsa-abc123", "rrsp-def456"}},
+		{"closed", []string{"Old"}, true, []string{"non-registered-old"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			selected, err := SelectAccounts(selectionAccounts(), tc.selectors, tc.includeClosed)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(lo.Map(selected, func(a generated.AccountWithFinancials, _ int) string { return a.Id })).To(Equal(tc.expected))
+		})
+	}
+}
+
+func Test_SelectAccounts_Errors(t *testing.T) {
+	g := NewWithT(t)
+
+	_, err := SelectAccounts(selectionAccounts(), []string{"Retirement"}, false)
+	var ambiguous *AmbiguousAccountError
+	g.Expect(err).To(BeAssignableToTypeOf(ambiguous))
+	g.Expect(err.Error()).To(ContainSubstring("rrsp-def456, tfsa-ghi789"))
+
+	_, err = SelectAccounts(selectionAccounts(), []string{"Old"}, false)
+	g.Expect(err).To(MatchError(ErrNoAccountFound))
+	g.Expect(err.Error()).To(ContainSubstring("--include-closed"))
+
+	_, err = SelectAccounts(selectionAccounts(), []string{"resp"}, false)
+	g.Expect(err).To(MatchError(ErrNoAccountFound))
+	g.Expect(err.Error()).To(ContainSubstring(`tfsa-abc123 (ca_tfsa "Savings")`))
+}
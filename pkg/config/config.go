@@ -0,0 +1,470 @@
+// Package config reads and writes the wsfetch configuration file,
+// $XDG_CONFIG_HOME/wsfetch/config.yaml, and applies the WSFETCH_*
+// environment variables overriding it
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vpnda/wsfetch/pkg/output"
+	"github.com/vpnda/wsfetch/pkg/profile"
+	"golang.org/x/text/language"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// PathEnv overrides where the configuration file is read from
+	PathEnv = "WSFETCH_CONFIG"
+
+	// EnvPrefix starts the environment variables overriding keys, the
+	// variable of cache.dir being WSFETCH_CACHE_DIR
+	EnvPrefix = "WSFETCH_"
+)
+
+// ErrUnknownKey is returned for keys the configuration doesn't have
+var ErrUnknownKey = errors.New("unknown configuration key")
+
+// Config holds the settings of wsfetch, empty values leave the
+// built-in defaults in place
+type Config struct {
+	// SessionDir is where profiles and their sessions are stored
+	SessionDir string `yaml:"sessionDir,omitempty"`
+
+	// Output is the default output format
+	Output string `yaml:"output,omitempty"`
+
+	// Profile is the profile used without --profile
+	Profile string `yaml:"profile,omitempty"`
+
+	// Profiles are the profiles commands run across without
+	// --profile, --profiles or --all-profiles
+	Profiles []string `yaml:"profiles,omitempty"`
+
+	// Aliases name account selectors, an alias given to --account
+	// standing for the comma separated selectors it maps to. Alias
+	// names are lower case.
+	Aliases map[string]string `yaml:"aliases,omitempty"`
+
+	// Timezone dates are read and printed in, an IANA name such as
+	// America/Toronto
+	Timezone string `yaml:"timezone,omitempty"`
+
+	// Locale numbers are printed in by tables, such as fr-CA
+	Locale string `yaml:"locale,omitempty"`
+
+	// LedgerAccounts maps account IDs, nicknames and roles such as
+	// dividends to the accounts of plain-text accounting exports.
+	// Names are lower case.
+	LedgerAccounts map[string]string `yaml:"ledgerAccounts,omitempty"`
+
+	Cache Cache `yaml:"cache,omitempty"`
+}
+
+// Cache holds the settings of the client id cache
+type Cache struct {
+	// Dir is where cached data is stored, next to the profiles
+	// when empty
+	Dir string `yaml:"dir,omitempty"`
+
+	// ClientIdMaxAge is how long a discovered client id is reused,
+	// a duration such as 24h
+	ClientIdMaxAge string `yaml:"clientIdMaxAge,omitempty"`
+
+	// Disabled turns the cache off, the client id being
+	// discovered on every login
+	Disabled bool `yaml:"disabled,omitempty"`
+}
+
+// key is a setting addressable by config get and set, and by an
+// environment variable
+type key struct {
+	name string
+	help string
+	get  func(c *Config) string
+	set  func(c *Config, value string) error
+}
+
+var keys = []key{
+	{
+		name: "sessionDir",
+		help: "Directory profiles and sessions are stored in",
+		get:  func(c *Config) string { return c.SessionDir },
+		set:  func(c *Config, v string) error { c.SessionDir = v; return nil },
+	},
+	{
+		name: "output",
+		help: "Default output format",
+		get:  func(c *Config) string { return c.Output },
+		set: func(c *Config, v string) error {
+			if v != "" {
+				if _, err := output.ParseFormat(v); err != nil {
+					return err
+				}
+			}
+			c.Output = v
+			return nil
+		},
+	},
+	{
+		name: "profile",
+		help: "Profile used without --profile",
+		get:  func(c *Config) string { return c.Profile },
+		set: func(c *Config, v string) error {
+			if v != "" {
+				if err := profile.ValidateName(v); err != nil {
+					return err
+				}
+			}
+			c.Profile = v
+			return nil
+		},
+	},
+	{
+		name: "profiles",
+		help: "Comma separated profiles commands run across by default",
+		get:  func(c *Config) string { return strings.Join(c.Profiles, ",") },
+		set: func(c *Config, v string) error {
+			var names []string
+			for _, name := range strings.Split(v, ",") {
+				if name = strings.TrimSpace(name); name == "" {
+					continue
+				}
+				if err := profile.ValidateName(name); err != nil {
+					return err
+				}
+				names = append(names, name)
+			}
+			c.Profiles = names
+			return nil
+		},
+	},
+	{
+		name: "timezone",
+		help: "Timezone dates are read and printed in, eg America/Toronto",
+		get:  func(c *Config) string { return c.Timezone },
+		set: func(c *Config, v string) error {
+			if v != "" {
+				if _, err := time.LoadLocation(v); err != nil {
+					return fmt.Errorf("invalid timezone %q: %w", v, err)
+				}
+			}
+			c.Timezone = v
+			return nil
+		},
+	},
+	{
+		name: "locale",
+		help: "Locale numbers are printed in by tables, eg fr-CA",
+		get:  func(c *Config) string { return c.Locale },
+		set: func(c *Config, v string) error {
+			if v != "" {
+				if _, err := language.Parse(v); err != nil {
+					return fmt.Errorf("invalid locale %q: %w", v, err)
+				}
+			}
+			c.Locale = v
+			return nil
+		},
+	},
+	{
+		name: "cache.dir",
+		help: "Directory cached data is stored in",
+		get:  func(c *Config) string { return c.Cache.Dir },
+		set:  func(c *Config, v string) error { c.Cache.Dir = v; return nil },
+	},
+	{
+		name: "cache.clientIdMaxAge",
+		help: "How long a discovered client id is reused, eg 24h",
+		get:  func(c *Config) string { return c.Cache.ClientIdMaxAge },
+		set: func(c *Config, v string) error {
+			if v != "" {
+				if d, err := time.ParseDuration(v); err != nil || d < 0 {
+					return fmt.Errorf("invalid duration %q", v)
+				}
+			}
+			c.Cache.ClientIdMaxAge = v
+			return nil
+		},
+	},
+	{
+		name: "cache.disabled",
+		help: "Turn the cache off",
+		get: func(c *Config) string {
+			if !c.Cache.Disabled {
+				return ""
+			}
+			return "true"
+		},
+		set: func(c *Config, v string) error {
+			if v == "" {
+				c.Cache.Disabled = false
+				return nil
+			}
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				return fmt.Errorf("invalid boolean %q", v)
+			}
+			c.Cache.Disabled = b
+			return nil
+		},
+	},
+}
+
+// mapKey is a map of the configuration, its entries being set with
+// PREFIX.NAME keys, names are lower case
+type mapKey struct {
+	prefix string
+	help   string
+	values func(c *Config) *map[string]string
+}
+
+var mapKeys = []mapKey{
+	{
+		prefix: "aliases.",
+		help:   "Account selectors an --account alias stands for",
+		values: func(c *Config) *map[string]string { return &c.Aliases },
+	},
+	{
+		prefix: "ledgerAccounts.",
+		help:   "Account of plain-text accounting exports an account ID, nickname or role maps to",
+		values: func(c *Config) *map[string]string { return &c.LedgerAccounts },
+	},
+}
+
+// Key describes a configuration key
+type Key struct {
+	Name string
+	Env  string
+	Help string
+}
+
+// Keys lists the configuration keys, the entries of maps such as
+// account aliases being set with aliases.NAME keys
+func Keys() []Key {
+	list := make([]Key, len(keys))
+	for i, k := range keys {
+		list[i] = Key{Name: k.name, Env: EnvName(k.name), Help: k.help}
+	}
+	for _, m := range mapKeys {
+		list = append(list, Key{Name: m.prefix + "NAME", Env: EnvName(m.prefix) + "NAME", Help: m.help})
+	}
+	return list
+}
+
+// EnvName returns the environment variable overriding a key
+func EnvName(name string) string {
+	return EnvPrefix + strings.ToUpper(strings.ReplaceAll(name, ".", "_"))
+}
+
+func findKey(name string) (key, bool) {
+	for _, k := range keys {
+		if strings.EqualFold(k.name, name) {
+			return k, true
+		}
+	}
+	return key{}, false
+}
+
+// Get returns the value of a key, empty when it isn't set
+func (c *Config) Get(name string) (string, error) {
+	if m, entry, ok := cutMapKey(name); ok {
+		return (*m.values(c))[strings.ToLower(entry)], nil
+	}
+	k, ok := findKey(name)
+	if !ok {
+		return "", fmt.Errorf("%w %q", ErrUnknownKey, name)
+	}
+	return k.get(c), nil
+}
+
+// Set validates and sets the value of a key, an empty value unsets it
+func (c *Config) Set(name, value string) error {
+	if m, entry, ok := cutMapKey(name); ok {
+		entry = strings.ToLower(entry)
+		values := m.values(c)
+		if value == "" {
+			delete(*values, entry)
+			return nil
+		}
+		if *values == nil {
+			*values = map[string]string{}
+		}
+		(*values)[entry] = value
+		return nil
+	}
+	k, ok := findKey(name)
+	if !ok {
+		return fmt.Errorf("%w %q", ErrUnknownKey, name)
+	}
+	if err := k.set(c, value); err != nil {
+		return fmt.Errorf("invalid %s: %w", k.name, err)
+	}
+	return nil
+}
+
+func cutMapKey(name string) (mapKey, string, bool) {
+	for _, m := range mapKeys {
+		if len(name) > len(m.prefix) && strings.EqualFold(name[:len(m.prefix)], m.prefix) {
+			return m, name[len(m.prefix):], true
+		}
+	}
+	return mapKey{}, "", false
+}
+
+// Values returns every key that is set along with its value, sorted
+func (c *Config) Values() [][2]string {
+	var values [][2]string
+	for _, k := range keys {
+		if v := k.get(c); v != "" {
+			values = append(values, [2]string{k.name, v})
+		}
+	}
+	for _, m := range mapKeys {
+		entries := *m.values(c)
+		names := make([]string, 0, len(entries))
+		for name := range entries {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			values = append(values, [2]string{m.prefix + name, entries[name]})
+		}
+	}
+	return values
+}
+
+// ApplyEnv overrides keys with the WSFETCH_* variables of environ, given
+// in the KEY=VALUE form of os.Environ. The entries of maps are read from
+// variables such as WSFETCH_ALIASES_NAME.
+func (c *Config) ApplyEnv(environ []string) error {
+	env := map[string]string{}
+	for _, kv := range environ {
+		if name, value, ok := strings.Cut(kv, "="); ok && strings.HasPrefix(name, EnvPrefix) {
+			env[name] = value
+		}
+	}
+
+	for _, k := range keys {
+		value, ok := env[EnvName(k.name)]
+		if !ok {
+			continue
+		}
+		if err := k.set(c, value); err != nil {
+			return fmt.Errorf("invalid %s: %w", EnvName(k.name), err)
+		}
+	}
+
+	for _, m := range mapKeys {
+		for name, value := range env {
+			if entry, ok := strings.CutPrefix(name, EnvName(m.prefix)); ok && entry != "" {
+				if err := c.Set(m.prefix+entry, value); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// DefaultPath returns where the configuration file is, WSFETCH_CONFIG
+// when it is set
+func DefaultPath() (string, error) {
+	if path := os.Getenv(PathEnv); path != "" {
+		return path, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("unable to find user config dir: %w", err)
+	}
+	return filepath.Join(dir, "wsfetch", "config.yaml"), nil
+}
+
+// Load reads the configuration file at path, a missing file is an
+// empty configuration
+func Load(path string) (*Config, error) {
+	c := &Config{}
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := yaml.Unmarshal(b, c); err != nil {
+		return nil, fmt.Errorf("invalid configuration file %s: %w", path, err)
+	}
+	for _, m := range mapKeys {
+		entries := *m.values(c)
+		for name, value := range entries {
+			if lower := strings.ToLower(name); lower != name {
+				delete(entries, name)
+				entries[lower] = value
+			}
+		}
+	}
+	return c, nil
+}
+
+// Save writes the configuration file at path, readable by its owner only
+func (c *Config) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(c); err != nil {
+		return err
+	}
+	if err := enc.Close(); err != nil {
+		return err
+	}
+	return os.WriteFile(path, buf.Bytes(), 0o600)
+}
+
+// Location returns the timezone dates are handled in, the local one
+// when none is set
+func (c *Config) Location() (*time.Location, error) {
+	if c.Timezone == "" {
+		return time.Local, nil
+	}
+	loc, err := time.LoadLocation(c.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timezone %q: %w", c.Timezone, err)
+	}
+	return loc, nil
+}
+
+// Language returns the locale tables print numbers in, und when none
+// is set
+func (c *Config) Language() (language.Tag, error) {
+	if c.Locale == "" {
+		return language.Und, nil
+	}
+	tag, err := language.Parse(c.Locale)
+	if err != nil {
+		return language.Und, fmt.Errorf("invalid locale %q: %w", c.Locale, err)
+	}
+	return tag, nil
+}
+
+// ClientIdMaxAge returns how long a discovered client id is reused,
+// def when it isn't set
+func (c *Config) ClientIdMaxAge(def time.Duration) (time.Duration, error) {
+	if c.Cache.ClientIdMaxAge == "" {
+		return def, nil
+	}
+	d, err := time.ParseDuration(c.Cache.ClientIdMaxAge)
+	if err != nil {
+		return 0, fmt.Errorf("invalid cache.clientIdMaxAge %q: %w", c.Cache.ClientIdMaxAge, err)
+	}
+	return d, nil
+}
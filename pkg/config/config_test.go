@@ -0,0 +1,138 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+func Test_LoadAndSave(t *testing.T) {
+	g := NewWithT(t)
+	path := filepath.Join(t.TempDir(), "wsfetch", "config.yaml")
+
+	// a missing file is an empty configuration
+	c, err := Load(path)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(c).To(Equal(&Config{}))
+
+	g.Expect(c.Set("output", "json")).To(Succeed())
+	g.Expect(c.Set("profiles", "alice, bob")).To(Succeed())
+	g.Expect(c.Set("aliases.Fun", "tfsa,Retirement")).To(Succeed())
+	g.Expect(c.Set("ledgerAccounts.Retirement", "Assets:Retirement")).To(Succeed())
+	g.Expect(c.Set("cache.clientIdMaxAge", "24h")).To(Succeed())
+	g.Expect(c.Save(path)).To(Succeed())
+
+	info, err := os.Stat(path)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(info.Mode().Perm()).To(Equal(os.FileMode(0o600)))
+
+	loaded, err := Load(path)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(loaded).To(Equal(&Config{
+		Output:         "json",
+		Profiles:       []string{"alice", "bob"},
+		Aliases:        map[string]string{"fun": "tfsa,Retirement"},
+		LedgerAccounts: map[string]string{"retirement": "Assets:Retirement"},
+		Cache:          Cache{ClientIdMaxAge: "24h"},
+	}))
+	g.Expect(loaded.Values()).To(Equal([][2]string{
+		{"output", "json"},
+		{"profiles", "alice,bob"},
+		{"cache.clientIdMaxAge", "24h"},
+		{"aliases.fun", "tfsa,Retirement"},
+		{"ledgerAccounts.retirement", "Assets:Retirement"},
+	}))
+}
+
+func Test_Load_Invalid(t *testing.T) {
+	g := NewWithT(t)
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	g.Expect(os.WriteFile(path, []byte("output: [json"), 0o600)).To(Succeed())
+
+	_, err := Load(path)
+	g.Expect(err).To(MatchError(ContainSubstring(path)))
+}
+
+func Test_GetAndSet(t *testing.T) {
+	testCases := []struct {
+		key      string
+		value    string
+		expected string
+		invalid  bool
+	}{
+		{key: "sessionDir", value: "/srv/wsfetch", expected: "/srv/wsfetch"},
+		{key: "output", value: "csv", expected: "csv"},
+		{key: "output", value: "xml", invalid: true},
+		{key: "profile", value: "alice", expected: "alice"},
+		{key: "profile", value: "../alice", invalid: true},
+		{key: "timezone", value: "UTC", expected: "UTC"},
+		{key: "timezone", value: "Mars/Olympus", invalid: true},
+		{key: "locale", value: "fr-CA", expected: "fr-CA"},
+		{key: "locale", value: "not a locale", invalid: true},
+		{key: "cache.dir", value: "/tmp/cache", expected: "/tmp/cache"},
+		{key: "cache.clientIdMaxAge", value: "soon", invalid: true},
+		{key: "cache.disabled", value: "true", expected: "true"},
+		{key: "cache.disabled", value: "maybe", invalid: true},
+		{key: "CACHE.DIR", value: "/tmp/cache", expected: "/tmp/cache"},
+		{key: "colour", value: "blue", invalid: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.key+"="+tc.value, func(t *testing.T) {
+			g := NewWithT(t)
+			c := &Config{}
+			err := c.Set(tc.key, tc.value)
+			if tc.invalid {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(c.Get(tc.key)).To(Equal(tc.expected))
+
+			// an empty value unsets the key
+			g.Expect(c.Set(tc.key, "")).To(Succeed())
+			g.Expect(c.Get(tc.key)).To(BeEmpty())
+		})
+	}
+}
+
+func Test_ApplyEnv(t *testing.T) {
+	g := NewWithT(t)
+	c := &Config{Output: "json", Timezone: "UTC"}
+
+	g.Expect(c.ApplyEnv([]string{
+		"WSFETCH_OUTPUT=yaml",
+		"WSFETCH_CACHE_DISABLED=1",
+		"WSFETCH_ALIASES_SAVINGS=tfsa-abc123",
+		"WSFETCH_LEDGERACCOUNTS_DIVIDENDS=Income:Dividends",
+		"WSFETCH_CLIENT_ID=someClientId",
+		"HOME=/root",
+	})).To(Succeed())
+	g.Expect(c.Output).To(Equal("yaml"))
+	g.Expect(c.Timezone).To(Equal("UTC"))
+	g.Expect(c.Cache.Disabled).To(BeTrue())
+	g.Expect(c.Aliases).To(Equal(map[string]string{"savings": "tfsa-abc123"}))
+	g.Expect(c.LedgerAccounts).To(Equal(map[string]string{"dividends": "Income:Dividends"}))
+
+	err := c.ApplyEnv([]string{"WSFETCH_TIMEZONE=Mars/Olympus"})
+	g.Expect(err).To(MatchError(ContainSubstring("WSFETCH_TIMEZONE")))
+}
+
+func Test_TypedValues(t *testing.T) {
+	g := NewWithT(t)
+	c := &Config{Timezone: "America/Toronto", Locale: "fr-CA", Cache: Cache{ClientIdMaxAge: "1h"}}
+
+	loc, err := c.Location()
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(loc.String()).To(Equal("America/Toronto"))
+
+	tag, err := c.Language()
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(tag.String()).To(Equal("fr-CA"))
+
+	g.Expect(c.ClientIdMaxAge(time.Minute)).To(Equal(time.Hour))
+	g.Expect((&Config{}).ClientIdMaxAge(time.Minute)).To(Equal(time.Minute))
+}
@@ -0,0 +1,100 @@
+// Package daterange reads the date expressions accepted by --from and
+// --to, such as 2025-03-15, 2025, -90d, ytd or last-month
+package daterange
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Range is the span of time an expression denotes, both ends included
+type Range struct {
+	Start time.Time
+	End   time.Time
+}
+
+// Expressions lists the forms Parse accepts, for help texts and errors
+const Expressions = "YYYY-MM-DD, YYYY-MM, YYYY, an RFC 3339 time, -Nd, -Nw, -Nm, -Ny, " +
+	"today, yesterday, mtd, ytd, this-month, this-year, last-month or last-year"
+
+var relative = regexp.MustCompile(`^-?(\d+)([dwmy])$`)
+
+// Parse reads an expression relative to now, days start at midnight in
+// the location of now. A date denotes its whole day, a month or a year
+// all of its days, and a relative expression such as -90d the day that
+// many days, weeks, months or years ago. ytd and mtd run from the start
+// of the year or month up to now.
+func Parse(expr string, now time.Time) (Range, error) {
+	expr = strings.ToLower(strings.TrimSpace(expr))
+	today := startOfDay(now)
+	year, month, _ := today.Date()
+	loc := now.Location()
+
+	switch expr {
+	case "today":
+		return days(today, 1), nil
+	case "yesterday":
+		return days(today.AddDate(0, 0, -1), 1), nil
+	case "mtd":
+		return Range{Start: time.Date(year, month, 1, 0, 0, 0, 0, loc), End: now}, nil
+	case "ytd":
+		return Range{Start: time.Date(year, 1, 1, 0, 0, 0, 0, loc), End: now}, nil
+	case "this-month":
+		return months(time.Date(year, month, 1, 0, 0, 0, 0, loc), 1), nil
+	case "this-year":
+		return months(time.Date(year, 1, 1, 0, 0, 0, 0, loc), 12), nil
+	case "last-month":
+		return months(time.Date(year, month-1, 1, 0, 0, 0, 0, loc), 1), nil
+	case "last-year":
+		return months(time.Date(year-1, 1, 1, 0, 0, 0, 0, loc), 12), nil
+	}
+
+	if m := relative.FindStringSubmatch(expr); m != nil {
+		n, err := strconv.Atoi(m[1])
+		if err != nil {
+			return Range{}, fmt.Errorf("invalid date %q: %w", expr, err)
+		}
+		var day time.Time
+		switch m[2] {
+		case "d":
+			day = today.AddDate(0, 0, -n)
+		case "w":
+			day = today.AddDate(0, 0, -7*n)
+		case "m":
+			day = today.AddDate(0, -n, 0)
+		case "y":
+			day = today.AddDate(-n, 0, 0)
+		}
+		return days(day, 1), nil
+	}
+
+	if t, err := time.ParseInLocation(time.DateOnly, expr, loc); err == nil {
+		return days(t, 1), nil
+	}
+	if t, err := time.ParseInLocation("2006-01", expr, loc); err == nil {
+		return months(t, 1), nil
+	}
+	if t, err := time.ParseInLocation("2006", expr, loc); err == nil {
+		return months(t, 12), nil
+	}
+	if t, err := time.Parse(time.RFC3339, strings.ToUpper(expr)); err == nil {
+		return Range{Start: t, End: t}, nil
+	}
+	return Range{}, fmt.Errorf("invalid date %q, expected %s", expr, Expressions)
+}
+
+func startOfDay(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+}
+
+func days(start time.Time, n int) Range {
+	return Range{Start: start, End: start.AddDate(0, 0, n).Add(-time.Nanosecond)}
+}
+
+func months(start time.Time, n int) Range {
+	return Range{Start: start, End: start.AddDate(0, n, 0).Add(-time.Nanosecond)}
+}
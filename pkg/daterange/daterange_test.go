@@ -0,0 +1,69 @@
+package daterange
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+func Test_Parse(t *testing.T) {
+	toronto, err := time.LoadLocation("America/Toronto")
+	if err != nil {
+		toronto = time.FixedZone("EST", -5*3600)
+	}
+	now := time.Date(2025, 3, 15, 14, 30, 0, 0, toronto)
+	day := func(year int, month time.Month, d int) time.Time {
+		return time.Date(year, month, d, 0, 0, 0, 0, toronto)
+	}
+	endOf := func(t time.Time) time.Time { return t.Add(-time.Nanosecond) }
+
+	testCases := []struct {
+		expr          string
+		expectedStart time.Time
+		expectedEnd   time.Time
+	}{
+		{"2025-01-15", day(2025, 1, 15), endOf(day(2025, 1, 16))},
+		{"2024-02", day(2024, 2, 1), endOf(day(2024, 3, 1))},
+		{"2024", day(2024, 1, 1), endOf(day(2025, 1, 1))},
+		{"-90d", day(2024, 12, 15), endOf(day(2024, 12, 16))},
+		{"2w", day(2025, 3, 1), endOf(day(2025, 3, 2))},
+		{"-1m", day(2025, 2, 15), endOf(day(2025, 2, 16))},
+		{"-1y", day(2024, 3, 15), endOf(day(2024, 3, 16))},
+		{"today", day(2025, 3, 15), endOf(day(2025, 3, 16))},
+		{"Yesterday", day(2025, 3, 14), endOf(day(2025, 3, 15))},
+		{"ytd", day(2025, 1, 1), now},
+		{"mtd", day(2025, 3, 1), now},
+		{"this-year", day(2025, 1, 1), endOf(day(2026, 1, 1))},
+		{"last-month", day(2025, 2, 1), endOf(day(2025, 3, 1))},
+		{"last-year", day(2024, 1, 1), endOf(day(2025, 1, 1))},
+		{"2025-03-01T12:00:00Z", time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC), time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.expr, func(t *testing.T) {
+			g := NewWithT(t)
+			r, err := Parse(tc.expr, now)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(r.Start.Equal(tc.expectedStart)).To(BeTrue(), "start %s", r.Start)
+			g.Expect(r.End.Equal(tc.expectedEnd)).To(BeTrue(), "end %s", r.End)
+		})
+	}
+}
+
+func Test_Parse_LastMonthInJanuary(t *testing.T) {
+	g := NewWithT(t)
+
+	r, err := Parse("last-month", time.Date(2025, 1, 10, 0, 0, 0, 0, time.UTC))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(r.Start).To(Equal(time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC)))
+}
+
+func Test_Parse_Invalid(t *testing.T) {
+	g := NewWithT(t)
+
+	for _, expr := range []string{"", "soon", "-90x", "2025-13", "2025-02-30"} {
+		_, err := Parse(expr, time.Now())
+		g.Expect(err).To(HaveOccurred(), expr)
+	}
+}
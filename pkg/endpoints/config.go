@@ -0,0 +1,71 @@
+package endpoints
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Config locates the Wealthsimple endpoints. Routes name the host they
+// belong to, the config maps that host to the scheme, host and path
+// prefix requests are actually sent to. That way the whole stack can
+// run against a local stand-in server.
+type Config struct {
+	// MyWealthsimple is the base URL of the web app, serving the
+	// login page, web sessions and GraphQL
+	MyWealthsimple url.URL
+
+	// Api is the base URL of the OAuth API
+	Api url.URL
+}
+
+// Default is the production configuration
+var Default = Config{
+	MyWealthsimple: url.URL{Scheme: "https", Host: MyWeathSimple},
+	Api:            url.URL{Scheme: "https", Host: Api},
+}
+
+// ForBase returns a config sending every route to base, its path
+// prefixes the path of every route
+func ForBase(base *url.URL) Config {
+	return Config{MyWealthsimple: *base, Api: *base}
+}
+
+// ParseBase parses the base URL given to ForBase, it must be absolute
+func ParseBase(raw string) (Config, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return Config{}, err
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return Config{}, fmt.Errorf("base URL %q must include a scheme and a host", raw)
+	}
+	return ForBase(u), nil
+}
+
+// Base returns the base URL of the host r belongs to
+func (c Config) Base(r Route) url.URL {
+	if r.Host == Api {
+		return c.Api
+	}
+	return c.MyWealthsimple
+}
+
+// URL returns where requests for r are sent
+func (c Config) URL(r Route) *url.URL {
+	u := c.Base(r)
+	u.Path = strings.TrimSuffix(u.Path, "/") + r.Path
+	u.RawPath = ""
+	return &u
+}
+
+// Is reports whether u targets r
+func (c Config) Is(u *url.URL, r Route) bool {
+	target := c.URL(r)
+	return u.Host == target.Host && u.Path == target.Path
+}
+
+// IsMyWealthsimple reports whether u targets the web app host
+func (c Config) IsMyWealthsimple(u *url.URL) bool {
+	return u.Host == c.MyWealthsimple.Host
+}
@@ -8,8 +8,10 @@ type Route struct {
 	Path   string
 }
 
+// String returns the production URL of the route, see Config.URL
+// to honour an alternate configuration
 func (r Route) String() string {
-	return "https://" + r.Host + r.Path
+	return Default.URL(r).String()
 }
 
 var (
@@ -20,4 +22,5 @@ var (
 
 	AuthToken     = Route{http.MethodPost, Api, "/v1/oauth/v2/token"}
 	AuthTokenInfo = Route{http.MethodGet, Api, "/v1/oauth/v2/token/info"}
+	AuthRevoke    = Route{http.MethodPost, Api, "/v1/oauth/v2/revoke"}
 )
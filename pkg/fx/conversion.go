@@ -0,0 +1,93 @@
+package fx
+
+import (
+	"time"
+
+	"github.com/samber/lo"
+	"github.com/vpnda/wsfetch/pkg/client/generated"
+	"github.com/vpnda/wsfetch/pkg/money"
+)
+
+// Conversion is an exchange of currencies made by an activity, either a
+// funds conversion or a trade settled in another currency
+type Conversion struct {
+	ActivityId string
+	At         time.Time
+
+	// Sold and Bought are the positive amounts exchanged
+	Sold   money.Money
+	Bought money.Money
+
+	// Rate is the effective rate, units of Bought per unit of Sold
+	Rate money.Decimal
+
+	// QuotedRate is the rate Wealthsimple states for the activity, in
+	// the same direction as Rate, nil when it doesn't state one
+	QuotedRate *money.Decimal
+}
+
+// ConversionFromActivity reads the conversion an activity made. The
+// activity amount is in its currency, the counter party amount in the
+// counter party currency, the amount sign telling which one was bought.
+func ConversionFromActivity(act *generated.Activity) (Conversion, bool) {
+	currency := lo.FromPtr(act.Currency)
+	counterCurrency := lo.FromPtr(act.CounterPartyCurrency)
+	counterAmount := lo.FromPtr(act.CounterPartyCurrencyAmount).Abs()
+	amount := act.Amount.Abs()
+	if currency == "" || counterCurrency == "" || currency == counterCurrency ||
+		amount.IsZero() || counterAmount.IsZero() {
+		return Conversion{}, false
+	}
+
+	c := Conversion{
+		ActivityId: lo.FromPtr(act.CanonicalId),
+		At:         lo.FromPtr(act.OccurredAt),
+		Sold:       money.NewMoney(counterAmount, counterCurrency),
+		Bought:     money.NewMoney(amount, currency),
+	}
+	if act.AmountSign == generated.AmountSignNegative {
+		c.Sold, c.Bought = c.Bought, c.Sold
+	}
+	c.Rate, _ = c.Bought.Amount.Div(c.Sold.Amount, ratePlaces, money.HalfEven)
+	if act.FxRate != nil && act.FxRate.Sign() > 0 {
+		c.QuotedRate = lo.ToPtr(orient(*act.FxRate, c.Rate))
+	}
+	return c, true
+}
+
+// orient returns the quoted rate or its inverse, whichever is in the
+// direction of the effective rate. Wealthsimple doesn't tell in which
+// direction it quotes, but a spread never gets close to inverting it.
+func orient(quoted, effective money.Decimal) money.Decimal {
+	inverse, err := money.NewFromInt(1).Div(quoted, ratePlaces, money.HalfEven)
+	if err != nil {
+		return quoted
+	}
+	if quoted.Sub(effective).Abs().Cmp(inverse.Sub(effective).Abs()) <= 0 {
+		return quoted
+	}
+	return inverse
+}
+
+// Spread is what a conversion cost compared to a reference rate
+type Spread struct {
+	// Relative is the share of the converted value lost, 0.015 for 1.5%
+	Relative money.Decimal
+
+	// Cost is the amount of the bought currency lost
+	Cost money.Money
+}
+
+// Spread compares the conversion to a reference rate, typically the
+// mid-market rate, of one unit of the sold currency in the bought one
+func (c Conversion) Spread(reference money.Decimal) (Spread, error) {
+	relative, err := reference.Sub(c.Rate).Div(reference, 6, money.HalfEven)
+	if err != nil {
+		return Spread{}, err
+	}
+	fair := c.Sold.Amount.Mul(reference)
+	return Spread{
+		Relative: relative,
+		Cost:     money.NewMoney(fair.Sub(c.Bought.Amount), c.Bought.Currency).Round(2, money.HalfEven),
+	}, nil
+}
@@ -0,0 +1,139 @@
+// Package fx converts amounts between currencies using the exchange rates
+// seen in the activities of the accounts, so USD holdings and activities
+// can be reported in a single currency without an external rate feed.
+package fx
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vpnda/wsfetch/pkg/client/generated"
+	"github.com/vpnda/wsfetch/pkg/money"
+)
+
+// DefaultReportingCurrency is the currency amounts are reported in
+// unless another one is chosen
+const DefaultReportingCurrency = "CAD"
+
+// ratePlaces is the precision of derived rates, such as inverses
+const ratePlaces = 10
+
+// ErrNoRate is returned when no rate is known between two currencies
+var ErrNoRate = errors.New("no exchange rate known")
+
+// Rate is the value of one unit of From in To at a point in time
+type Rate struct {
+	From  string
+	To    string
+	Value money.Decimal
+	At    time.Time
+}
+
+// ParseRate reads a rate such as "USD/CAD=1.3650", dated at
+func ParseRate(s string, at time.Time) (Rate, error) {
+	pair, value, ok := strings.Cut(s, "=")
+	from, to, ok2 := strings.Cut(pair, "/")
+	if !ok || !ok2 || from == "" || to == "" {
+		return Rate{}, fmt.Errorf("invalid rate %q, expected FROM/TO=VALUE", s)
+	}
+	d, err := money.Parse(value)
+	if err != nil {
+		return Rate{}, err
+	}
+	if d.Sign() <= 0 {
+		return Rate{}, fmt.Errorf("invalid rate %q, the value must be positive", s)
+	}
+	return Rate{From: strings.ToUpper(from), To: strings.ToUpper(to), Value: d, At: at}, nil
+}
+
+type pair struct{ from, to string }
+
+// Book records exchange rates and converts amounts with them, it is
+// safe for concurrent use
+type Book struct {
+	mu    sync.Mutex
+	rates map[pair][]Rate
+}
+
+// NewBook creates a book holding the given rates
+func NewBook(rates ...Rate) *Book {
+	b := &Book{rates: map[pair][]Rate{}}
+	for _, r := range rates {
+		b.Add(r)
+	}
+	return b
+}
+
+// Add records a rate, rates of a pair are kept sorted by time
+func (b *Book) Add(r Rate) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	p := pair{r.From, r.To}
+	rates := append(b.rates[p], r)
+	sort.SliceStable(rates, func(i, j int) bool { return rates[i].At.Before(rates[j].At) })
+	b.rates[p] = rates
+}
+
+// Record records the rate of the conversion the activity made, if any
+func (b *Book) Record(act *generated.Activity) (Conversion, bool) {
+	c, ok := ConversionFromActivity(act)
+	if ok {
+		b.Add(Rate{From: c.Sold.Currency, To: c.Bought.Currency, Value: c.Rate, At: c.At})
+	}
+	return c, ok
+}
+
+// Rate returns the value of one unit of from in to at the given time.
+// The latest rate seen at or before that time is used, the earliest
+// one seen after it otherwise. Rates of the reverse pair are inverted
+// when they are closer in time.
+func (b *Book) Rate(from, to string, at time.Time) (money.Decimal, error) {
+	if from == to {
+		return money.NewFromInt(1), nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	direct, okDirect := closest(b.rates[pair{from, to}], at)
+	inverse, okInverse := closest(b.rates[pair{to, from}], at)
+	switch {
+	case okDirect && (!okInverse || distance(direct, at) <= distance(inverse, at)):
+		return direct.Value, nil
+	case okInverse:
+		return money.NewFromInt(1).Div(inverse.Value, ratePlaces, money.HalfEven)
+	}
+	return money.Zero, fmt.Errorf("%w from %s to %s", ErrNoRate, from, to)
+}
+
+// Convert converts m to the currency to, at the rate of the given time,
+// the result is rounded to the cent
+func (b *Book) Convert(m money.Money, to string, at time.Time) (money.Money, error) {
+	rate, err := b.Rate(m.Currency, to, at)
+	if err != nil {
+		return money.Money{}, err
+	}
+	return money.NewMoney(m.Amount.Mul(rate), to).Round(2, money.HalfEven), nil
+}
+
+func closest(rates []Rate, at time.Time) (Rate, bool) {
+	if len(rates) == 0 {
+		return Rate{}, false
+	}
+	i := sort.Search(len(rates), func(i int) bool { return rates[i].At.After(at) })
+	if i == 0 {
+		return rates[0], true
+	}
+	return rates[i-1], true
+}
+
+func distance(r Rate, at time.Time) time.Duration {
+	d := at.Sub(r.At)
+	if d < 0 {
+		return -d
+	}
+	return d
+}
@@ -0,0 +1,151 @@
+package fx
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/samber/lo"
+	"github.com/vpnda/wsfetch/pkg/client/generated"
+	"github.com/vpnda/wsfetch/pkg/money"
+)
+
+var (
+	may1 = time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	may5 = time.Date(2024, 5, 5, 0, 0, 0, 0, time.UTC)
+)
+
+func Test_Book_Rate(t *testing.T) {
+	book := NewBook(
+		Rate{From: "USD", To: "CAD", Value: money.MustParse("1.36"), At: may1},
+		Rate{From: "CAD", To: "USD", Value: money.MustParse("0.8"), At: may5},
+	)
+
+	testCases := []struct {
+		name     string
+		from, to string
+		at       time.Time
+		expected string
+	}{
+		{"same currency", "USD", "USD", may1, "1"},
+		{"direct rate", "USD", "CAD", may1, "1.36"},
+		{"earliest rate before any", "USD", "CAD", may1.Add(-48 * time.Hour), "1.36"},
+		{"inverse rate closer in time", "USD", "CAD", may5, "1.25"},
+		{"inverse of the direct rate", "CAD", "USD", may1, "0.7352941176"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			rate, err := book.Rate(tc.from, tc.to, tc.at)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(rate.Trim().String()).To(Equal(tc.expected))
+		})
+	}
+}
+
+func Test_Book_Convert(t *testing.T) {
+	g := NewWithT(t)
+	book := NewBook(Rate{From: "USD", To: "CAD", Value: money.MustParse("1.3655"), At: may1})
+
+	converted, err := book.Convert(money.NewMoney(money.MustParse("100.01"), "USD"), "CAD", may1)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(converted.String()).To(Equal("136.56 CAD"))
+
+	_, err = book.Convert(money.NewMoney(money.MustParse("1"), "EUR"), "CAD", may1)
+	g.Expect(err).To(MatchError(ErrNoRate))
+}
+
+func Test_ConversionFromActivity(t *testing.T) {
+	testCases := []struct {
+		name           string
+		act            generated.Activity
+		expectedSold   string
+		expectedBought string
+		expectedRate   string
+		expectedQuoted string
+	}{
+		{
+			name: "bought USD, rate quoted in CAD per USD",
+			act: generated.Activity{
+				Currency: lo.ToPtr("USD"), Amount: money.MustParse("100.00"), AmountSign: generated.AmountSignPositive,
+				CounterPartyCurrency: lo.ToPtr("CAD"), CounterPartyCurrencyAmount: lo.ToPtr(money.MustParse("138.00")),
+				FxRate: lo.ToPtr(money.MustParse("1.36")),
+			},
+			expectedSold:   "138.00 CAD",
+			expectedBought: "100.00 USD",
+			expectedRate:   "0.7246376812",
+			expectedQuoted: "0.7352941176",
+		},
+		{
+			name: "sold USD",
+			act: generated.Activity{
+				Currency: lo.ToPtr("USD"), Amount: money.MustParse("-100.00"), AmountSign: generated.AmountSignNegative,
+				CounterPartyCurrency: lo.ToPtr("CAD"), CounterPartyCurrencyAmount: lo.ToPtr(money.MustParse("134.00")),
+				FxRate: lo.ToPtr(money.MustParse("1.36")),
+			},
+			expectedSold:   "100.00 USD",
+			expectedBought: "134.00 CAD",
+			expectedRate:   "1.34",
+			expectedQuoted: "1.36",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			c, ok := ConversionFromActivity(&tc.act)
+			g.Expect(ok).To(BeTrue())
+			g.Expect(c.Sold.String()).To(Equal(tc.expectedSold))
+			g.Expect(c.Bought.String()).To(Equal(tc.expectedBought))
+			g.Expect(c.Rate.Trim().String()).To(Equal(tc.expectedRate))
+			g.Expect(c.QuotedRate.Trim().String()).To(Equal(tc.expectedQuoted))
+		})
+	}
+}
+
+func Test_ConversionFromActivity_NotAConversion(t *testing.T) {
+	g := NewWithT(t)
+
+	_, ok := ConversionFromActivity(&generated.Activity{Currency: lo.ToPtr("CAD"), Amount: money.MustParse("10")})
+	g.Expect(ok).To(BeFalse())
+
+	_, ok = ConversionFromActivity(&generated.Activity{
+		Currency: lo.ToPtr("CAD"), Amount: money.MustParse("10"),
+		CounterPartyCurrency: lo.ToPtr("CAD"), CounterPartyCurrencyAmount: lo.ToPtr(money.MustParse("10")),
+	})
+	g.Expect(ok).To(BeFalse())
+}
+
+func Test_Conversion_Spread(t *testing.T) {
+	g := NewWithT(t)
+	c := Conversion{
+		Sold:   money.NewMoney(money.MustParse("100.00"), "USD"),
+		Bought: money.NewMoney(money.MustParse("134.00"), "CAD"),
+		Rate:   money.MustParse("1.34"),
+	}
+
+	spread, err := c.Spread(money.MustParse("1.36"))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(spread.Relative.String()).To(Equal("0.014706"))
+	g.Expect(spread.Cost.String()).To(Equal("2.00 CAD"))
+
+	_, err = c.Spread(money.Zero)
+	g.Expect(err).To(HaveOccurred())
+}
+
+func Test_ParseRate(t *testing.T) {
+	g := NewWithT(t)
+
+	r, err := ParseRate("usd/cad=1.3650", may1)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(r.From).To(Equal("USD"))
+	g.Expect(r.To).To(Equal("CAD"))
+	g.Expect(r.Value.String()).To(Equal("1.3650"))
+	g.Expect(r.At).To(Equal(may1))
+
+	for _, invalid := range []string{"USD/CAD", "USD=1.36", "USD/CAD=abc", "USD/CAD=-1"} {
+		_, err := ParseRate(invalid, may1)
+		g.Expect(err).To(HaveOccurred(), invalid)
+	}
+}
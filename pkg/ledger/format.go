@@ -0,0 +1,188 @@
+package ledger
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Formats lists the syntaxes transactions can be written in
+var Formats = []Format{Beancount, Ledger}
+
+// ParseFormat reads a syntax, beancount or ledger
+func ParseFormat(s string) (Format, error) {
+	for _, f := range Formats {
+		if strings.EqualFold(s, string(f)) {
+			return f, nil
+		}
+	}
+	return "", fmt.Errorf("invalid format %q, expected %s or %s", s, Beancount, Ledger)
+}
+
+// Existing is what a journal already has
+type Existing struct {
+	// Ids are the canonical ids of the transactions of the journal
+	Ids map[string]bool
+
+	// Transfers are the transfers between Wealthsimple accounts the
+	// journal has, from either leg
+	Transfers map[string]bool
+
+	// Opened are the accounts a beancount journal opens
+	Opened map[string]bool
+}
+
+var (
+	// beancountId is the metadata line carrying the id of a
+	// beancount transaction
+	beancountId = regexp.MustCompile(`^\s+id:\s*"([^"]+)"`)
+	// ledgerCode is the code of a ledger transaction, its id
+	ledgerCode = regexp.MustCompile(`^\d{4}[-/]\d{2}[-/]\d{2}(?:=\S+)?\s+(?:[*!]\s*)?\(([^)]+)\)`)
+	// transferMeta is the metadata identifying a transfer, quoted
+	// in beancount
+	transferMeta = regexp.MustCompile(`^\s+(?:;\s*)?transfer:\s*"?([^"]+?)"?\s*$`)
+	// openDirective opens a beancount account
+	openDirective = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}\s+open\s+(\S+)`)
+)
+
+// ReadExisting reads the transaction ids, the transfers and the opened
+// accounts of a beancount or ledger journal
+func ReadExisting(r io.Reader) (*Existing, error) {
+	e := &Existing{Ids: map[string]bool{}, Transfers: map[string]bool{}, Opened: map[string]bool{}}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if m := beancountId.FindStringSubmatch(line); m != nil {
+			e.Ids[m[1]] = true
+		} else if m := ledgerCode.FindStringSubmatch(line); m != nil {
+			e.Ids[m[1]] = true
+		} else if m := transferMeta.FindStringSubmatch(line); m != nil {
+			e.Transfers[m[1]] = true
+		} else if m := openDirective.FindStringSubmatch(line); m != nil {
+			e.Opened[m[1]] = true
+		}
+	}
+	return e, scanner.Err()
+}
+
+// writeOpens opens the accounts the transactions use and the journal
+// doesn't open yet, on the date of the first transaction. Wealthsimple
+// accounts reduce their lots first in, first out.
+func writeOpens(sb *strings.Builder, txns []transaction, assets map[string]bool, existing *Existing) {
+	if len(txns) == 0 {
+		return
+	}
+	used := map[string]bool{}
+	for _, txn := range txns {
+		for _, p := range txn.postings {
+			used[p.account] = true
+		}
+	}
+	var accounts []string
+	for account := range used {
+		if !existing.Opened[account] {
+			accounts = append(accounts, account)
+		}
+	}
+	if len(accounts) == 0 {
+		return
+	}
+	sort.Strings(accounts)
+
+	date := txns[0].date.Format(time.DateOnly)
+	for _, account := range accounts {
+		if assets[account] {
+			fmt.Fprintf(sb, "%s open %s \"FIFO\"\n", date, account)
+		} else {
+			fmt.Fprintf(sb, "%s open %s\n", date, account)
+		}
+	}
+	sb.WriteString("\n")
+}
+
+func writeBeancount(sb *strings.Builder, txn transaction) {
+	fmt.Fprintf(sb, "%s * %s\n", txn.date.Format(time.DateOnly), quote(txn.narration))
+	fmt.Fprintf(sb, "  id: %s\n", quote(txn.id))
+	if txn.transfer != "" {
+		fmt.Fprintf(sb, "  transfer: %s\n", quote(txn.transfer))
+	}
+	width := accountWidth(txn)
+	for _, p := range txn.postings {
+		if p.amount == nil {
+			fmt.Fprintf(sb, "  %s\n", p.account)
+			continue
+		}
+		fmt.Fprintf(sb, "  %-*s  %s", width, p.account, formatAmount(*p.amount, false))
+		switch {
+		case p.sold:
+			sb.WriteString(" {}")
+		case p.cost != nil:
+			fmt.Fprintf(sb, " {%s}", formatAmount(*p.cost, false))
+		}
+		if p.price != nil {
+			fmt.Fprintf(sb, " @@ %s", formatAmount(*p.price, false))
+		}
+		sb.WriteString("\n")
+	}
+	sb.WriteString("\n")
+}
+
+func writeLedger(sb *strings.Builder, txn transaction) {
+	fmt.Fprintf(sb, "%s * (%s) %s\n", txn.date.Format(time.DateOnly), txn.id, oneLine(txn.narration))
+	if txn.transfer != "" {
+		fmt.Fprintf(sb, "    ; transfer: %s\n", txn.transfer)
+	}
+	width := accountWidth(txn)
+	for _, p := range txn.postings {
+		// ledger has no booking method to fill in the capital gains
+		// posting of a sale, nor the cost of the lot sold: sales are
+		// written at their price and their gains aren't tracked
+		if p.amount == nil {
+			continue
+		}
+		fmt.Fprintf(sb, "    %-*s  %s", width, p.account, formatAmount(*p.amount, true))
+		if p.cost != nil {
+			fmt.Fprintf(sb, " {%s}", formatAmount(*p.cost, true))
+		}
+		if p.price != nil {
+			fmt.Fprintf(sb, " @@ %s", formatAmount(*p.price, true))
+		}
+		sb.WriteString("\n")
+	}
+	sb.WriteString("\n")
+}
+
+// accountWidth returns the width amounts are aligned after
+func accountWidth(txn transaction) int {
+	width := 0
+	for _, p := range txn.postings {
+		if p.amount != nil {
+			width = max(width, len(p.account))
+		}
+	}
+	return width
+}
+
+// formatAmount writes an amount, ledger commodities holding other
+// characters than letters being quoted
+func formatAmount(a amount, ledger bool) string {
+	commodity := a.commodity
+	if ledger && strings.ContainsFunc(commodity, func(r rune) bool { return !(r >= 'A' && r <= 'Z' || r >= 'a' && r <= 'z') }) {
+		commodity = `"` + commodity + `"`
+	}
+	return a.number.String() + " " + commodity
+}
+
+// quote returns a beancount string
+func quote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(oneLine(s)) + `"`
+}
+
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
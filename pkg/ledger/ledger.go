@@ -0,0 +1,393 @@
+// Package ledger writes activities as double-entry transactions in the
+// plain-text accounting syntaxes of beancount and ledger, the latter
+// being also read by hledger. Every transaction carries the canonical id
+// of its activity, so exports appended to a journal skip the activities
+// it already has.
+package ledger
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/samber/lo"
+	"github.com/vpnda/wsfetch/pkg/client"
+	"github.com/vpnda/wsfetch/pkg/client/generated"
+	"github.com/vpnda/wsfetch/pkg/fx"
+	"github.com/vpnda/wsfetch/pkg/money"
+)
+
+// Format is a plain-text accounting syntax
+type Format string
+
+const (
+	Beancount Format = "beancount"
+	// Ledger is the syntax of ledger, which hledger reads too
+	Ledger Format = "ledger"
+)
+
+// Roles name the accounts activities are balanced against, mapped to
+// ledger accounts like Wealthsimple accounts are
+const (
+	Dividends      = "dividends"
+	Interest       = "interest"
+	OtherIncome    = "income"
+	WithholdingTax = "withholding-tax"
+	CapitalGains   = "capital-gains"
+	// External is where deposits come from and withdrawals,
+	// payments and transfers to other institutions go
+	External = "external"
+)
+
+// Roles lists the roles along with the accounts they map to by default
+var Roles = [][2]string{
+	{Dividends, "Income:Wealthsimple:Dividends"},
+	{Interest, "Income:Wealthsimple:Interest"},
+	{OtherIncome, "Income:Wealthsimple:Other"},
+	{WithholdingTax, "Expenses:Taxes:Withholding"},
+	{CapitalGains, "Income:Wealthsimple:CapitalGains"},
+	{External, "Equity:Wealthsimple:External"},
+}
+
+// assetsRoot is the parent of the accounts Wealthsimple accounts map to
+// by default
+const assetsRoot = "Assets:Wealthsimple"
+
+// Mapping names the ledger accounts of Wealthsimple accounts and roles
+type Mapping struct {
+	// Names maps lower case account IDs, nicknames and roles to
+	// ledger accounts, overriding the defaults
+	Names map[string]string
+
+	// Accounts are the accounts of the profiles by ID, the opposing
+	// accounts of transfers being named after them
+	Accounts map[string]*generated.AccountWithFinancials
+}
+
+// Account returns the ledger account of a Wealthsimple account, mapped
+// from its ID or nickname, Assets:Wealthsimple:NICKNAME by default
+func (m Mapping) Account(id string) string {
+	if name, ok := m.Names[strings.ToLower(id)]; ok {
+		return name
+	}
+	a, ok := m.Accounts[id]
+	if !ok || lo.FromPtr(a.Nickname) == "" {
+		return assetsRoot + ":" + component(id)
+	}
+	if name, ok := m.Names[strings.ToLower(*a.Nickname)]; ok {
+		return name
+	}
+	return assetsRoot + ":" + component(*a.Nickname)
+}
+
+// Role returns the ledger account of a role
+func (m Mapping) Role(role string) string {
+	if name, ok := m.Names[role]; ok {
+		return name
+	}
+	for _, r := range Roles {
+		if r[0] == role {
+			return r[1]
+		}
+	}
+	return role
+}
+
+// Transaction is an activity along with its description
+type Transaction struct {
+	Activity    generated.Activity
+	Description string
+}
+
+// Account is an account along with its activities
+type Account struct {
+	Account      generated.AccountWithFinancials
+	Transactions []Transaction
+}
+
+// Options tune how transactions are written
+type Options struct {
+	// Location is the timezone dates are written in, UTC if nil
+	Location *time.Location
+
+	// Existing is the journal transactions are appended to, those it
+	// has and the accounts it opens are skipped
+	Existing *Existing
+}
+
+// Write writes the activities of the accounts as transactions, sorted by
+// date. Beancount exports start with the open directives of the accounts
+// used.
+func Write(w io.Writer, format Format, accounts []Account, mapping Mapping, opts Options) error {
+	if opts.Location == nil {
+		opts.Location = time.UTC
+	}
+	if opts.Existing == nil {
+		opts.Existing = &Existing{}
+	}
+	b := &builder{mapping: mapping, exported: map[string]bool{}}
+	for _, a := range accounts {
+		b.exported[a.Account.Id] = true
+	}
+
+	var txns []transaction
+	for _, a := range accounts {
+		for _, t := range a.Transactions {
+			txn, ok := b.transaction(&a.Account, &t)
+			if !ok || opts.Existing.Ids[txn.id] || opts.Existing.Transfers[txn.transfer] {
+				continue
+			}
+			txn.date = txn.date.In(opts.Location)
+			txns = append(txns, txn)
+		}
+	}
+	sort.SliceStable(txns, func(i, j int) bool {
+		di, dj := txns[i].date.Format(time.DateOnly), txns[j].date.Format(time.DateOnly)
+		if di != dj {
+			return di < dj
+		}
+		return txns[i].id < txns[j].id
+	})
+
+	var sb strings.Builder
+	switch format {
+	case Beancount:
+		writeOpens(&sb, txns, b.assets, opts.Existing)
+		for _, txn := range txns {
+			writeBeancount(&sb, txn)
+		}
+	case Ledger:
+		for _, txn := range txns {
+			writeLedger(&sb, txn)
+		}
+	default:
+		return fmt.Errorf("unknown format %q", format)
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// transaction is a balanced set of postings
+type transaction struct {
+	date      time.Time
+	id        string
+	narration string
+	postings  []posting
+
+	// transfer identifies a transfer between two Wealthsimple
+	// accounts whichever of its legs it is written from
+	transfer string
+}
+
+// posting moves an amount in or out of an account. A lot is held at
+// cost, a sold lot has an empty cost the booking method fills, and price
+// is the total the amount was exchanged for.
+type posting struct {
+	account string
+	amount  *amount
+	cost    *amount
+	sold    bool
+	price   *amount
+}
+
+type amount struct {
+	number    money.Decimal
+	commodity string
+}
+
+func (a amount) neg() *amount { return &amount{number: a.number.Neg(), commodity: a.commodity} }
+
+// builder turns activities into transactions
+type builder struct {
+	mapping Mapping
+
+	// exported tells which accounts are exported, transfers between
+	// two of them being written once
+	exported map[string]bool
+
+	// assets are the ledger accounts of Wealthsimple accounts used,
+	// opened with a booking method
+	assets map[string]bool
+}
+
+func (b *builder) account(id string) string {
+	name := b.mapping.Account(id)
+	if b.assets == nil {
+		b.assets = map[string]bool{}
+	}
+	b.assets[name] = true
+	return name
+}
+
+// transaction returns the transaction of an activity, false for
+// activities written along with another one or moving nothing
+func (b *builder) transaction(a *generated.AccountWithFinancials, t *Transaction) (transaction, bool) {
+	act := &t.Activity
+	txn := transaction{
+		date:      lo.FromPtr(act.OccurredAt),
+		id:        lo.FromPtr(act.CanonicalId),
+		narration: t.Description,
+	}
+	if txn.id == "" {
+		return txn, false
+	}
+	account := b.account(a.Id)
+	flow := client.CashFlow(act)
+	cash := amount{number: flow.Amount.Amount, commodity: flow.Amount.Currency}
+	if cash.commodity == "" {
+		cash.commodity = lo.FromPtr(a.Currency)
+	}
+	units := act.AssetQuantity.Abs()
+	symbol := commodity(act)
+
+	switch {
+	case (act.Type == generated.ActivityTypeDiyBuy || act.Type == generated.ActivityTypeManagedBuy) && symbol != "" && !units.IsZero():
+		total := amount{number: act.Amount.Abs(), commodity: lo.FromPtr(act.Currency)}
+		price, _ := total.number.Div(units, 6, money.HalfEven)
+		txn.postings = []posting{
+			{account: account, amount: &amount{units, symbol}, cost: &amount{price.Trim(), total.commodity}, price: &total},
+			b.cashLeg(account, act, total.neg()),
+		}
+	case (act.Type == generated.ActivityTypeDiySell || act.Type == generated.ActivityTypeManagedSell) && symbol != "" && !units.IsZero():
+		total := amount{number: act.Amount.Abs(), commodity: lo.FromPtr(act.Currency)}
+		txn.postings = []posting{
+			{account: account, amount: &amount{units.Neg(), symbol}, sold: true, price: &total},
+			b.cashLeg(account, act, &total),
+			{account: b.mapping.Role(CapitalGains)},
+		}
+	case act.Type == generated.ActivityTypeFundsConversion:
+		c, ok := fx.ConversionFromActivity(act)
+		if !ok {
+			return txn, false
+		}
+		bought := amount{c.Bought.Amount, c.Bought.Currency}
+		txn.postings = []posting{
+			{account: account, amount: &amount{c.Sold.Amount.Neg(), c.Sold.Currency}, price: &bought},
+			{account: account, amount: &bought},
+		}
+	case act.Type == generated.ActivityTypeInternalTransfer && act.OpposingAccountId != nil:
+		// a transfer between two exported accounts is written once,
+		// from the account the cash leaves
+		if act.SubType != generated.ActivitySubtypeSource && b.exported[*act.OpposingAccountId] {
+			return txn, false
+		}
+		txn.postings = []posting{
+			{account: account, amount: &cash},
+			{account: b.account(*act.OpposingAccountId), amount: cash.neg()},
+		}
+		txn.transfer = transferKey(a.Id, *act.OpposingAccountId, flow.Direction, txn.date, cash)
+	case flow.Direction == client.NoCashFlow:
+		if symbol == "" || units.IsZero() {
+			return txn, false
+		}
+		moved := amount{units, symbol}
+		if act.AmountSign == generated.AmountSignNegative {
+			moved.number = moved.number.Neg()
+		}
+		txn.postings = []posting{
+			{account: account, amount: &moved},
+			{account: b.mapping.Role(External), amount: moved.neg()},
+		}
+	default:
+		if cash.number.IsZero() {
+			return txn, false
+		}
+		txn.postings = []posting{
+			{account: account, amount: &cash},
+			{account: b.mapping.Role(counterRole(act)), amount: cash.neg()},
+		}
+	}
+	return txn, true
+}
+
+// cashLeg returns the posting paying or receiving total for a trade, in
+// the currency the account settled it in when it was converted
+func (b *builder) cashLeg(account string, act *generated.Activity, total *amount) posting {
+	if _, ok := fx.ConversionFromActivity(act); ok {
+		settled := lo.FromPtr(act.CounterPartyCurrencyAmount).Abs()
+		if total.number.IsNegative() {
+			settled = settled.Neg()
+		}
+		return posting{
+			account: account,
+			amount:  &amount{settled, lo.FromPtr(act.CounterPartyCurrency)},
+			price:   &amount{total.number.Abs(), total.commodity},
+		}
+	}
+	return posting{account: account, amount: total}
+}
+
+// transferKey identifies a transfer by the account the cash leaves, the
+// account it goes to, its day and its amount, both legs having their own
+// canonical id
+func transferKey(account, opposing string, direction client.Direction, date time.Time, cash amount) string {
+	from, to := opposing, account
+	if direction == client.Outflow {
+		from, to = account, opposing
+	}
+	return fmt.Sprintf("%s %s>%s %s %s", date.UTC().Format(time.DateOnly), from, to, cash.number.Abs().Trim(), cash.commodity)
+}
+
+// counterRole returns the role cash activities are balanced against
+func counterRole(act *generated.Activity) string {
+	switch act.Type {
+	case generated.ActivityTypeDividend:
+		return Dividends
+	case generated.ActivityTypeInterest:
+		return Interest
+	case generated.ActivityTypeNonResidentTax:
+		return WithholdingTax
+	case generated.ActivityTypePromotion,
+		generated.ActivityTypeReferral,
+		generated.ActivityTypeRefund:
+		return OtherIncome
+	}
+	return External
+}
+
+// commodity returns the commodity of the security of an activity, its
+// symbol when it is known
+func commodity(act *generated.Activity) string {
+	symbol := lo.FromPtr(act.AssetSymbol)
+	if symbol == "" {
+		id := lo.FromPtr(act.SecurityId)
+		if id == "" || strings.HasPrefix(id, "sec-c-") {
+			return ""
+		}
+		symbol = strings.TrimPrefix(id, "sec-s-")
+	}
+
+	// beancount commodities are upper case, start with a letter and
+	// end with a letter or digit
+	symbol = strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '_', r == '-', r == '\'':
+			return r
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		}
+		return '-'
+	}, symbol)
+	symbol = strings.TrimRight(symbol, ".-_'")
+	if symbol != "" && (symbol[0] < 'A' || symbol[0] > 'Z') {
+		symbol = "X" + symbol
+	}
+	return symbol
+}
+
+// component returns a name usable as a component of an account, each
+// word capitalized, Retirement savings becoming Retirement-Savings
+func component(name string) string {
+	words := strings.FieldsFunc(name, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9')
+	})
+	for i, w := range words {
+		words[i] = strings.ToUpper(w[:1]) + w[1:]
+	}
+	if len(words) == 0 {
+		return "Unnamed"
+	}
+	return strings.Join(words, "-")
+}
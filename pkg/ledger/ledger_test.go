@@ -0,0 +1,341 @@
+package ledger
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/samber/lo"
+	"github.com/vpnda/wsfetch/pkg/client/generated"
+	"github.com/vpnda/wsfetch/pkg/money"
+	"github.com/vpnda/wsfetch/pkg/wstest"
+)
+
+// exported returns the fixture TFSA, nicknamed Savings, and RRSP, their
+// activities along with the other side of the transfer and trades,
+// taxes and conversions made from copies of them
+func exported() ([]Account, Mapping) {
+	f := wstest.DefaultFixtures()
+	tfsa := f.Account("tfsa-abc123")
+	tfsa.Nickname = lo.ToPtr("Savings")
+	rrsp := f.Account("rrsp-def456")
+
+	buy := f.Activity("act-1")
+	buy.Currency = lo.ToPtr("USD")
+	buy.CounterPartyCurrency = lo.ToPtr("CAD")
+	buy.CounterPartyCurrencyAmount = lo.ToPtr(money.MustParse("411.55"))
+
+	sell := f.Activity("act-1")
+	sell.CanonicalId = lo.ToPtr("sell-1")
+	sell.Type = generated.ActivityTypeDiySell
+	sell.AmountSign = generated.AmountSignPositive
+	sell.Amount = money.MustParse("160.00")
+	sell.SecurityId = lo.ToPtr("sec-s-vfv")
+	sell.AssetQuantity = money.MustParse("1.5")
+	sell.OccurredAt = lo.ToPtr(time.Date(2024, 5, 3, 14, 30, 0, 0, time.UTC))
+
+	dividend := f.Activity("act-2")
+	tax := f.Activity("act-2")
+	tax.CanonicalId = lo.ToPtr("tax-1")
+	tax.Type = generated.ActivityTypeNonResidentTax
+	tax.AmountSign = generated.AmountSignNegative
+	tax.Amount = money.MustParse("1.85")
+
+	conversion := f.Activity("act-4")
+	conversion.CanonicalId = lo.ToPtr("fx-1")
+	conversion.Type = generated.ActivityTypeFundsConversion
+	conversion.SubType = ""
+	conversion.Amount = money.MustParse("100.00")
+	conversion.Currency = lo.ToPtr("USD")
+	conversion.CounterPartyCurrency = lo.ToPtr("CAD")
+	conversion.CounterPartyCurrencyAmount = lo.ToPtr(money.MustParse("136.50"))
+	conversion.OccurredAt = lo.ToPtr(time.Date(2024, 5, 5, 9, 0, 0, 0, time.UTC))
+
+	out := f.Activity("act-3")
+	in := f.Activity("act-3")
+	in.CanonicalId = lo.ToPtr("transfer-in")
+	in.AccountId = rrsp.Id
+	in.SubType = ""
+	in.AmountSign = generated.AmountSignPositive
+	in.OpposingAccountId = lo.ToPtr(tfsa.Id)
+
+	accounts := []Account{
+		{Account: tfsa, Transactions: []Transaction{
+			{Activity: buy, Description: "Market Order: buy 2 x NASDAQ:AAPL @ 150.75"},
+			{Activity: sell, Description: "Market Order: sell 1.5 x TSX:VFV"},
+			{Activity: dividend, Description: "Dividend: VFV"},
+			{Activity: tax, Description: "Non-resident tax: VFV"},
+			{Activity: conversion, Description: `Funds conversion: "CAD" to USD`},
+			{Activity: out, Description: "Transfer out: Transfer to Wealthsimple RRSP"},
+			{Activity: f.Activity("act-4"), Description: "Deposit: Interac e-transfer"},
+		}},
+		{Account: rrsp, Transactions: []Transaction{
+			{Activity: in, Description: "Transfer in: Transfer from Savings"},
+			{Activity: f.Activity("act-7"), Description: "Cash sent to $friend"},
+		}},
+	}
+	mapping := Mapping{
+		Names:    map[string]string{External: "Assets:Bank:Chequing"},
+		Accounts: map[string]*generated.AccountWithFinancials{tfsa.Id: &tfsa, rrsp.Id: &rrsp},
+	}
+	return accounts, mapping
+}
+
+func Test_Write(t *testing.T) {
+	testCases := []struct {
+		format   Format
+		expected string
+	}{
+		{
+			format: Beancount,
+			expected: `2024-04-26 open Assets:Bank:Chequing
+2024-04-26 open Assets:Wealthsimple:Retirement "FIFO"
+2024-04-26 open Assets:Wealthsimple:Savings "FIFO"
+2024-04-26 open Expenses:Taxes:Withholding
+2024-04-26 open Income:Wealthsimple:CapitalGains
+2024-04-26 open Income:Wealthsimple:Dividends
+
+2024-04-26 * "Cash sent to $friend"
+  id: "act-7"
+  Assets:Wealthsimple:Retirement  -20.00 CAD
+  Assets:Bank:Chequing            20.00 CAD
+
+2024-04-29 * "Deposit: Interac e-transfer"
+  id: "act-4"
+  Assets:Wealthsimple:Savings  1000.00 CAD
+  Assets:Bank:Chequing         -1000.00 CAD
+
+2024-04-30 * "Transfer out: Transfer to Wealthsimple RRSP"
+  id: "act-3"
+  transfer: "2024-04-30 tfsa-abc123>rrsp-def456 500 CAD"
+  Assets:Wealthsimple:Savings     -500.00 CAD
+  Assets:Wealthsimple:Retirement  500.00 CAD
+
+2024-05-01 * "Dividend: VFV"
+  id: "act-2"
+  Assets:Wealthsimple:Savings    12.34 CAD
+  Income:Wealthsimple:Dividends  -12.34 CAD
+
+2024-05-01 * "Non-resident tax: VFV"
+  id: "tax-1"
+  Assets:Wealthsimple:Savings  -1.85 CAD
+  Expenses:Taxes:Withholding   1.85 CAD
+
+2024-05-02 * "Market Order: buy 2 x NASDAQ:AAPL @ 150.75"
+  id: "act-1"
+  Assets:Wealthsimple:Savings  2 AAPL {150.75 USD} @@ 301.50 USD
+  Assets:Wealthsimple:Savings  -411.55 CAD @@ 301.50 USD
+
+2024-05-03 * "Market Order: sell 1.5 x TSX:VFV"
+  id: "sell-1"
+  Assets:Wealthsimple:Savings  -1.5 VFV {} @@ 160.00 CAD
+  Assets:Wealthsimple:Savings  160.00 CAD
+  Income:Wealthsimple:CapitalGains
+
+2024-05-05 * "Funds conversion: \"CAD\" to USD"
+  id: "fx-1"
+  Assets:Wealthsimple:Savings  -136.50 CAD @@ 100.00 USD
+  Assets:Wealthsimple:Savings  100.00 USD
+
+`,
+		},
+		{
+			format: Ledger,
+			expected: `2024-04-26 * (act-7) Cash sent to $friend
+    Assets:Wealthsimple:Retirement  -20.00 CAD
+    Assets:Bank:Chequing            20.00 CAD
+
+2024-04-29 * (act-4) Deposit: Interac e-transfer
+    Assets:Wealthsimple:Savings  1000.00 CAD
+    Assets:Bank:Chequing         -1000.00 CAD
+
+2024-04-30 * (act-3) Transfer out: Transfer to Wealthsimple RRSP
+    ; transfer: 2024-04-30 tfsa-abc123>rrsp-def456 500 CAD
+    Assets:Wealthsimple:Savings     -500.00 CAD
+    Assets:Wealthsimple:Retirement  500.00 CAD
+
+2024-05-01 * (act-2) Dividend: VFV
+    Assets:Wealthsimple:Savings    12.34 CAD
+    Income:Wealthsimple:Dividends  -12.34 CAD
+
+2024-05-01 * (tax-1) Non-resident tax: VFV
+    Assets:Wealthsimple:Savings  -1.85 CAD
+    Expenses:Taxes:Withholding   1.85 CAD
+
+2024-05-02 * (act-1) Market Order: buy 2 x NASDAQ:AAPL @ 150.75
+    Assets:Wealthsimple:Savings  2 AAPL {150.75 USD} @@ 301.50 USD
+    Assets:Wealthsimple:Savings  -411.55 CAD @@ 301.50 USD
+
+2024-05-03 * (sell-1) Market Order: sell 1.5 x TSX:VFV
+    Assets:Wealthsimple:Savings  -1.5 VFV @@ 160.00 CAD
+    Assets:Wealthsimple:Savings  160.00 CAD
+
+2024-05-05 * (fx-1) Funds conversion: "CAD" to USD
+    Assets:Wealthsimple:Savings  -136.50 CAD @@ 100.00 USD
+    Assets:Wealthsimple:Savings  100.00 USD
+
+`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(string(tc.format), func(t *testing.T) {
+			g := NewWithT(t)
+			accounts, mapping := exported()
+			var sb strings.Builder
+			g.Expect(Write(&sb, tc.format, accounts, mapping, Options{})).To(Succeed())
+			g.Expect(sb.String()).To(Equal(tc.expected))
+		})
+	}
+}
+
+func Test_Write_Existing(t *testing.T) {
+	g := NewWithT(t)
+	accounts, mapping := exported()
+
+	var journal strings.Builder
+	g.Expect(Write(&journal, Beancount, accounts, mapping, Options{})).To(Succeed())
+	existing, err := ReadExisting(strings.NewReader(journal.String()))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(existing.Ids).To(HaveLen(8))
+	g.Expect(existing.Opened).To(HaveKey("Assets:Wealthsimple:Savings"))
+
+	// appending the same export again adds nothing
+	var appended strings.Builder
+	g.Expect(Write(&appended, Beancount, accounts, mapping, Options{Existing: existing})).To(Succeed())
+	g.Expect(appended.String()).To(BeEmpty())
+
+	journal.Reset()
+	g.Expect(Write(&journal, Ledger, accounts, mapping, Options{})).To(Succeed())
+	existing, err = ReadExisting(strings.NewReader(journal.String()))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(existing.Ids).To(HaveKey("act-3"))
+	g.Expect(existing.Ids).To(HaveLen(8))
+}
+
+func Test_Write_AppendFillsGaps(t *testing.T) {
+	for _, format := range Formats {
+		t.Run(string(format), func(t *testing.T) {
+			g := NewWithT(t)
+			accounts, mapping := exported()
+
+			// a first run seeing only the newest activities of the TFSA
+			// and none of the RRSP
+			partial := []Account{{Account: accounts[0].Account, Transactions: accounts[0].Transactions[:3]}}
+			var journal strings.Builder
+			g.Expect(Write(&journal, format, partial, mapping, Options{})).To(Succeed())
+			existing, err := ReadExisting(strings.NewReader(journal.String()))
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(existing.Ids).To(HaveLen(3))
+
+			// appending the whole window writes what it missed, once
+			g.Expect(Write(&journal, format, accounts, mapping, Options{Existing: existing})).To(Succeed())
+			existing, err = ReadExisting(strings.NewReader(journal.String()))
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(existing.Ids).To(HaveLen(8))
+			g.Expect(strings.Count(journal.String(), "act-1")).To(Equal(1))
+			g.Expect(existing.Transfers).To(HaveLen(1))
+
+			var appended strings.Builder
+			g.Expect(Write(&appended, format, accounts, mapping, Options{Existing: existing})).To(Succeed())
+			g.Expect(appended.String()).To(BeEmpty())
+		})
+	}
+}
+
+func Test_Write_TransferFromUnexportedAccount(t *testing.T) {
+	g := NewWithT(t)
+	accounts, mapping := exported()
+
+	// without the account the cash left, the transfer is written
+	// from the account it went to
+	var sb strings.Builder
+	g.Expect(Write(&sb, Ledger, accounts[1:], mapping, Options{})).To(Succeed())
+	g.Expect(sb.String()).To(Equal(`2024-04-26 * (act-7) Cash sent to $friend
+    Assets:Wealthsimple:Retirement  -20.00 CAD
+    Assets:Bank:Chequing            20.00 CAD
+
+2024-04-30 * (transfer-in) Transfer in: Transfer from Savings
+    ; transfer: 2024-04-30 tfsa-abc123>rrsp-def456 500 CAD
+    Assets:Wealthsimple:Retirement  500.00 CAD
+    Assets:Wealthsimple:Savings     -500.00 CAD
+
+`))
+}
+
+func Test_Write_TransferAppendedFromEachLeg(t *testing.T) {
+	for _, format := range Formats {
+		t.Run(string(format), func(t *testing.T) {
+			g := NewWithT(t)
+			accounts, mapping := exported()
+
+			// the transfer written from the account the cash left is
+			// skipped when the account it went to is appended
+			var journal strings.Builder
+			g.Expect(Write(&journal, format, accounts[:1], mapping, Options{})).To(Succeed())
+			existing, err := ReadExisting(strings.NewReader(journal.String()))
+			g.Expect(err).ToNot(HaveOccurred())
+			var appended strings.Builder
+			g.Expect(Write(&appended, format, accounts[1:], mapping, Options{Existing: existing})).To(Succeed())
+			g.Expect(appended.String()).ToNot(ContainSubstring("transfer-in"))
+			g.Expect(appended.String()).To(ContainSubstring("act-7"))
+
+			// and the other way around
+			journal.Reset()
+			g.Expect(Write(&journal, format, accounts[1:], mapping, Options{})).To(Succeed())
+			existing, err = ReadExisting(strings.NewReader(journal.String()))
+			g.Expect(err).ToNot(HaveOccurred())
+			appended.Reset()
+			g.Expect(Write(&appended, format, accounts[:1], mapping, Options{Existing: existing})).To(Succeed())
+			g.Expect(appended.String()).ToNot(ContainSubstring("act-3"))
+			g.Expect(appended.String()).To(ContainSubstring("act-4"))
+		})
+	}
+}
+
+func Test_Mapping(t *testing.T) {
+	g := NewWithT(t)
+	_, mapping := exported()
+
+	g.Expect(mapping.Account("tfsa-abc123")).To(Equal("Assets:Wealthsimple:Savings"))
+	g.Expect(mapping.Account("unknown-1")).To(Equal("Assets:Wealthsimple:Unknown-1"))
+	g.Expect(mapping.Role(Dividends)).To(Equal("Income:Wealthsimple:Dividends"))
+	g.Expect(mapping.Role(External)).To(Equal("Assets:Bank:Chequing"))
+
+	// accounts are mapped by ID first, then by nickname
+	mapping.Names["savings"] = "Assets:TFSA"
+	g.Expect(mapping.Account("tfsa-abc123")).To(Equal("Assets:TFSA"))
+	mapping.Names["tfsa-abc123"] = "Assets:Wealthsimple:TFSA"
+	g.Expect(mapping.Account("tfsa-abc123")).To(Equal("Assets:Wealthsimple:TFSA"))
+}
+
+func Test_Commodity(t *testing.T) {
+	testCases := []struct {
+		symbol     *string
+		securityId *string
+		expected   string
+	}{
+		{symbol: lo.ToPtr("BRK.B"), expected: "BRK.B"},
+		{symbol: lo.ToPtr("vfv"), expected: "VFV"},
+		{securityId: lo.ToPtr("sec-s-1234abcd"), expected: "X1234ABCD"},
+		{securityId: lo.ToPtr("sec-c-cad")},
+		{},
+	}
+
+	for _, tc := range testCases {
+		g := NewWithT(t)
+		g.Expect(commodity(&generated.Activity{AssetSymbol: tc.symbol, SecurityId: tc.securityId})).To(Equal(tc.expected))
+	}
+}
+
+func Test_ParseFormat(t *testing.T) {
+	g := NewWithT(t)
+
+	f, err := ParseFormat("Beancount")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(f).To(Equal(Beancount))
+
+	_, err = ParseFormat("gnucash")
+	g.Expect(err).To(HaveOccurred())
+}
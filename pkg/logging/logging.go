@@ -0,0 +1,129 @@
+package logging
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	// Redacted replaces the value of every sensitive field
+	Redacted = "[REDACTED]"
+
+	FormatConsole = "console"
+	FormatJSON    = "json"
+)
+
+// sensitiveKeys are matched, case insensitively and ignoring dashes and
+// underscores, against field keys. Any field whose key contains one of
+// them is redacted.
+var sensitiveKeys = []string{
+	"password",
+	"token",
+	"secret",
+	"otp",
+	"claim",
+	"authorization",
+	"cookie",
+	"email",
+	"username",
+	"payload",
+	"firstname",
+	"lastname",
+	"fullname",
+	"phone",
+	"address",
+	"birth",
+	"accountnumber",
+}
+
+// New builds a logger writing to w at the given level ("debug", "info",
+// "warn", "error") and format ("console" or "json"). Sensitive fields
+// are redacted before reaching the encoder.
+func New(w io.Writer, level string, format string) (*zap.Logger, error) {
+	lvl, err := zapcore.ParseLevel(level)
+	if err != nil {
+		return nil, err
+	}
+
+	encoderCfg := zap.NewProductionEncoderConfig()
+	encoderCfg.EncodeTime = zapcore.ISO8601TimeEncoder
+
+	var encoder zapcore.Encoder
+	switch format {
+	case FormatConsole:
+		encoderCfg.EncodeLevel = zapcore.CapitalLevelEncoder
+		encoder = zapcore.NewConsoleEncoder(encoderCfg)
+	case FormatJSON:
+		encoder = zapcore.NewJSONEncoder(encoderCfg)
+	default:
+		return nil, fmt.Errorf("unknown log format %q, expected %s or %s", format, FormatConsole, FormatJSON)
+	}
+
+	core := zapcore.NewCore(encoder, zapcore.AddSync(w), lvl)
+	return zap.New(NewRedactingCore(core)), nil
+}
+
+// Nop returns the logger used when none is configured
+func Nop() *zap.Logger {
+	return zap.NewNop()
+}
+
+var keyNormalizer = strings.NewReplacer("_", "", "-", "")
+
+// IsSensitive reports whether a field with the given key must be redacted
+func IsSensitive(key string) bool {
+	key = keyNormalizer.Replace(strings.ToLower(key))
+	for _, s := range sensitiveKeys {
+		if strings.Contains(key, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// NewRedactingCore wraps core so the value of every sensitive field,
+// see IsSensitive, is replaced before being encoded
+func NewRedactingCore(core zapcore.Core) zapcore.Core {
+	return &redactingCore{Core: core}
+}
+
+type redactingCore struct {
+	zapcore.Core
+}
+
+func (c *redactingCore) With(fields []zapcore.Field) zapcore.Core {
+	return &redactingCore{Core: c.Core.With(redact(fields))}
+}
+
+func (c *redactingCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *redactingCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	return c.Core.Write(ent, redact(fields))
+}
+
+func redact(fields []zapcore.Field) []zapcore.Field {
+	var out []zapcore.Field
+	for i, f := range fields {
+		if !IsSensitive(f.Key) {
+			continue
+		}
+		if out == nil {
+			out = make([]zapcore.Field, len(fields))
+			copy(out, fields)
+		}
+		out[i] = zap.String(f.Key, Redacted)
+	}
+	if out == nil {
+		return fields
+	}
+	return out
+}
@@ -0,0 +1,39 @@
+package logging
+
+import (
+	"bytes"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap"
+)
+
+func Test_RedactingLogger(t *testing.T) {
+	g := NewWithT(t)
+	buf := &bytes.Buffer{}
+	logger, err := New(buf, "debug", FormatJSON)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	logger.With(zap.String("refresh_token", "someRefreshToken")).Sugar().Infow("Authenticating",
+		"password", "hunter2",
+		"x-wealthsimple-otp-claim", "someOtpClaim",
+		"eTransferEmail", "someone@example.com",
+		"respStatus", "200 OK",
+	)
+
+	out := buf.String()
+	g.Expect(out).ToNot(ContainSubstring("someRefreshToken"))
+	g.Expect(out).ToNot(ContainSubstring("hunter2"))
+	g.Expect(out).ToNot(ContainSubstring("someOtpClaim"))
+	g.Expect(out).ToNot(ContainSubstring("someone@example.com"))
+	g.Expect(out).To(ContainSubstring(Redacted))
+	g.Expect(out).To(ContainSubstring("200 OK"))
+}
+
+func Test_New_InvalidSettings(t *testing.T) {
+	g := NewWithT(t)
+	_, err := New(&bytes.Buffer{}, "loud", FormatJSON)
+	g.Expect(err).To(HaveOccurred())
+	_, err = New(&bytes.Buffer{}, "info", "xml")
+	g.Expect(err).To(HaveOccurred())
+}
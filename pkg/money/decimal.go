@@ -0,0 +1,208 @@
+// Package money holds exact decimal numbers and currency amounts.
+// Wealthsimple sends amounts, quantities and prices as decimal strings,
+// they are kept exact so computing book values or reconciling balances
+// doesn't drift the way floats do.
+package money
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/shopspring/decimal"
+)
+
+// ErrDivisionByZero is returned when dividing by zero
+var ErrDivisionByZero = errors.New("division by zero")
+
+// RoundingMode tells how digits dropped by rounding are handled
+type RoundingMode int
+
+const (
+	// HalfUp rounds to the nearest value, ties away from zero
+	HalfUp RoundingMode = iota
+	// HalfEven rounds to the nearest value, ties to the even digit
+	HalfEven
+	// Down truncates, rounding towards zero
+	Down
+	// Up rounds away from zero
+	Up
+	// Floor rounds towards negative infinity
+	Floor
+	// Ceiling rounds towards positive infinity
+	Ceiling
+)
+
+// Decimal is an exact decimal number. Quantities, prices and amounts
+// whose currency is carried separately are Decimals. The zero value is 0.
+//
+// Decimals keep the scale they were parsed with, "301.50" is printed
+// back as "301.50", and are marshalled to JSON as strings like
+// Wealthsimple sends them.
+type Decimal struct {
+	d decimal.Decimal
+}
+
+// Zero is the decimal 0
+var Zero = Decimal{}
+
+// New returns value * 10^exp
+func New(value int64, exp int32) Decimal {
+	return Decimal{decimal.New(value, exp)}
+}
+
+// NewFromInt returns i as a decimal
+func NewFromInt(i int64) Decimal {
+	return Decimal{decimal.NewFromInt(i)}
+}
+
+// Parse reads a decimal number such as "-12.50"
+func Parse(s string) (Decimal, error) {
+	d, err := decimal.NewFromString(s)
+	if err != nil {
+		return Zero, fmt.Errorf("invalid decimal %q: %w", s, err)
+	}
+	return Decimal{d}, nil
+}
+
+// MustParse is like Parse but panics on invalid numbers,
+// it is meant for constants and tests
+func MustParse(s string) Decimal {
+	d, err := Parse(s)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+func (d Decimal) Add(o Decimal) Decimal { return Decimal{d.d.Add(o.d)} }
+func (d Decimal) Sub(o Decimal) Decimal { return Decimal{d.d.Sub(o.d)} }
+func (d Decimal) Mul(o Decimal) Decimal { return Decimal{d.d.Mul(o.d)} }
+func (d Decimal) Neg() Decimal          { return Decimal{d.d.Neg()} }
+func (d Decimal) Abs() Decimal          { return Decimal{d.d.Abs()} }
+
+// Div divides d by o, the quotient is rounded to places decimals
+func (d Decimal) Div(o Decimal, places int32, mode RoundingMode) (Decimal, error) {
+	if o.IsZero() {
+		return Zero, ErrDivisionByZero
+	}
+	// the quotient truncated to places decimals and the exact
+	// remainder, the rounding mode alone decides the last digit
+	q, r := d.d.QuoRem(o.d, places)
+	if r.IsZero() {
+		return Decimal{q}, nil
+	}
+	positive := d.Sign() == o.Sign()
+	ulp := decimal.New(1, -places)
+
+	var away bool
+	switch mode {
+	case Down:
+	case Up:
+		away = true
+	case Floor:
+		away = !positive
+	case Ceiling:
+		away = positive
+	default:
+		// the dropped fraction r/o is compared with half the last
+		// digit, scaled by the divisor to stay exact
+		switch r.Abs().Mul(decimal.NewFromInt(2)).Cmp(o.d.Abs().Mul(ulp)) {
+		case 1:
+			away = true
+		case 0:
+			away = mode == HalfUp || q.Shift(places).BigInt().Bit(0) == 1
+		}
+	}
+	if !away {
+		return Decimal{q}, nil
+	}
+	if !positive {
+		ulp = ulp.Neg()
+	}
+	return Decimal{q.Add(ulp)}, nil
+}
+
+// Round rounds d to places decimals, a negative places rounds
+// to the left of the decimal point
+func (d Decimal) Round(places int32, mode RoundingMode) Decimal {
+	switch mode {
+	case HalfEven:
+		return Decimal{d.d.RoundBank(places)}
+	case Down:
+		return Decimal{d.d.RoundDown(places)}
+	case Up:
+		return Decimal{d.d.RoundUp(places)}
+	case Floor:
+		return Decimal{d.d.RoundFloor(places)}
+	case Ceiling:
+		return Decimal{d.d.RoundCeil(places)}
+	default:
+		return Decimal{d.d.Round(places)}
+	}
+}
+
+// Cmp returns -1, 0 or +1 when d is less than, equal to or greater than o
+func (d Decimal) Cmp(o Decimal) int { return d.d.Cmp(o.d) }
+
+// Equal reports whether d and o are the same number, whatever their scale
+func (d Decimal) Equal(o Decimal) bool { return d.d.Equal(o.d) }
+
+// Sign returns -1, 0 or +1 depending on the sign of d
+func (d Decimal) Sign() int { return d.d.Sign() }
+
+func (d Decimal) IsZero() bool     { return d.d.IsZero() }
+func (d Decimal) IsNegative() bool { return d.d.IsNegative() }
+
+// Trim drops the trailing zeros of the fractional part, "1.50" becomes "1.5"
+func (d Decimal) Trim() Decimal {
+	return Decimal{decimal.RequireFromString(d.d.String())}
+}
+
+// String prints d with its scale, "301.50" stays "301.50"
+func (d Decimal) String() string {
+	return d.d.StringFixed(max(0, -d.d.Exponent()))
+}
+
+// StringFixed prints d rounded half up to places decimals
+func (d Decimal) StringFixed(places int32) string {
+	return d.d.StringFixed(places)
+}
+
+// Float64 returns the nearest float, for display and statistics only
+func (d Decimal) Float64() float64 {
+	f, _ := d.d.Float64()
+	return f
+}
+
+// MarshalJSON writes d as a JSON string
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+// UnmarshalJSON reads a JSON string or number, null and empty
+// strings, sent for missing quotes, are read as 0
+func (d *Decimal) UnmarshalJSON(b []byte) error {
+	b = bytes.TrimSpace(b)
+	if bytes.Equal(b, []byte("null")) {
+		*d = Zero
+		return nil
+	}
+	s := string(b)
+	if len(b) > 0 && b[0] == '"' {
+		if err := json.Unmarshal(b, &s); err != nil {
+			return err
+		}
+	}
+	if s == "" {
+		*d = Zero
+		return nil
+	}
+	parsed, err := Parse(s)
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
@@ -0,0 +1,93 @@
+package money
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrCurrencyMismatch is returned when combining amounts in different currencies
+var ErrCurrencyMismatch = errors.New("currency mismatch")
+
+// Money is an exact amount in a currency
+type Money struct {
+	Amount   Decimal `json:"amount"`
+	Currency string  `json:"currency"`
+}
+
+// NewMoney returns amount in currency, the currency code is upper cased
+func NewMoney(amount Decimal, currency string) Money {
+	return Money{Amount: amount, Currency: strings.ToUpper(currency)}
+}
+
+// ParseMoney reads amount, a decimal string, in currency
+func ParseMoney(amount, currency string) (Money, error) {
+	d, err := Parse(amount)
+	if err != nil {
+		return Money{}, err
+	}
+	return NewMoney(d, currency), nil
+}
+
+// Add sums m and o, they must share their currency
+func (m Money) Add(o Money) (Money, error) {
+	if err := m.checkCurrency(o); err != nil {
+		return Money{}, err
+	}
+	return Money{Amount: m.Amount.Add(o.Amount), Currency: m.Currency}, nil
+}
+
+// Sub subtracts o from m, they must share their currency
+func (m Money) Sub(o Money) (Money, error) {
+	if err := m.checkCurrency(o); err != nil {
+		return Money{}, err
+	}
+	return Money{Amount: m.Amount.Sub(o.Amount), Currency: m.Currency}, nil
+}
+
+// Cmp compares m to o, they must share their currency
+func (m Money) Cmp(o Money) (int, error) {
+	if err := m.checkCurrency(o); err != nil {
+		return 0, err
+	}
+	return m.Amount.Cmp(o.Amount), nil
+}
+
+// Mul multiplies m by a factor, such as a quantity
+func (m Money) Mul(factor Decimal) Money {
+	return Money{Amount: m.Amount.Mul(factor), Currency: m.Currency}
+}
+
+// Div divides m by a divisor, such as a quantity to get a unit
+// price, the quotient is rounded to places decimals
+func (m Money) Div(divisor Decimal, places int32, mode RoundingMode) (Money, error) {
+	amount, err := m.Amount.Div(divisor, places, mode)
+	if err != nil {
+		return Money{}, err
+	}
+	return Money{Amount: amount, Currency: m.Currency}, nil
+}
+
+// Round rounds the amount to places decimals
+func (m Money) Round(places int32, mode RoundingMode) Money {
+	return Money{Amount: m.Amount.Round(places, mode), Currency: m.Currency}
+}
+
+func (m Money) Neg() Money       { return Money{Amount: m.Amount.Neg(), Currency: m.Currency} }
+func (m Money) IsZero() bool     { return m.Amount.IsZero() }
+func (m Money) IsNegative() bool { return m.Amount.IsNegative() }
+
+// String prints the amount followed by the currency, eg "301.50 CAD"
+func (m Money) String() string {
+	if m.Currency == "" {
+		return m.Amount.String()
+	}
+	return m.Amount.String() + " " + m.Currency
+}
+
+func (m Money) checkCurrency(o Money) error {
+	if m.Currency != o.Currency {
+		return fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.Currency, o.Currency)
+	}
+	return nil
+}
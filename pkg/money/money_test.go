@@ -0,0 +1,169 @@
+package money
+
+import (
+	"encoding/json"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func Test_Decimal_Round(t *testing.T) {
+	testCases := []struct {
+		value    string
+		mode     RoundingMode
+		expected string
+	}{
+		{"2.345", HalfUp, "2.35"},
+		{"-2.345", HalfUp, "-2.35"},
+		{"2.345", HalfEven, "2.34"},
+		{"2.355", HalfEven, "2.36"},
+		{"2.349", Down, "2.34"},
+		{"-2.349", Down, "-2.34"},
+		{"2.341", Up, "2.35"},
+		{"-2.341", Up, "-2.35"},
+		{"-2.341", Floor, "-2.35"},
+		{"2.341", Ceiling, "2.35"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.value, func(t *testing.T) {
+			g := NewWithT(t)
+			g.Expect(MustParse(tc.value).Round(2, tc.mode).String()).To(Equal(tc.expected))
+		})
+	}
+}
+
+func Test_Decimal_Div(t *testing.T) {
+	testCases := []struct {
+		dividend string
+		divisor  string
+		mode     RoundingMode
+		expected string
+	}{
+		// ties
+		{"1", "8", HalfUp, "0.13"},
+		{"1", "8", HalfEven, "0.12"},
+		{"3", "8", HalfEven, "0.38"},
+		{"1", "8", Down, "0.12"},
+		{"1", "8", Up, "0.13"},
+		{"1", "8", Floor, "0.12"},
+		{"1", "8", Ceiling, "0.13"},
+		{"-1", "8", HalfUp, "-0.13"},
+		{"1", "-8", HalfEven, "-0.12"},
+		{"-3", "8", HalfEven, "-0.38"},
+		{"-1", "8", Down, "-0.12"},
+		{"-1", "8", Up, "-0.13"},
+		{"-1", "8", Floor, "-0.13"},
+		{"-1", "8", Ceiling, "-0.12"},
+
+		// just above and below a tie
+		{"125000000001", "1000000000000", HalfUp, "0.13"},
+		{"125000000001", "1000000000000", HalfEven, "0.13"},
+		{"125000000001", "1000000000000", Down, "0.12"},
+		{"125000000001", "1000000000000", Floor, "0.12"},
+		{"124999999999", "1000000000000", HalfUp, "0.12"},
+		{"124999999999", "1000000000000", HalfEven, "0.12"},
+		{"124999999999", "1000000000000", Up, "0.13"},
+		{"124999999999", "1000000000000", Ceiling, "0.13"},
+		{"-125000000001", "1000000000000", HalfEven, "-0.13"},
+		{"-124999999999", "1000000000000", HalfUp, "-0.12"},
+
+		// just below a whole number
+		{"99999999999", "100000000000", HalfUp, "1.00"},
+		{"99999999999", "100000000000", HalfEven, "1.00"},
+		{"99999999999", "100000000000", Down, "0.99"},
+		{"99999999999", "100000000000", Up, "1.00"},
+		{"99999999999", "100000000000", Floor, "0.99"},
+		{"99999999999", "100000000000", Ceiling, "1.00"},
+		{"-99999999999", "100000000000", Down, "-0.99"},
+		{"-99999999999", "100000000000", Floor, "-1.00"},
+		{"-99999999999", "100000000000", Ceiling, "-0.99"},
+
+		// exact quotients are kept, whatever the mode
+		{"1", "4", Up, "0.25"},
+		{"1", "4", Down, "0.25"},
+		{"-301.50", "2", Floor, "-150.75"},
+
+		// quotients smaller than the last digit
+		{"1", "1000", Up, "0.01"},
+		{"1", "1000", HalfUp, "0.00"},
+		{"-1", "1000", Floor, "-0.01"},
+		{"-1", "1000", Ceiling, "0.00"},
+		{"1", "-3", Up, "-0.34"},
+		{"1", "-3", HalfEven, "-0.33"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.dividend+"/"+tc.divisor, func(t *testing.T) {
+			g := NewWithT(t)
+			q, err := MustParse(tc.dividend).Div(MustParse(tc.divisor), 2, tc.mode)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(q.String()).To(Equal(tc.expected))
+		})
+	}
+}
+
+func Test_Decimal_Arithmetic(t *testing.T) {
+	g := NewWithT(t)
+
+	// 0.1 + 0.2 drifts with floats
+	g.Expect(MustParse("0.1").Add(MustParse("0.2")).Equal(MustParse("0.3"))).To(BeTrue())
+	g.Expect(MustParse("301.50").Sub(MustParse("1.5")).String()).To(Equal("300.00"))
+	g.Expect(MustParse("1.25").Mul(MustParse("3")).String()).To(Equal("3.75"))
+
+	price, err := MustParse("100").Div(MustParse("3"), 4, HalfEven)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(price.String()).To(Equal("33.3333"))
+
+	_, err = MustParse("1").Div(Zero, 2, HalfUp)
+	g.Expect(err).To(MatchError(ErrDivisionByZero))
+
+	g.Expect(MustParse("1.500").Trim().String()).To(Equal("1.5"))
+}
+
+func Test_Decimal_JSON(t *testing.T) {
+	testCases := []struct {
+		json     string
+		expected string
+	}{
+		{`"301.50"`, "301.50"},
+		{`-12.5`, "-12.5"},
+		{`null`, "0"},
+		{`""`, "0"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.json, func(t *testing.T) {
+			g := NewWithT(t)
+			var d Decimal
+			g.Expect(json.Unmarshal([]byte(tc.json), &d)).To(Succeed())
+			g.Expect(d.String()).To(Equal(tc.expected))
+
+			bits, err := json.Marshal(d)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(string(bits)).To(Equal(`"` + tc.expected + `"`))
+		})
+	}
+
+	var d Decimal
+	g := NewWithT(t)
+	g.Expect(json.Unmarshal([]byte(`"abc"`), &d)).ToNot(Succeed())
+}
+
+func Test_Money(t *testing.T) {
+	g := NewWithT(t)
+	cad := func(s string) Money { return NewMoney(MustParse(s), "cad") }
+
+	sum, err := cad("10.10").Add(cad("0.20"))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(sum.String()).To(Equal("10.30 CAD"))
+
+	_, err = cad("1").Sub(NewMoney(MustParse("1"), "USD"))
+	g.Expect(err).To(MatchError(ErrCurrencyMismatch))
+
+	unitPrice, err := cad("301.50").Div(MustParse("2"), 2, HalfEven)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(unitPrice).To(Equal(cad("150.75")))
+
+	g.Expect(cad("150.75").Mul(MustParse("2")).String()).To(Equal("301.50 CAD"))
+}
@@ -0,0 +1,235 @@
+package ofx
+
+import "encoding/xml"
+
+// The types below are the OFX 2.2 aggregates written, their fields are
+// in the order the specification requires.
+
+type document struct {
+	XMLName      xml.Name              `xml:"OFX"`
+	SignOn       signOnResponse        `xml:"SIGNONMSGSRSV1>SONRS"`
+	Bank         *bankMessages         `xml:"BANKMSGSRSV1"`
+	Investment   *investmentMessages   `xml:"INVSTMTMSGSRSV1"`
+	SecurityList *securityListMessages `xml:"SECLISTMSGSRSV1"`
+}
+
+func (d *document) bank() *bankMessages {
+	if d.Bank == nil {
+		d.Bank = &bankMessages{}
+	}
+	return d.Bank
+}
+
+func (d *document) investment() *investmentMessages {
+	if d.Investment == nil {
+		d.Investment = &investmentMessages{}
+	}
+	return d.Investment
+}
+
+type status struct {
+	Code     int    `xml:"CODE"`
+	Severity string `xml:"SEVERITY"`
+}
+
+// ok is the status of every response written
+var ok = status{Code: 0, Severity: "INFO"}
+
+type fi struct {
+	Org string `xml:"ORG"`
+	FID string `xml:"FID"`
+}
+
+type signOnResponse struct {
+	Status   status `xml:"STATUS"`
+	DTServer string `xml:"DTSERVER"`
+	Language string `xml:"LANGUAGE"`
+	FI       fi     `xml:"FI"`
+	IntuBid  string `xml:"INTU.BID,omitempty"`
+}
+
+type currency struct {
+	Rate   string `xml:"CURRATE"`
+	Symbol string `xml:"CURSYM"`
+}
+
+type bankMessages struct {
+	Statements []bankTransactionResponse `xml:"STMTTRNRS"`
+}
+
+type bankTransactionResponse struct {
+	TrnUID    string                 `xml:"TRNUID"`
+	Status    status                 `xml:"STATUS"`
+	Statement *bankStatementResponse `xml:"STMTRS"`
+}
+
+type bankStatementResponse struct {
+	CurDef        string              `xml:"CURDEF"`
+	Account       bankAccount         `xml:"BANKACCTFROM"`
+	Transactions  bankTransactionList `xml:"BANKTRANLIST"`
+	LedgerBalance balance             `xml:"LEDGERBAL"`
+}
+
+type bankAccount struct {
+	BankId   string `xml:"BANKID"`
+	AcctId   string `xml:"ACCTID"`
+	AcctType string `xml:"ACCTTYPE"`
+}
+
+type bankTransactionList struct {
+	DTStart      string            `xml:"DTSTART"`
+	DTEnd        string            `xml:"DTEND"`
+	Transactions []bankTransaction `xml:"STMTTRN"`
+}
+
+type bankTransaction struct {
+	TrnType  string    `xml:"TRNTYPE"`
+	DTPosted string    `xml:"DTPOSTED"`
+	TrnAmt   string    `xml:"TRNAMT"`
+	FITID    string    `xml:"FITID"`
+	Name     string    `xml:"NAME,omitempty"`
+	Memo     string    `xml:"MEMO,omitempty"`
+	Currency *currency `xml:"CURRENCY"`
+}
+
+type balance struct {
+	Amount string `xml:"BALAMT"`
+	DTAsOf string `xml:"DTASOF"`
+}
+
+type investmentMessages struct {
+	Statements []investmentTransactionResponse `xml:"INVSTMTTRNRS"`
+}
+
+type investmentTransactionResponse struct {
+	TrnUID    string                       `xml:"TRNUID"`
+	Status    status                       `xml:"STATUS"`
+	Statement *investmentStatementResponse `xml:"INVSTMTRS"`
+}
+
+type investmentStatementResponse struct {
+	DTAsOf       string                     `xml:"DTASOF"`
+	CurDef       string                     `xml:"CURDEF"`
+	Account      investmentAccount          `xml:"INVACCTFROM"`
+	Transactions *investmentTransactionList `xml:"INVTRANLIST"`
+	Positions    *positionList              `xml:"INVPOSLIST"`
+	Balance      *investmentBalance         `xml:"INVBAL"`
+}
+
+type investmentAccount struct {
+	BrokerId string `xml:"BROKERID"`
+	AcctId   string `xml:"ACCTID"`
+}
+
+type investmentTransactionList struct {
+	DTStart   string                      `xml:"DTSTART"`
+	DTEnd     string                      `xml:"DTEND"`
+	Buys      []buyStock                  `xml:"BUYSTOCK"`
+	Sells     []sellStock                 `xml:"SELLSTOCK"`
+	Income    []income                    `xml:"INCOME"`
+	Transfers []transfer                  `xml:"TRANSFER"`
+	Bank      []investmentBankTransaction `xml:"INVBANKTRAN"`
+}
+
+type investmentTransaction struct {
+	FITID   string `xml:"FITID"`
+	DTTrade string `xml:"DTTRADE"`
+	Memo    string `xml:"MEMO,omitempty"`
+}
+
+type secId struct {
+	UniqueId     string `xml:"UNIQUEID"`
+	UniqueIdType string `xml:"UNIQUEIDTYPE"`
+}
+
+// trade is the INVBUY or INVSELL aggregate of a trade
+type trade struct {
+	Transaction investmentTransaction `xml:"INVTRAN"`
+	SecId       secId                 `xml:"SECID"`
+	Units       string                `xml:"UNITS"`
+	UnitPrice   string                `xml:"UNITPRICE"`
+	Total       string                `xml:"TOTAL"`
+	Currency    *currency             `xml:"CURRENCY"`
+	SubAcctSec  string                `xml:"SUBACCTSEC"`
+	SubAcctFund string                `xml:"SUBACCTFUND"`
+}
+
+type buyStock struct {
+	Buy     trade  `xml:"INVBUY"`
+	BuyType string `xml:"BUYTYPE"`
+}
+
+type sellStock struct {
+	Sell     trade  `xml:"INVSELL"`
+	SellType string `xml:"SELLTYPE"`
+}
+
+type income struct {
+	Transaction investmentTransaction `xml:"INVTRAN"`
+	SecId       secId                 `xml:"SECID"`
+	IncomeType  string                `xml:"INCOMETYPE"`
+	Total       string                `xml:"TOTAL"`
+	SubAcctSec  string                `xml:"SUBACCTSEC"`
+	SubAcctFund string                `xml:"SUBACCTFUND"`
+	Currency    *currency             `xml:"CURRENCY"`
+}
+
+type transfer struct {
+	Transaction investmentTransaction `xml:"INVTRAN"`
+	SecId       secId                 `xml:"SECID"`
+	SubAcctSec  string                `xml:"SUBACCTSEC"`
+	Units       string                `xml:"UNITS"`
+	TnferAction string                `xml:"TNFERACTION"`
+	PosType     string                `xml:"POSTYPE"`
+}
+
+type investmentBankTransaction struct {
+	Transaction bankTransaction `xml:"STMTTRN"`
+	SubAcctFund string          `xml:"SUBACCTFUND"`
+}
+
+type positionList struct {
+	Stocks []stockPosition `xml:"POSSTOCK"`
+}
+
+type stockPosition struct {
+	Position investmentPosition `xml:"INVPOS"`
+}
+
+type investmentPosition struct {
+	SecId       secId     `xml:"SECID"`
+	HeldInAcct  string    `xml:"HELDINACCT"`
+	PosType     string    `xml:"POSTYPE"`
+	Units       string    `xml:"UNITS"`
+	UnitPrice   string    `xml:"UNITPRICE"`
+	MktVal      string    `xml:"MKTVAL"`
+	DTPriceAsOf string    `xml:"DTPRICEASOF"`
+	Currency    *currency `xml:"CURRENCY"`
+}
+
+type investmentBalance struct {
+	AvailCash     string `xml:"AVAILCASH"`
+	MarginBalance string `xml:"MARGINBALANCE"`
+	ShortBalance  string `xml:"SHORTBALANCE"`
+}
+
+type securityListMessages struct {
+	List securityList `xml:"SECLIST"`
+}
+
+type securityList struct {
+	Stocks []stockInfo `xml:"STOCKINFO"`
+}
+
+type stockInfo struct {
+	Info securityInfo `xml:"SECINFO"`
+}
+
+type securityInfo struct {
+	SecId     secId     `xml:"SECID"`
+	SecName   string    `xml:"SECNAME"`
+	Ticker    string    `xml:"TICKER,omitempty"`
+	UnitPrice string    `xml:"UNITPRICE,omitempty"`
+	DTAsOf    string    `xml:"DTASOF,omitempty"`
+	Currency  *currency `xml:"CURRENCY"`
+}
@@ -0,0 +1,502 @@
+// Package ofx writes activities as OFX 2.2 statements, the format
+// personal finance software such as GnuCash, Moneydance and Quicken
+// import. Cash accounts are written as bank statements, other accounts as
+// investment statements along with the list of the securities they trade
+// and hold.
+package ofx
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/samber/lo"
+	"github.com/vpnda/wsfetch/pkg/client"
+	"github.com/vpnda/wsfetch/pkg/client/generated"
+	"github.com/vpnda/wsfetch/pkg/money"
+)
+
+const (
+	// header follows the XML declaration of every OFX 2.x file
+	header = `<?OFX OFXHEADER="200" VERSION="220" SECURITY="NONE" OLDFILEUID="NONE" NEWFILEUID="NONE"?>`
+
+	// org identifies the institution statements come from
+	org = "Wealthsimple"
+	// brokerId identifies the institution in investment statements
+	brokerId = "wealthsimple.com"
+
+	// cashSecurityPrefix starts the security ids of cash balances
+	cashSecurityPrefix = "sec-c-"
+
+	// nameLength is the longest NAME a bank transaction may have
+	nameLength = 32
+)
+
+// ErrNoFITID is returned for activities without a canonical id, which
+// importers need to recognize activities already imported
+var ErrNoFITID = errors.New("activity has no canonical id")
+
+// Rates converts between currencies, the rate of a currency in the
+// currency of a statement is written along with the activities in it
+type Rates interface {
+	Rate(from, to string, at time.Time) (money.Decimal, error)
+}
+
+// Transaction is an activity along with its description
+type Transaction struct {
+	Activity    generated.Activity
+	Description string
+}
+
+// Statement is the activities of an account between two dates
+type Statement struct {
+	Account      generated.AccountWithFinancials
+	Start, End   time.Time
+	Transactions []Transaction
+}
+
+// Options tune how statements are written
+type Options struct {
+	// Now is when the statements are made, balances are as of then
+	Now time.Time
+
+	// Location is the timezone dates are written in, UTC if nil
+	Location *time.Location
+
+	// IntuBid is the Intuit bank id written for Quicken, which
+	// imports QFX files, OFX with this id
+	IntuBid string
+
+	// Rates converts amounts in another currency than the one of
+	// their account
+	Rates Rates
+}
+
+// IsBankAccount tells whether an account is written as a bank statement,
+// cash accounts are, other accounts hold securities
+func IsBankAccount(a *generated.AccountWithFinancials) bool {
+	return strings.HasPrefix(lo.FromPtr(a.AccountCore.Type), "ca_cash") ||
+		strings.HasPrefix(lo.FromPtr(a.UnifiedAccountType), "CASH")
+}
+
+// SecurityIds returns the securities the investment statements trade or
+// hold, the security list is written with their market data
+func SecurityIds(statements []Statement) []string {
+	ids := map[string]struct{}{}
+	for _, s := range statements {
+		if IsBankAccount(&s.Account) {
+			continue
+		}
+		for _, t := range s.Transactions {
+			if id := lo.FromPtr(t.Activity.SecurityId); id != "" && !strings.HasPrefix(id, cashSecurityPrefix) {
+				ids[id] = struct{}{}
+			}
+		}
+		for _, b := range balances(&s.Account) {
+			if !strings.HasPrefix(b.SecurityId, cashSecurityPrefix) {
+				ids[b.SecurityId] = struct{}{}
+			}
+		}
+	}
+	return lo.Keys(ids)
+}
+
+// Write writes the statements as an OFX file, securities being the market
+// data of the securities given by SecurityIds
+func Write(w io.Writer, statements []Statement, securities map[string]*generated.SecurityMarketData, opts Options) error {
+	if opts.Location == nil {
+		opts.Location = time.UTC
+	}
+	e := &encoder{opts: opts, securities: securities, used: map[string]struct{}{}}
+
+	doc := document{
+		SignOn: signOnResponse{
+			Status:   ok,
+			DTServer: e.date(opts.Now),
+			Language: "ENG",
+			FI:       fi{Org: org, FID: org},
+			IntuBid:  opts.IntuBid,
+		},
+	}
+	for i, s := range statements {
+		trnuid := strconv.Itoa(i + 1)
+		if IsBankAccount(&s.Account) {
+			rs, err := e.bankStatement(&s)
+			if err != nil {
+				return fmt.Errorf("account %s: %w", s.Account.Id, err)
+			}
+			bank := doc.bank()
+			bank.Statements = append(bank.Statements, bankTransactionResponse{TrnUID: trnuid, Status: ok, Statement: rs})
+			continue
+		}
+		rs, err := e.investmentStatement(&s)
+		if err != nil {
+			return fmt.Errorf("account %s: %w", s.Account.Id, err)
+		}
+		investment := doc.investment()
+		investment.Statements = append(investment.Statements, investmentTransactionResponse{TrnUID: trnuid, Status: ok, Statement: rs})
+	}
+	if len(e.used) > 0 {
+		doc.SecurityList = &securityListMessages{List: e.securityList()}
+	}
+
+	if _, err := io.WriteString(w, xml.Header+header+"\n"); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// encoder converts activities to OFX aggregates, remembering the
+// securities they refer to
+type encoder struct {
+	opts       Options
+	securities map[string]*generated.SecurityMarketData
+	used       map[string]struct{}
+
+	// listCurrency is the currency prices of the security list are
+	// written in, the one of the first investment statement
+	listCurrency string
+}
+
+// date formats a time the OFX way, 20240502103000.000[-4:EDT]
+func (e *encoder) date(t time.Time) string {
+	t = t.In(e.opts.Location)
+	name, offset := t.Zone()
+	hours := strconv.FormatFloat(float64(offset)/3600, 'f', -1, 64)
+	return fmt.Sprintf("%s.%03d[%s:%s]", t.Format("20060102150405"), t.Nanosecond()/int(time.Millisecond), hours, name)
+}
+
+// currency returns the currency aggregate of amounts in cur made at the
+// given time, nil when cur is the currency of the statement
+func (e *encoder) currency(cur, curdef string, at time.Time) (*currency, error) {
+	if cur == "" || cur == curdef {
+		return nil, nil
+	}
+	if e.opts.Rates == nil {
+		return nil, fmt.Errorf("no rate from %s to %s", cur, curdef)
+	}
+	rate, err := e.opts.Rates.Rate(cur, curdef, at)
+	if err != nil {
+		return nil, err
+	}
+	return &currency{Rate: rate.Round(6, money.HalfEven).Trim().String(), Symbol: cur}, nil
+}
+
+// activityCurrency returns the currency aggregate of an activity
+func (e *encoder) activityCurrency(act *generated.Activity, curdef string) (*currency, error) {
+	cur, err := e.currency(lo.FromPtr(act.Currency), curdef, lo.FromPtr(act.OccurredAt))
+	if err != nil {
+		return nil, fmt.Errorf("activity %s: %w", lo.FromPtr(act.CanonicalId), err)
+	}
+	return cur, nil
+}
+
+func (e *encoder) bankStatement(s *Statement) (*bankStatementResponse, error) {
+	curdef := accountCurrency(&s.Account)
+	rs := &bankStatementResponse{
+		CurDef: curdef,
+		Account: bankAccount{
+			BankId:   org,
+			AcctId:   s.Account.Id,
+			AcctType: "CHECKING",
+		},
+		Transactions: bankTransactionList{DTStart: e.date(s.Start), DTEnd: e.date(s.End)},
+		LedgerBalance: balance{
+			Amount: netLiquidationValue(&s.Account).String(),
+			DTAsOf: e.date(e.opts.Now),
+		},
+	}
+	for _, t := range s.Transactions {
+		trn, err := e.bankTransaction(&t, curdef)
+		if err != nil {
+			return nil, err
+		}
+		rs.Transactions.Transactions = append(rs.Transactions.Transactions, *trn)
+	}
+	return rs, nil
+}
+
+func (e *encoder) bankTransaction(t *Transaction, curdef string) (*bankTransaction, error) {
+	act := &t.Activity
+	fitid := lo.FromPtr(act.CanonicalId)
+	if fitid == "" {
+		return nil, ErrNoFITID
+	}
+	cur, err := e.activityCurrency(act, curdef)
+	if err != nil {
+		return nil, err
+	}
+	flow := client.CashFlow(act)
+	return &bankTransaction{
+		TrnType:  bankTransactionType(act, flow),
+		DTPosted: e.date(lo.FromPtr(act.OccurredAt)),
+		TrnAmt:   flow.Amount.Amount.String(),
+		FITID:    fitid,
+		Name:     truncate(t.Description, nameLength),
+		Memo:     t.Description,
+		Currency: cur,
+	}, nil
+}
+
+func (e *encoder) investmentStatement(s *Statement) (*investmentStatementResponse, error) {
+	curdef := accountCurrency(&s.Account)
+	rs := &investmentStatementResponse{
+		DTAsOf:       e.date(e.opts.Now),
+		CurDef:       curdef,
+		Account:      investmentAccount{BrokerId: brokerId, AcctId: s.Account.Id},
+		Transactions: &investmentTransactionList{DTStart: e.date(s.Start), DTEnd: e.date(s.End)},
+	}
+	if e.listCurrency == "" {
+		e.listCurrency = curdef
+	}
+	for _, t := range s.Transactions {
+		if err := e.investmentTransaction(rs.Transactions, &t, curdef); err != nil {
+			return nil, err
+		}
+	}
+
+	var cash money.Decimal
+	for _, b := range balances(&s.Account) {
+		if strings.HasPrefix(b.SecurityId, cashSecurityPrefix) {
+			if strings.EqualFold(strings.TrimPrefix(b.SecurityId, cashSecurityPrefix), curdef) {
+				cash = cash.Add(b.Quantity)
+			}
+			continue
+		}
+		if rs.Positions == nil {
+			rs.Positions = &positionList{}
+		}
+		pos, err := e.position(b, curdef)
+		if err != nil {
+			return nil, err
+		}
+		rs.Positions.Stocks = append(rs.Positions.Stocks, pos)
+	}
+	rs.Balance = &investmentBalance{AvailCash: cash.String(), MarginBalance: "0", ShortBalance: "0"}
+	return rs, nil
+}
+
+func (e *encoder) investmentTransaction(list *investmentTransactionList, t *Transaction, curdef string) error {
+	act := &t.Activity
+	fitid := lo.FromPtr(act.CanonicalId)
+	if fitid == "" {
+		return ErrNoFITID
+	}
+	cur, err := e.activityCurrency(act, curdef)
+	if err != nil {
+		return err
+	}
+	tran := investmentTransaction{
+		FITID:   fitid,
+		DTTrade: e.date(lo.FromPtr(act.OccurredAt)),
+		Memo:    t.Description,
+	}
+	securityId := lo.FromPtr(act.SecurityId)
+	units := act.AssetQuantity.Abs()
+	flow := client.CashFlow(act)
+
+	hasSecurity := securityId != "" && !strings.HasPrefix(securityId, cashSecurityPrefix)
+	switch {
+	case hasSecurity && !units.IsZero() && isBuy(act):
+		list.Buys = append(list.Buys, buyStock{
+			Buy:     e.trade(tran, securityId, units, flow.Amount.Amount, cur),
+			BuyType: "BUY",
+		})
+	case hasSecurity && !units.IsZero() && isSell(act):
+		list.Sells = append(list.Sells, sellStock{
+			Sell:     e.trade(tran, securityId, units.Neg(), flow.Amount.Amount, cur),
+			SellType: "SELL",
+		})
+	case hasSecurity && act.Type == generated.ActivityTypeDividend:
+		list.Income = append(list.Income, income{
+			Transaction: tran,
+			SecId:       e.secId(securityId),
+			IncomeType:  "DIV",
+			Total:       flow.Amount.Amount.String(),
+			SubAcctSec:  "CASH",
+			SubAcctFund: "CASH",
+			Currency:    cur,
+		})
+	case hasSecurity && flow.Direction == client.NoCashFlow:
+		action := "IN"
+		if act.AmountSign == generated.AmountSignNegative {
+			action = "OUT"
+		}
+		list.Transfers = append(list.Transfers, transfer{
+			Transaction: tran,
+			SecId:       e.secId(securityId),
+			SubAcctSec:  "CASH",
+			Units:       units.String(),
+			TnferAction: action,
+			PosType:     "LONG",
+		})
+	default:
+		bank, err := e.bankTransaction(t, curdef)
+		if err != nil {
+			return err
+		}
+		list.Bank = append(list.Bank, investmentBankTransaction{Transaction: *bank, SubAcctFund: "CASH"})
+	}
+	return nil
+}
+
+// trade returns the aggregate of a buy or sell of units, total being the
+// cash the trade brought in, negative for buys
+func (e *encoder) trade(tran investmentTransaction, securityId string, units, total money.Decimal, cur *currency) trade {
+	price, _ := total.Abs().Div(units.Abs(), 6, money.HalfEven)
+	return trade{
+		Transaction: tran,
+		SecId:       e.secId(securityId),
+		Units:       units.String(),
+		UnitPrice:   price.Trim().String(),
+		Total:       total.String(),
+		Currency:    cur,
+		SubAcctSec:  "CASH",
+		SubAcctFund: "CASH",
+	}
+}
+
+// position returns the position of a balance, priced at the last quote
+// of the security when it is known
+func (e *encoder) position(b generated.AccountFinancialsCustodianAccountsCustodianAccountFinancialsBalance, curdef string) (stockPosition, error) {
+	pos := investmentPosition{
+		SecId:       e.secId(b.SecurityId),
+		HeldInAcct:  "CASH",
+		PosType:     "LONG",
+		Units:       b.Quantity.String(),
+		UnitPrice:   "0",
+		MktVal:      "0",
+		DTPriceAsOf: e.date(e.opts.Now),
+	}
+	if data := e.securities[b.SecurityId]; data != nil && data.Quote != nil {
+		pos.UnitPrice = data.Quote.Last.String()
+		pos.MktVal = b.Quantity.Mul(data.Quote.Last).Round(2, money.HalfEven).String()
+		if data.Quote.QuotedAsOf != nil {
+			pos.DTPriceAsOf = e.date(*data.Quote.QuotedAsOf)
+		}
+		if data.Fundamentals != nil {
+			cur, err := e.currency(data.Fundamentals.Currency, curdef, e.opts.Now)
+			if err != nil {
+				return stockPosition{}, fmt.Errorf("security %s: %w", b.SecurityId, err)
+			}
+			pos.Currency = cur
+		}
+	}
+	return stockPosition{Position: pos}, nil
+}
+
+// secId returns the identifier of a security, which is listed in the
+// security list
+func (e *encoder) secId(securityId string) secId {
+	e.used[securityId] = struct{}{}
+	return secId{UniqueId: securityId, UniqueIdType: org}
+}
+
+func (e *encoder) securityList() securityList {
+	ids := lo.Keys(e.used)
+	sort.Strings(ids)
+	var list securityList
+	for _, id := range ids {
+		info := securityInfo{SecId: secId{UniqueId: id, UniqueIdType: org}, SecName: id}
+		if data := e.securities[id]; data != nil {
+			if data.Stock != nil {
+				info.Ticker = data.Stock.Symbol
+				if name := lo.FromPtr(data.Stock.Name); name != "" {
+					info.SecName = name
+				}
+			}
+			// prices in another currency are only written along
+			// with its rate
+			var cur *currency
+			var err error
+			if data.Fundamentals != nil {
+				cur, err = e.currency(data.Fundamentals.Currency, e.listCurrency, e.opts.Now)
+			}
+			if q := data.Quote; q != nil && err == nil {
+				info.UnitPrice = q.Last.String()
+				info.Currency = cur
+				if q.QuotedAsOf != nil {
+					info.DTAsOf = e.date(*q.QuotedAsOf)
+				}
+			}
+		}
+		list.Stocks = append(list.Stocks, stockInfo{Info: info})
+	}
+	return list
+}
+
+func isBuy(act *generated.Activity) bool {
+	return act.Type == generated.ActivityTypeDiyBuy || act.Type == generated.ActivityTypeManagedBuy
+}
+
+func isSell(act *generated.Activity) bool {
+	return act.Type == generated.ActivityTypeDiySell || act.Type == generated.ActivityTypeManagedSell
+}
+
+// bankTransactionType returns the TRNTYPE of an activity, CREDIT or DEBIT
+// when no more specific type applies
+func bankTransactionType(act *generated.Activity, flow client.Flow) string {
+	switch act.Type {
+	case generated.ActivityTypeDeposit:
+		return "DEP"
+	case generated.ActivityTypeInterest:
+		return "INT"
+	case generated.ActivityTypeDividend:
+		return "DIV"
+	case generated.ActivityTypeInternalTransfer,
+		generated.ActivityTypeInstitutionalTransferIntent,
+		generated.ActivityTypeFundsConversion:
+		return "XFER"
+	case generated.ActivityTypeP2pPayment:
+		if flow.Direction == client.Outflow {
+			return "PAYMENT"
+		}
+	}
+	if flow.Direction == client.Outflow {
+		return "DEBIT"
+	}
+	return "CREDIT"
+}
+
+func accountCurrency(a *generated.AccountWithFinancials) string {
+	if value := a.Financials.CurrentCombined; value != nil && value.NetLiquidationValueV2 != nil {
+		return value.NetLiquidationValueV2.Currency
+	}
+	return lo.FromPtr(a.Currency)
+}
+
+func netLiquidationValue(a *generated.AccountWithFinancials) money.Decimal {
+	if value := a.Financials.CurrentCombined; value != nil && value.NetLiquidationValueV2 != nil {
+		return value.NetLiquidationValueV2.Amount
+	}
+	return money.Zero
+}
+
+func balances(a *generated.AccountWithFinancials) []generated.AccountFinancialsCustodianAccountsCustodianAccountFinancialsBalance {
+	var all []generated.AccountFinancialsCustodianAccountsCustodianAccountFinancialsBalance
+	for _, custodian := range a.AccountFinancials.CustodianAccounts {
+		if custodian.Financials != nil {
+			all = append(all, custodian.Financials.Balance...)
+		}
+	}
+	return all
+}
+
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n])
+}
@@ -0,0 +1,168 @@
+package ofx
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/samber/lo"
+	"github.com/vpnda/wsfetch/pkg/client/generated"
+	"github.com/vpnda/wsfetch/pkg/fx"
+	"github.com/vpnda/wsfetch/pkg/money"
+	"github.com/vpnda/wsfetch/pkg/wstest"
+)
+
+var now = time.Date(2024, 5, 10, 16, 0, 0, 0, time.UTC)
+
+// statements returns the fixture RRSP, retyped as a cash account, and
+// TFSA, whose AAPL buy is in USD, along with a sale made from a copy of
+// the buy
+func statements() []Statement {
+	f := wstest.DefaultFixtures()
+	cash := f.Account("rrsp-def456")
+	cash.Type = lo.ToPtr("ca_cash")
+	cash.UnifiedAccountType = lo.ToPtr("CASH")
+	tfsa := f.Account("tfsa-abc123")
+	tfsa.CustodianAccounts[0].Financials.Balance = append(tfsa.CustodianAccounts[0].Financials.Balance,
+		generated.AccountFinancialsCustodianAccountsCustodianAccountFinancialsBalance{SecurityId: "sec-c-usd", Quantity: money.MustParse("10")})
+
+	buy := f.Activity("act-1")
+	buy.Currency = lo.ToPtr("USD")
+	sell := f.Activity("act-1")
+	sell.CanonicalId = lo.ToPtr("sell-1")
+	sell.Type = generated.ActivityTypeDiySell
+	sell.AmountSign = generated.AmountSignPositive
+	sell.Amount = money.MustParse("160.00")
+	sell.SecurityId = lo.ToPtr("sec-s-vfv")
+	sell.AssetQuantity = money.MustParse("1.5")
+	sell.OccurredAt = lo.ToPtr(time.Date(2024, 5, 3, 14, 30, 0, 0, time.UTC))
+
+	start := time.Date(2024, 4, 26, 0, 0, 0, 0, time.UTC)
+	return []Statement{
+		{
+			Account: cash,
+			Start:   start, End: now,
+			Transactions: []Transaction{
+				{Activity: f.Activity("act-7"), Description: "Cash sent to $friend"},
+				{Activity: f.Activity("act-4"), Description: "Deposit: Interac e-transfer from Jane Doe"},
+			},
+		},
+		{
+			Account: tfsa,
+			Start:   start, End: now,
+			Transactions: []Transaction{
+				{Activity: buy, Description: "Market Order: buy 2 x NASDAQ:AAPL @ 150.75"},
+				{Activity: sell, Description: "Market Order: sell 1.5 x TSX:VFV"},
+				{Activity: f.Activity("act-2"), Description: "Dividend: VFV"},
+				{Activity: f.Activity("act-6"), Description: "Interest"},
+			},
+		},
+	}
+}
+
+// securities are the fixture securities by ID
+func securities() map[string]*generated.SecurityMarketData {
+	return lo.SliceToMap(wstest.DefaultFixtures().Securities, func(s generated.SecurityMarketData) (string, *generated.SecurityMarketData) {
+		return s.Id, &s
+	})
+}
+
+func Test_Write(t *testing.T) {
+	g := NewWithT(t)
+	rates := fx.NewBook(fx.Rate{From: "USD", To: "CAD", Value: money.MustParse("1.365"), At: now})
+
+	var buf bytes.Buffer
+	g.Expect(Write(&buf, statements(), securities(), Options{Now: now, Rates: rates, IntuBid: "12345"})).To(Succeed())
+	g.Expect(buf.String()).To(HavePrefix(xml.Header + header + "\n<OFX>\n"))
+
+	var doc document
+	g.Expect(xml.Unmarshal(buf.Bytes(), &doc)).To(Succeed())
+	g.Expect(doc.SignOn.DTServer).To(Equal("20240510160000.000[0:UTC]"))
+	g.Expect(doc.SignOn.IntuBid).To(Equal("12345"))
+
+	g.Expect(doc.Bank.Statements).To(HaveLen(1))
+	bank := doc.Bank.Statements[0].Statement
+	g.Expect(bank.Account).To(Equal(bankAccount{BankId: "Wealthsimple", AcctId: "rrsp-def456", AcctType: "CHECKING"}))
+	g.Expect(bank.LedgerBalance.Amount).To(Equal("5400.00"))
+	g.Expect(bank.Transactions.Transactions).To(Equal([]bankTransaction{
+		{TrnType: "PAYMENT", DTPosted: "20240426090000.000[0:UTC]", TrnAmt: "-20.00", FITID: "act-7",
+			Name: "Cash sent to $friend", Memo: "Cash sent to $friend"},
+		{TrnType: "DEP", DTPosted: "20240429090000.000[0:UTC]", TrnAmt: "1000.00", FITID: "act-4",
+			Name: "Deposit: Interac e-transfer from", Memo: "Deposit: Interac e-transfer from Jane Doe"},
+	}))
+
+	g.Expect(doc.Investment.Statements).To(HaveLen(1))
+	inv := doc.Investment.Statements[0].Statement
+	g.Expect(inv.Account).To(Equal(investmentAccount{BrokerId: "wealthsimple.com", AcctId: "tfsa-abc123"}))
+	g.Expect(inv.Transactions.Buys).To(Equal([]buyStock{{
+		Buy: trade{
+			Transaction: investmentTransaction{FITID: "act-1", DTTrade: "20240502143000.000[0:UTC]", Memo: "Market Order: buy 2 x NASDAQ:AAPL @ 150.75"},
+			SecId:       secId{UniqueId: "sec-s-aapl", UniqueIdType: "Wealthsimple"},
+			Units:       "2", UnitPrice: "150.75", Total: "-301.50",
+			Currency:   &currency{Rate: "1.365", Symbol: "USD"},
+			SubAcctSec: "CASH", SubAcctFund: "CASH",
+		},
+		BuyType: "BUY",
+	}}))
+	g.Expect(inv.Transactions.Sells).To(HaveLen(1))
+	g.Expect(inv.Transactions.Sells[0].Sell.Units).To(Equal("-1.5"))
+	g.Expect(inv.Transactions.Sells[0].Sell.UnitPrice).To(Equal("106.666667"))
+	g.Expect(inv.Transactions.Sells[0].Sell.Total).To(Equal("160.00"))
+	g.Expect(inv.Transactions.Income).To(HaveLen(1))
+	g.Expect(inv.Transactions.Income[0].IncomeType).To(Equal("DIV"))
+	g.Expect(inv.Transactions.Income[0].Total).To(Equal("12.34"))
+	g.Expect(inv.Transactions.Bank).To(HaveLen(1))
+	g.Expect(inv.Transactions.Bank[0].Transaction.TrnType).To(Equal("INT"))
+
+	g.Expect(inv.Positions.Stocks).To(HaveLen(1))
+	g.Expect(inv.Positions.Stocks[0].Position.MktVal).To(Equal("301.50"))
+	g.Expect(inv.Positions.Stocks[0].Position.Currency).To(Equal(&currency{Rate: "1.365", Symbol: "USD"}))
+	g.Expect(inv.Balance.AvailCash).To(Equal("9949.25"))
+
+	// every security traded or held is listed, with its market data
+	// when it is known
+	g.Expect(doc.SecurityList.List.Stocks).To(Equal([]stockInfo{
+		{Info: securityInfo{
+			SecId:   secId{UniqueId: "sec-s-aapl", UniqueIdType: "Wealthsimple"},
+			SecName: "Apple Inc", Ticker: "AAPL", UnitPrice: "150.75",
+			Currency: &currency{Rate: "1.365", Symbol: "USD"},
+		}},
+		{Info: securityInfo{
+			SecId:   secId{UniqueId: "sec-s-vfv", UniqueIdType: "Wealthsimple"},
+			SecName: "sec-s-vfv",
+		}},
+	}))
+}
+
+func Test_Write_Errors(t *testing.T) {
+	g := NewWithT(t)
+
+	// amounts in another currency need a rate
+	err := Write(&bytes.Buffer{}, statements(), securities(), Options{Now: now, Rates: fx.NewBook()})
+	g.Expect(err).To(MatchError(fx.ErrNoRate))
+	g.Expect(err.Error()).To(ContainSubstring("act-1"))
+
+	noId := statements()[:1]
+	noId[0].Transactions[0].Activity.CanonicalId = nil
+	err = Write(&bytes.Buffer{}, noId, nil, Options{Now: now})
+	g.Expect(err).To(MatchError(ErrNoFITID))
+}
+
+func Test_SecurityIds(t *testing.T) {
+	g := NewWithT(t)
+	g.Expect(SecurityIds(statements())).To(ConsistOf("sec-s-aapl", "sec-s-vfv"))
+}
+
+func Test_Date(t *testing.T) {
+	g := NewWithT(t)
+	at := time.Date(2024, 5, 2, 14, 30, 0, 250*int(time.Millisecond), time.UTC)
+
+	e := &encoder{opts: Options{Location: time.FixedZone("EDT", -4*3600)}}
+	g.Expect(e.date(at)).To(Equal("20240502103000.250[-4:EDT]"))
+
+	e = &encoder{opts: Options{Location: time.FixedZone("NDT", -(2*3600 + 1800))}}
+	g.Expect(strings.HasSuffix(e.date(at), "[-2.5:NDT]")).To(BeTrue())
+}
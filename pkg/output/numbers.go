@@ -0,0 +1,60 @@
+package output
+
+import (
+	"strings"
+
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"golang.org/x/text/number"
+)
+
+// numberFormat holds the separators of a locale. Decimals are formatted
+// from their exact string form, x/text only formatting floats.
+type numberFormat struct {
+	group   string
+	decimal string
+}
+
+// newNumberFormat finds the separators of a locale by formatting a
+// sample number, und keeping numbers as they are
+func newNumberFormat(tag language.Tag) numberFormat {
+	if tag == language.Und {
+		return numberFormat{}
+	}
+	sample := message.NewPrinter(tag).Sprint(number.Decimal(1234.5, number.Scale(1)))
+	group, rest, ok := strings.Cut(strings.TrimPrefix(sample, "1"), "234")
+	if !ok {
+		return numberFormat{}
+	}
+	decimal, _, ok := strings.Cut(rest, "5")
+	if !ok {
+		return numberFormat{}
+	}
+	return numberFormat{group: group, decimal: decimal}
+}
+
+// format formats a number such as -1234.50
+func (f numberFormat) format(s string) string {
+	if f.decimal == "" {
+		return s
+	}
+	sign := ""
+	if strings.HasPrefix(s, "-") {
+		sign, s = "-", s[1:]
+	}
+	integer, fraction, hasFraction := strings.Cut(s, ".")
+
+	var b strings.Builder
+	b.WriteString(sign)
+	for i, digit := range integer {
+		if i > 0 && (len(integer)-i)%3 == 0 {
+			b.WriteString(f.group)
+		}
+		b.WriteRune(digit)
+	}
+	if hasFraction {
+		b.WriteString(f.decimal)
+		b.WriteString(fraction)
+	}
+	return b.String()
+}
@@ -0,0 +1,251 @@
+// Package output renders the records printed by wsfetch as a table or in
+// a machine-readable format. Every format shares the field names of the
+// record json tags, so a column of a CSV file, a JSON key and a YAML key
+// always carry the same name.
+package output
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/vpnda/wsfetch/pkg/money"
+	"golang.org/x/text/language"
+	"gopkg.in/yaml.v3"
+)
+
+// Format is a way of rendering records
+type Format string
+
+const (
+	// Table aligns a subset of the fields in columns for humans
+	Table Format = "table"
+	// JSON writes records as a JSON array
+	JSON Format = "json"
+	// NDJSON writes one JSON object per line
+	NDJSON Format = "ndjson"
+	// CSV writes a header row followed by one row per record
+	CSV Format = "csv"
+	// YAML writes records as a YAML sequence
+	YAML Format = "yaml"
+)
+
+// Formats lists the supported formats
+var Formats = []Format{Table, JSON, NDJSON, CSV, YAML}
+
+// ParseFormat reads an output format
+func ParseFormat(s string) (Format, error) {
+	for _, f := range Formats {
+		if Format(strings.ToLower(s)) == f {
+			return f, nil
+		}
+	}
+	return "", fmt.Errorf("invalid output format %q, expected one of %s", s, strings.Join(FormatNames(), ", "))
+}
+
+// FormatNames lists the names of the supported formats
+func FormatNames() []string {
+	names := make([]string, len(Formats))
+	for i, f := range Formats {
+		names[i] = string(f)
+	}
+	return names
+}
+
+// Options tune how records are rendered
+type Options struct {
+	// Location times are printed in, times are left in their own
+	// location when nil
+	Location *time.Location
+
+	// Locale numbers are printed in by tables, machine-readable
+	// formats always write them with a dot and no grouping
+	Locale language.Tag
+}
+
+// Write renders records in the given format. Records are structs whose
+// exported fields carry a json tag naming them, fields with a table tag
+// are the columns of the table format, in their order of declaration.
+func Write[T any](w io.Writer, format Format, records []T) error {
+	return Render(w, format, Options{}, records)
+}
+
+// Render renders records in the given format like Write, with options
+func Render[T any](w io.Writer, format Format, opts Options, records []T) error {
+	if records == nil {
+		records = []T{}
+	}
+	if opts.Location != nil {
+		records = inLocation(records, opts.Location)
+	}
+	switch format {
+	case Table:
+		return writeTable(w, records, newNumberFormat(opts.Locale))
+	case JSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(records)
+	case NDJSON:
+		enc := json.NewEncoder(w)
+		for _, r := range records {
+			if err := enc.Encode(r); err != nil {
+				return err
+			}
+		}
+		return nil
+	case CSV:
+		return writeCSV(w, records)
+	case YAML:
+		return writeYAML(w, records)
+	}
+	return fmt.Errorf("invalid output format %q", format)
+}
+
+type column struct {
+	index int
+	name  string
+}
+
+// columns returns the fields of records named by the given tag
+func columns(t reflect.Type, tag string) []column {
+	var cols []column
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get(tag), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		cols = append(cols, column{index: i, name: name})
+	}
+	return cols
+}
+
+func writeTable[T any](w io.Writer, records []T, numbers numberFormat) error {
+	cols := columns(reflect.TypeFor[T](), "table")
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	header := make([]string, len(cols))
+	for i, c := range cols {
+		header[i] = c.name
+	}
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+	for _, r := range records {
+		v := reflect.ValueOf(r)
+		row := make([]string, len(cols))
+		for i, c := range cols {
+			row[i] = formatValue(v.Field(c.index), time.DateOnly, numbers)
+		}
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	return tw.Flush()
+}
+
+func writeCSV[T any](w io.Writer, records []T) error {
+	cols := columns(reflect.TypeFor[T](), "json")
+	cw := csv.NewWriter(w)
+	header := make([]string, len(cols))
+	for i, c := range cols {
+		header[i] = c.name
+	}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	for _, r := range records {
+		v := reflect.ValueOf(r)
+		row := make([]string, len(cols))
+		for i, c := range cols {
+			row[i] = formatValue(v.Field(c.index), time.RFC3339, numberFormat{})
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// formatValue formats a field for tables and CSV files, nil pointers
+// being left empty
+func formatValue(v reflect.Value, timeLayout string, numbers numberFormat) string {
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return ""
+		}
+		v = v.Elem()
+	}
+	switch x := v.Interface().(type) {
+	case time.Time:
+		if x.IsZero() {
+			return ""
+		}
+		return x.Format(timeLayout)
+	case money.Decimal:
+		return numbers.format(x.String())
+	case fmt.Stringer:
+		return x.String()
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+// writeYAML goes through JSON so YAML keys and values are the ones of
+// the JSON format
+func writeYAML[T any](w io.Writer, records []T) error {
+	b, err := json.Marshal(records)
+	if err != nil {
+		return err
+	}
+	var node yaml.Node
+	if err := yaml.NewDecoder(bytes.NewReader(b)).Decode(&node); err != nil {
+		return err
+	}
+	blockStyle(&node)
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(&node); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// blockStyle drops the flow style and quotes inherited from JSON, values
+// that would read as another type keep their quotes
+func blockStyle(n *yaml.Node) {
+	n.Style = 0
+	for _, c := range n.Content {
+		blockStyle(c)
+	}
+}
+
+var (
+	timeType    = reflect.TypeFor[time.Time]()
+	timePtrType = reflect.TypeFor[*time.Time]()
+)
+
+// inLocation returns copies of records whose times are in loc
+func inLocation[T any](records []T, loc *time.Location) []T {
+	t := reflect.TypeFor[T]()
+	if t.Kind() != reflect.Struct {
+		return records
+	}
+	converted := make([]T, len(records))
+	for i, r := range records {
+		v := reflect.ValueOf(&converted[i]).Elem()
+		v.Set(reflect.ValueOf(r))
+		for j := 0; j < t.NumField(); j++ {
+			f := v.Field(j)
+			switch {
+			case !f.CanSet():
+			case f.Type() == timeType:
+				f.Set(reflect.ValueOf(f.Interface().(time.Time).In(loc)))
+			case f.Type() == timePtrType && !f.IsNil():
+				in := f.Interface().(*time.Time).In(loc)
+				f.Set(reflect.ValueOf(&in))
+			}
+		}
+	}
+	return converted
+}
@@ -0,0 +1,182 @@
+package output
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/samber/lo"
+	"github.com/vpnda/wsfetch/pkg/client/generated"
+	"github.com/vpnda/wsfetch/pkg/fx"
+	"github.com/vpnda/wsfetch/pkg/money"
+	"golang.org/x/text/language"
+)
+
+type record struct {
+	Name   string         `json:"name" table:"NAME"`
+	At     time.Time      `json:"at" table:"DATE"`
+	Amount money.Decimal  `json:"amount" table:"AMOUNT"`
+	Price  *money.Decimal `json:"price"`
+}
+
+var records = []record{
+	{Name: "first", At: time.Date(2024, 5, 2, 14, 30, 0, 0, time.UTC), Amount: money.MustParse("-301.50"), Price: lo.ToPtr(money.MustParse("150.75"))},
+	{Name: "second, with a comma", At: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC), Amount: money.MustParse("12.34")},
+}
+
+func Test_Write(t *testing.T) {
+	testCases := []struct {
+		format   Format
+		expected string
+	}{
+		{
+			format: Table,
+			expected: "NAME                  DATE        AMOUNT\n" +
+				"first                 2024-05-02  -301.50\n" +
+				"second, with a comma  2024-05-01  12.34\n",
+		},
+		{
+			format: JSON,
+			expected: `[
+  {
+    "name": "first",
+    "at": "2024-05-02T14:30:00Z",
+    "amount": "-301.50",
+    "price": "150.75"
+  },
+  {
+    "name": "second, with a comma",
+    "at": "2024-05-01T10:00:00Z",
+    "amount": "12.34",
+    "price": null
+  }
+]
+`,
+		},
+		{
+			format: NDJSON,
+			expected: `{"name":"first","at":"2024-05-02T14:30:00Z","amount":"-301.50","price":"150.75"}
+{"name":"second, with a comma","at":"2024-05-01T10:00:00Z","amount":"12.34","price":null}
+`,
+		},
+		{
+			format: CSV,
+			expected: "name,at,amount,price\n" +
+				"first,2024-05-02T14:30:00Z,-301.50,150.75\n" +
+				"\"second, with a comma\",2024-05-01T10:00:00Z,12.34,\n",
+		},
+		{
+			format: YAML,
+			expected: `- name: first
+  at: "2024-05-02T14:30:00Z"
+  amount: "-301.50"
+  price: "150.75"
+- name: second, with a comma
+  at: "2024-05-01T10:00:00Z"
+  amount: "12.34"
+  price: null
+`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(string(tc.format), func(t *testing.T) {
+			g := NewWithT(t)
+			var buf bytes.Buffer
+			g.Expect(Write(&buf, tc.format, records)).To(Succeed())
+			g.Expect(buf.String()).To(Equal(tc.expected))
+		})
+	}
+}
+
+func Test_Write_NoRecords(t *testing.T) {
+	g := NewWithT(t)
+
+	var buf bytes.Buffer
+	g.Expect(Write[record](&buf, JSON, nil)).To(Succeed())
+	g.Expect(buf.String()).To(Equal("[]\n"))
+
+	buf.Reset()
+	g.Expect(Write[record](&buf, CSV, nil)).To(Succeed())
+	g.Expect(buf.String()).To(Equal("name,at,amount,price\n"))
+}
+
+func Test_ParseFormat(t *testing.T) {
+	g := NewWithT(t)
+
+	f, err := ParseFormat("NDJSON")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(f).To(Equal(NDJSON))
+
+	_, err = ParseFormat("xml")
+	g.Expect(err).To(HaveOccurred())
+}
+
+func Test_NewPosition(t *testing.T) {
+	g := NewWithT(t)
+
+	cash := NewPosition("default", "tfsa-abc123", money.MustParse("9949.25"), "sec-c-cad", nil)
+	g.Expect(cash.Symbol).To(Equal("CAD"))
+	g.Expect(cash.Currency).To(Equal("CAD"))
+	g.Expect(cash.MarketValue.String()).To(Equal("9949.25"))
+
+	data := &generated.SecurityMarketData{
+		Id:           "sec-s-aapl",
+		Fundamentals: &generated.SecurityMarketDataFundamentals{Currency: "USD"},
+		Quote:        &generated.SecurityMarketDataQuote{Last: money.MustParse("150.75")},
+		Stock:        &generated.SecurityMarketDataStock{Symbol: "AAPL", PrimaryExchange: lo.ToPtr("NASDAQ")},
+	}
+	stock := NewPosition("default", "tfsa-abc123", money.MustParse("3"), "sec-s-aapl", data)
+	g.Expect(stock.Symbol).To(Equal("NASDAQ:AAPL"))
+	g.Expect(stock.Currency).To(Equal("USD"))
+	g.Expect(stock.Price.String()).To(Equal("150.75"))
+	g.Expect(stock.MarketValue.String()).To(Equal("452.25"))
+
+	unquoted := NewPosition("default", "tfsa-abc123", money.MustParse("3"), "sec-s-unknown", nil)
+	g.Expect(unquoted.Symbol).To(Equal("sec-s-unknown"))
+	g.Expect(unquoted.MarketValue).To(BeNil())
+}
+
+func Test_Render_LocationAndLocale(t *testing.T) {
+	g := NewWithT(t)
+	opts := Options{Location: time.FixedZone("EST", -5*3600), Locale: language.MustParse("fr-CA")}
+	big := []record{{Name: "big", At: time.Date(2024, 5, 2, 2, 0, 0, 0, time.UTC), Amount: money.MustParse("-1234567.891")}}
+
+	var buf bytes.Buffer
+	g.Expect(Render(&buf, Table, opts, big)).To(Succeed())
+	g.Expect(buf.String()).To(Equal("NAME  DATE        AMOUNT\n" +
+		"big   2024-05-01  -1 234 567,891\n"))
+
+	// machine-readable formats keep plain numbers
+	buf.Reset()
+	g.Expect(Render(&buf, CSV, opts, big)).To(Succeed())
+	g.Expect(buf.String()).To(Equal("name,at,amount,price\n" +
+		"big,2024-05-01T21:00:00-05:00,-1234567.891,\n"))
+}
+
+func Test_Convert(t *testing.T) {
+	g := NewWithT(t)
+	at := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	rate, err := fx.ParseRate("USD/CAD=1.3650", at)
+	g.Expect(err).ToNot(HaveOccurred())
+	rates := fx.NewBook(rate)
+
+	usd := Account{Id: "tfsa-abc123", NetLiquidationValue: money.MustParse("100.00"), Currency: "USD"}
+	g.Expect(usd.Convert(rates, "CAD", at)).To(Succeed())
+	g.Expect(usd.ConvertedValue.String()).To(Equal("136.50"))
+	g.Expect(usd.ConvertedCurrency).To(Equal("CAD"))
+
+	cad := Account{Id: "rrsp-def456", NetLiquidationValue: money.MustParse("5400.00"), Currency: "CAD"}
+	g.Expect(cad.Convert(rates, "CAD", at)).To(Succeed())
+	g.Expect(cad.ConvertedValue).To(BeNil())
+
+	eur := Account{Id: "tfsa-abc123", NetLiquidationValue: money.MustParse("100.00"), Currency: "EUR"}
+	g.Expect(eur.Convert(rates, "CAD", at)).ToNot(Succeed())
+	g.Expect(eur.ConvertedValue).To(BeNil())
+
+	act := Activity{Id: "act-1", OccurredAt: at, Amount: money.MustParse("-20.00"), Currency: "USD"}
+	g.Expect(act.Convert(rates, "CAD")).To(Succeed())
+	g.Expect(act.ConvertedAmount.String()).To(Equal("-27.30"))
+	g.Expect(act.ConvertedCurrency).To(Equal("CAD"))
+}
@@ -0,0 +1,214 @@
+package output
+
+import (
+	"strings"
+	"time"
+
+	"github.com/samber/lo"
+	"github.com/vpnda/wsfetch/pkg/client"
+	"github.com/vpnda/wsfetch/pkg/client/generated"
+	"github.com/vpnda/wsfetch/pkg/fx"
+	"github.com/vpnda/wsfetch/pkg/money"
+)
+
+// The records below are the schema of the machine-readable formats,
+// fields may be added but are never renamed nor removed.
+
+// Account is an account along with its value. The converted value is in
+// the reporting currency and only set for accounts in another currency.
+type Account struct {
+	Profile             string         `json:"profile" table:"PROFILE"`
+	Id                  string         `json:"id" table:"ACCOUNT"`
+	Type                string         `json:"type" table:"TYPE"`
+	Nickname            string         `json:"nickname" table:"NICKNAME"`
+	Status              string         `json:"status"`
+	NetLiquidationValue money.Decimal  `json:"netLiquidationValue" table:"VALUE"`
+	Currency            string         `json:"currency" table:"CURRENCY"`
+	ConvertedValue      *money.Decimal `json:"convertedValue" table:"CONVERTED"`
+	ConvertedCurrency   string         `json:"convertedCurrency"`
+}
+
+// NewAccount returns the record of an account of a profile
+func NewAccount(profile string, a *generated.AccountWithFinancials) Account {
+	r := Account{
+		Profile:  profile,
+		Id:       a.Id,
+		Type:     lo.FromPtr(a.UnifiedAccountType),
+		Nickname: lo.FromPtr(a.Nickname),
+		Status:   a.Status,
+		Currency: lo.FromPtr(a.Currency),
+	}
+	if value := a.Financials.CurrentCombined; value != nil && value.NetLiquidationValueV2 != nil {
+		r.NetLiquidationValue = value.NetLiquidationValueV2.Amount
+		r.Currency = value.NetLiquidationValueV2.Currency
+	}
+	return r
+}
+
+// Convert sets the value of an account held in another currency than
+// the reporting one converted to it, at the rate of the book closest to at
+func (a *Account) Convert(rates *fx.Book, reporting string, at time.Time) error {
+	if a.Currency == "" || a.Currency == reporting {
+		return nil
+	}
+	converted, err := rates.Convert(money.NewMoney(a.NetLiquidationValue, a.Currency), reporting, at)
+	if err != nil {
+		return err
+	}
+	a.ConvertedValue = &converted.Amount
+	a.ConvertedCurrency = converted.Currency
+	return nil
+}
+
+// Activity is an activity of an account. Amount is signed according to
+// the sign convention chosen, Converted amounts are in the reporting
+// currency and only set for activities in another currency.
+type Activity struct {
+	Profile           string         `json:"profile" table:"PROFILE"`
+	AccountId         string         `json:"accountId" table:"ACCOUNT"`
+	Id                string         `json:"id"`
+	OccurredAt        time.Time      `json:"occurredAt" table:"DATE"`
+	Type              string         `json:"type"`
+	SubType           string         `json:"subType"`
+	Status            string         `json:"status"`
+	Description       string         `json:"description" table:"DESCRIPTION"`
+	Direction         string         `json:"direction"`
+	Amount            money.Decimal  `json:"amount" table:"AMOUNT"`
+	Currency          string         `json:"currency" table:"CURRENCY"`
+	SecurityId        string         `json:"securityId"`
+	Quantity          *money.Decimal `json:"quantity"`
+	ConvertedAmount   *money.Decimal `json:"convertedAmount" table:"CONVERTED"`
+	ConvertedCurrency string         `json:"convertedCurrency"`
+}
+
+// Convert sets the amount of an activity in another currency than the
+// reporting one converted to it, at the rate of the book closest to
+// when it occurred
+func (a *Activity) Convert(rates *fx.Book, reporting string) error {
+	if a.Currency == "" || a.Currency == reporting {
+		return nil
+	}
+	converted, err := rates.Convert(money.NewMoney(a.Amount, a.Currency), reporting, a.OccurredAt)
+	if err != nil {
+		return err
+	}
+	a.ConvertedAmount = &converted.Amount
+	a.ConvertedCurrency = converted.Currency
+	return nil
+}
+
+// Position is the quantity of a security held by an account, priced at
+// its last quote when one is known
+type Position struct {
+	Profile     string         `json:"profile" table:"PROFILE"`
+	AccountId   string         `json:"accountId" table:"ACCOUNT"`
+	SecurityId  string         `json:"securityId"`
+	Symbol      string         `json:"symbol" table:"SYMBOL"`
+	Quantity    money.Decimal  `json:"quantity" table:"QUANTITY"`
+	Price       *money.Decimal `json:"price" table:"PRICE"`
+	MarketValue *money.Decimal `json:"marketValue" table:"VALUE"`
+	Currency    string         `json:"currency" table:"CURRENCY"`
+}
+
+// cashSecurityPrefix starts the security ids Wealthsimple uses for
+// cash balances, followed by the currency
+const cashSecurityPrefix = "sec-c-"
+
+// IsCash tells whether a security id is the one of a cash balance
+func IsCash(securityId string) bool {
+	return strings.HasPrefix(securityId, cashSecurityPrefix)
+}
+
+// NewPosition returns the record of a balance of an account. Cash
+// balances are priced at one unit of their currency, other balances at
+// the last quote of data when it is given.
+func NewPosition(profile, accountId string, quantity money.Decimal, securityId string, data *generated.SecurityMarketData) Position {
+	r := Position{
+		Profile:    profile,
+		AccountId:  accountId,
+		SecurityId: securityId,
+		Symbol:     securityId,
+		Quantity:   quantity,
+	}
+	if IsCash(securityId) {
+		r.Currency = strings.ToUpper(strings.TrimPrefix(securityId, cashSecurityPrefix))
+		r.Symbol = r.Currency
+		r.Price = lo.ToPtr(money.NewFromInt(1))
+		r.MarketValue = lo.ToPtr(quantity)
+		return r
+	}
+	if data == nil {
+		return r
+	}
+	q := NewQuote(data)
+	r.Symbol = q.Symbol
+	r.Currency = q.Currency
+	if data.Quote != nil {
+		r.Price = lo.ToPtr(q.Last)
+		r.MarketValue = lo.ToPtr(quantity.Mul(q.Last).Round(2, money.HalfEven))
+	}
+	return r
+}
+
+// Quote is the last quote of a security
+type Quote struct {
+	SecurityId    string        `json:"securityId"`
+	Symbol        string        `json:"symbol" table:"SYMBOL"`
+	Name          string        `json:"name" table:"NAME"`
+	Currency      string        `json:"currency" table:"CURRENCY"`
+	Last          money.Decimal `json:"last" table:"LAST"`
+	Bid           money.Decimal `json:"bid" table:"BID"`
+	Ask           money.Decimal `json:"ask" table:"ASK"`
+	Open          money.Decimal `json:"open"`
+	High          money.Decimal `json:"high"`
+	Low           money.Decimal `json:"low"`
+	PreviousClose money.Decimal `json:"previousClose" table:"PREVIOUS CLOSE"`
+	Volume        int           `json:"volume" table:"VOLUME"`
+	QuotedAsOf    *time.Time    `json:"quotedAsOf" table:"AS OF"`
+}
+
+// NewQuote returns the record of the market data of a security, the
+// symbol is prefixed with the exchange as activity descriptions do
+func NewQuote(data *generated.SecurityMarketData) Quote {
+	r := Quote{SecurityId: data.Id, Symbol: data.Id}
+	if data.Stock != nil {
+		symbol, _ := client.SecuritySymbolFromMarketData(data)
+		r.Symbol = string(symbol)
+		r.Name = lo.FromPtr(data.Stock.Name)
+	}
+	if data.Fundamentals != nil {
+		r.Currency = data.Fundamentals.Currency
+	}
+	if q := data.Quote; q != nil {
+		r.Last = q.Last
+		r.Bid = q.Bid
+		r.Ask = q.Ask
+		r.Open = q.Open
+		r.High = q.High
+		r.Low = q.Low
+		r.PreviousClose = q.PreviousClose
+		r.Volume = q.Volume
+		r.QuotedAsOf = q.QuotedAsOf
+	}
+	return r
+}
+
+// Conversion is an exchange of currencies made by an activity along
+// with the spread paid, measured against ReferenceRate when one is known.
+// Spread is the share of the value lost, 0.015 for 1.5%, and SpreadCost
+// the amount lost in the bought currency.
+type Conversion struct {
+	Profile        string         `json:"profile" table:"PROFILE"`
+	AccountId      string         `json:"accountId" table:"ACCOUNT"`
+	ActivityId     string         `json:"activityId"`
+	OccurredAt     time.Time      `json:"occurredAt" table:"DATE"`
+	SoldAmount     money.Decimal  `json:"soldAmount" table:"SOLD"`
+	SoldCurrency   string         `json:"soldCurrency" table:"SOLD CURRENCY"`
+	BoughtAmount   money.Decimal  `json:"boughtAmount" table:"BOUGHT"`
+	BoughtCurrency string         `json:"boughtCurrency" table:"BOUGHT CURRENCY"`
+	Rate           money.Decimal  `json:"rate" table:"RATE"`
+	QuotedRate     *money.Decimal `json:"quotedRate"`
+	ReferenceRate  *money.Decimal `json:"referenceRate" table:"REFERENCE"`
+	Spread         *money.Decimal `json:"spread" table:"SPREAD"`
+	SpreadCost     *money.Decimal `json:"spreadCost" table:"COST"`
+}
@@ -0,0 +1,211 @@
+package profile
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+
+	"github.com/vpnda/wsfetch/pkg/auth/types"
+)
+
+const (
+	// DefaultName is the profile used when none is selected
+	DefaultName = "default"
+
+	profileFile = "profile.json"
+	sessionFile = "session.json"
+)
+
+var (
+	// ErrNoSession is returned when the profile has never logged in
+	// or has logged out
+	ErrNoSession = errors.New("no session stored for profile")
+
+	validName = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+)
+
+// Profile holds everything tied to a single Wealthsimple login
+// that must survive across sessions
+type Profile struct {
+	// Name of the profile, also the name of its directory
+	Name string `json:"-"`
+
+	// Username used to log in, used as a default when prompting
+	Username string `json:"username,omitempty"`
+
+	// DeviceId is the WSSID Wealthsimple identifies this device with,
+	// kept per profile so each login looks like its own device
+	DeviceId string `json:"deviceId,omitempty"`
+
+	// OtpClaim is the remember-me claim that lets this profile skip 2FA
+	OtpClaim string `json:"otpClaim,omitempty"`
+
+	// Scopes requested when logging in, the default scopes when empty
+	Scopes []types.Scope `json:"scopes,omitempty"`
+
+	// OAuthProfile is the Wealthsimple profile logged in to
+	OAuthProfile string `json:"oauthProfile,omitempty"`
+
+	// Defaults applied to commands run with this profile
+	Defaults Defaults `json:"defaults"`
+}
+
+// Defaults are per profile command defaults
+type Defaults struct {
+	// Accounts restricts commands to these account IDs, all accounts
+	// are used when empty
+	Accounts []string `json:"accounts,omitempty"`
+
+	// LookbackDays is how far back activities are fetched
+	LookbackDays int `json:"lookbackDays,omitempty"`
+}
+
+// Store keeps profiles on disk, one directory per profile holding the
+// profile and its session
+type Store struct {
+	Dir string
+}
+
+// DefaultStore returns the store under the user configuration directory
+func DefaultStore() (*Store, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return nil, fmt.Errorf("unable to find user config dir: %w", err)
+	}
+	return &Store{Dir: filepath.Join(dir, "wsfetch", "profiles")}, nil
+}
+
+// List returns the names of every stored profile, sorted
+func (s *Store) List() ([]string, error) {
+	entries, err := os.ReadDir(s.Dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, e := range entries {
+		if e.IsDir() && validName.MatchString(e.Name()) {
+			names = append(names, e.Name())
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// Load returns the named profile, an empty profile is returned if it
+// doesn't exist yet
+func (s *Store) Load(name string) (*Profile, error) {
+	if err := ValidateName(name); err != nil {
+		return nil, err
+	}
+
+	p := &Profile{Name: name}
+	err := s.readJSON(name, profileFile, p)
+	if errors.Is(err, os.ErrNotExist) {
+		return p, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load profile %s: %w", name, err)
+	}
+	return p, nil
+}
+
+// Save persists the profile
+func (s *Store) Save(p *Profile) error {
+	if err := ValidateName(p.Name); err != nil {
+		return err
+	}
+	return s.writeJSON(p.Name, profileFile, p)
+}
+
+// LoadSession returns the session stored for the named profile
+func (s *Store) LoadSession(name string) (*types.Session, error) {
+	if err := ValidateName(name); err != nil {
+		return nil, err
+	}
+
+	var sess *types.Session
+	err := s.readJSON(name, sessionFile, &sess)
+	if errors.Is(err, os.ErrNotExist) || (err == nil && sess == nil) {
+		return nil, ErrNoSession
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode session file: %w", err)
+	}
+	return sess, nil
+}
+
+// SaveSession stores the session of the named profile. The device ID
+// and remember-me claim are copied to the profile so they outlive it.
+func (s *Store) SaveSession(name string, sess *types.Session) error {
+	p, err := s.Load(name)
+	if err != nil {
+		return err
+	}
+
+	if sess.WSSID != "" {
+		p.DeviceId = sess.WSSID
+	}
+	if sess.RefreshOtpToken != "" {
+		p.OtpClaim = sess.RefreshOtpToken
+	}
+	if err := s.Save(p); err != nil {
+		return err
+	}
+	return s.writeJSON(name, sessionFile, sess)
+}
+
+// RemoveSession deletes the session of the named profile, the profile
+// itself is kept
+func (s *Store) RemoveSession(name string) error {
+	if err := ValidateName(name); err != nil {
+		return err
+	}
+	err := os.Remove(filepath.Join(s.Dir, name, sessionFile))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove session file: %w", err)
+	}
+	return nil
+}
+
+func (s *Store) readJSON(name, file string, v any) error {
+	f, err := os.Open(filepath.Join(s.Dir, name, file))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return json.NewDecoder(f).Decode(v)
+}
+
+func (s *Store) writeJSON(name, file string, v any) error {
+	dir := filepath.Join(s.Dir, name)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return fmt.Errorf("failed to create profile dir: %w", err)
+	}
+
+	// sessions and claims are secrets, keep them private to the user
+	f, err := os.OpenFile(filepath.Join(dir, file), os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// ValidateName checks a profile name can be used as a directory name
+func ValidateName(name string) error {
+	if !validName.MatchString(name) {
+		return fmt.Errorf("invalid profile name %q", name)
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package profile
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/vpnda/wsfetch/pkg/auth/types"
+)
+
+func Test_Store_Sessions(t *testing.T) {
+	g := NewWithT(t)
+	store := &Store{Dir: t.TempDir()}
+	expiry := time.Now().Add(time.Hour).Round(time.Second)
+
+	_, err := store.LoadSession("alice")
+	g.Expect(err).To(MatchError(ErrNoSession))
+
+	err = store.SaveSession("alice", &types.Session{
+		AccessToken:     "someAccessToken",
+		WSSID:           "someDevice",
+		RefreshOtpToken: "someOtpClaim",
+		Expiry:          &expiry,
+	})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(store.SaveSession("bob", &types.Session{AccessToken: "other"})).To(Succeed())
+
+	sess, err := store.LoadSession("alice")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(sess.AccessToken).To(Equal("someAccessToken"))
+	g.Expect(sess.Expiry.Equal(expiry)).To(BeTrue())
+
+	// logging out keeps the device and remember-me claim around
+	g.Expect(store.RemoveSession("alice")).To(Succeed())
+	_, err = store.LoadSession("alice")
+	g.Expect(err).To(MatchError(ErrNoSession))
+
+	p, err := store.Load("alice")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(p.DeviceId).To(Equal("someDevice"))
+	g.Expect(p.OtpClaim).To(Equal("someOtpClaim"))
+
+	names, err := store.List()
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(names).To(Equal([]string{"alice", "bob"}))
+
+	_, err = store.Load("../escape")
+	g.Expect(err).To(HaveOccurred())
+}
@@ -0,0 +1,121 @@
+// Package ratelimit throttles the requests sent to Wealthsimple so bursts
+// caused by pagination, batch describing or multi-profile fetches don't
+// get our accounts flagged. Limiters are shared by every client of the
+// process, authentication and GraphQL requests have separate budgets.
+package ratelimit
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// Limit is the budget of a limiter, a token bucket refilled at
+// RequestsPerSecond holding at most Burst tokens
+type Limit struct {
+	RequestsPerSecond float64
+	Burst             int
+}
+
+var (
+	// DefaultAuthLimit is the budget of the authentication endpoints,
+	// they are only hit when logging in or refreshing a session
+	DefaultAuthLimit = Limit{RequestsPerSecond: 1, Burst: 3}
+
+	// DefaultGraphQLLimit is the budget of the GraphQL endpoint
+	DefaultGraphQLLimit = Limit{RequestsPerSecond: 5, Burst: 10}
+)
+
+var (
+	// Auth limits the requests sent to the authentication endpoints
+	Auth = NewLimiter("auth", DefaultAuthLimit)
+
+	// GraphQL limits the requests sent to the GraphQL endpoint
+	GraphQL = NewLimiter("graphql", DefaultGraphQLLimit)
+)
+
+// Stats tells how long requests waited for a token
+type Stats struct {
+	Requests  int64
+	Throttled int64
+	TotalWait time.Duration
+	MaxWait   time.Duration
+}
+
+// Limiter is a token bucket safe for concurrent use
+type Limiter struct {
+	Name string
+
+	limiter *rate.Limiter
+
+	mu    sync.Mutex
+	stats Stats
+}
+
+// NewLimiter creates a limiter with the given budget, a non positive
+// RequestsPerSecond disables the limit
+func NewLimiter(name string, l Limit) *Limiter {
+	limit, burst := l.rate()
+	return &Limiter{Name: name, limiter: rate.NewLimiter(limit, burst)}
+}
+
+// SetLimit changes the budget of the limiter
+func (l *Limiter) SetLimit(limit Limit) {
+	r, burst := limit.rate()
+	l.limiter.SetLimit(r)
+	l.limiter.SetBurst(burst)
+}
+
+func (l Limit) rate() (rate.Limit, int) {
+	if l.RequestsPerSecond <= 0 {
+		return rate.Inf, 0
+	}
+	return rate.Limit(l.RequestsPerSecond), max(l.Burst, 1)
+}
+
+// Wait blocks until a request can be sent or ctx is done
+func (l *Limiter) Wait(ctx context.Context) error {
+	start := time.Now()
+	err := l.limiter.Wait(ctx)
+	waited := time.Since(start)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.stats.Requests++
+	l.stats.TotalWait += waited
+	l.stats.MaxWait = max(l.stats.MaxWait, waited)
+	if waited >= time.Millisecond {
+		l.stats.Throttled++
+	}
+	return err
+}
+
+// Stats returns how long requests waited on the limiter so far
+func (l *Limiter) Stats() Stats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.stats
+}
+
+// Transport waits on the limiter picked by pick before sending each
+// request through next, a nil limiter lets the request through
+func Transport(next http.RoundTripper, pick func(*http.Request) *Limiter) http.RoundTripper {
+	return &transport{next: next, pick: pick}
+}
+
+type transport struct {
+	next http.RoundTripper
+	pick func(*http.Request) *Limiter
+}
+
+func (t *transport) RoundTrip(r *http.Request) (*http.Response, error) {
+	if l := t.pick(r); l != nil {
+		if err := l.Wait(r.Context()); err != nil {
+			return nil, err
+		}
+	}
+	return t.next.RoundTrip(r)
+}
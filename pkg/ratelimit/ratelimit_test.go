@@ -0,0 +1,86 @@
+package ratelimit
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/vpnda/wsfetch/internal/httputil"
+)
+
+func Test_Limiter(t *testing.T) {
+	testCases := []struct {
+		name              string
+		limit             Limit
+		requests          int
+		expectedThrottled int64
+	}{
+		{
+			name:     "burst goes through",
+			limit:    Limit{RequestsPerSecond: 20, Burst: 3},
+			requests: 3,
+		},
+		{
+			name:              "requests past the burst wait",
+			limit:             Limit{RequestsPerSecond: 20, Burst: 1},
+			requests:          3,
+			expectedThrottled: 2,
+		},
+		{
+			name:     "disabled limiter",
+			limit:    Limit{},
+			requests: 50,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			l := NewLimiter("test", tc.limit)
+			for i := 0; i < tc.requests; i++ {
+				g.Expect(l.Wait(context.Background())).To(Succeed())
+			}
+
+			stats := l.Stats()
+			g.Expect(stats.Requests).To(BeEquivalentTo(tc.requests))
+			g.Expect(stats.Throttled).To(Equal(tc.expectedThrottled))
+			if tc.expectedThrottled > 0 {
+				g.Expect(stats.MaxWait).To(BeNumerically(">", 10*time.Millisecond))
+			}
+		})
+	}
+}
+
+func Test_Transport(t *testing.T) {
+	g := NewWithT(t)
+	auth := NewLimiter("auth", Limit{RequestsPerSecond: 1, Burst: 1})
+	graphql := NewLimiter("graphql", Limit{RequestsPerSecond: 1, Burst: 1})
+
+	rt := Transport(httputil.RoundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+	}), func(r *http.Request) *Limiter {
+		if r.URL.Path == "/graphql" {
+			return graphql
+		}
+		return auth
+	})
+
+	for _, path := range []string{"/graphql", "/v1/oauth/v2/token"} {
+		req, err := http.NewRequest(http.MethodPost, "https://example.com"+path, http.NoBody)
+		g.Expect(err).ToNot(HaveOccurred())
+		_, err = rt.RoundTrip(req)
+		g.Expect(err).ToNot(HaveOccurred())
+	}
+	g.Expect(auth.Stats().Requests).To(BeEquivalentTo(1))
+	g.Expect(graphql.Stats().Requests).To(BeEquivalentTo(1))
+
+	// the budget is exhausted, a cancelled request gives up waiting
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "https://example.com/graphql", http.NoBody)
+	g.Expect(err).ToNot(HaveOccurred())
+	_, err = rt.RoundTrip(req)
+	g.Expect(err).To(HaveOccurred())
+}
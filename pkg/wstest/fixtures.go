@@ -0,0 +1,39 @@
+package wstest
+
+import (
+	_ "embed"
+	"encoding/json"
+
+	"github.com/samber/lo"
+	"github.com/vpnda/wsfetch/pkg/client/generated"
+)
+
+//go:embed fixtures/default.json
+var defaultFixtures []byte
+
+// Fixtures are the data served over GraphQL
+type Fixtures struct {
+	Accounts   []generated.AccountWithFinancials `json:"accounts"`
+	Activities []generated.Activity              `json:"activities"`
+	Securities []generated.SecurityMarketData    `json:"securities"`
+}
+
+// DefaultFixtures returns a TFSA and an RRSP along with a week of
+// activities, most of them in the TFSA, and the security they traded
+func DefaultFixtures() Fixtures {
+	var f Fixtures
+	lo.Must0(json.Unmarshal(defaultFixtures, &f))
+	return f
+}
+
+// Account returns the account with the ID, tests changing what they need
+// of it. It panics when there is none.
+func (f Fixtures) Account(id string) generated.AccountWithFinancials {
+	return lo.Must(lo.Find(f.Accounts, func(a generated.AccountWithFinancials) bool { return a.Id == id }))
+}
+
+// Activity returns the activity with the canonical ID, tests changing
+// what they need of it. It panics when there is none.
+func (f Fixtures) Activity(canonicalId string) generated.Activity {
+	return lo.Must(lo.Find(f.Activities, func(a generated.Activity) bool { return lo.FromPtr(a.CanonicalId) == canonicalId }))
+}
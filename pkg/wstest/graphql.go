@@ -0,0 +1,232 @@
+package wstest
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/samber/lo"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"github.com/vpnda/wsfetch/pkg/client/generated"
+)
+
+// defaultPageSize is used when queries don't ask for a page size
+const defaultPageSize = 25
+
+type graphqlRequest struct {
+	OperationName string          `json:"operationName"`
+	Query         string          `json:"query"`
+	Variables     json.RawMessage `json:"variables"`
+}
+
+// handleGraphQL answers the operations of the generated client with the
+// fixtures, unknown operations get a GraphQL error
+func (s *Server) handleGraphQL(w http.ResponseWriter, r *http.Request) {
+	if !s.authorized(r) {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	var req graphqlRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeGraphQLError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var (
+		data any
+		err  error
+	)
+	switch req.OperationName {
+	case "FetchAllAccountFinancials":
+		data, err = s.accountFinancials(req.Variables)
+	case "FetchActivityFeedItems":
+		data, err = s.activityFeedItems(req.Variables)
+	case "FetchSecurityMarketData":
+		data, err = s.securityMarketData(req.Variables)
+	default:
+		writeGraphQLError(w, http.StatusOK, "unknown operation "+req.OperationName)
+		return
+	}
+	if err != nil {
+		writeGraphQLError(w, http.StatusOK, err.Error())
+		return
+	}
+	writeJSON(w, map[string]any{"data": data})
+}
+
+func (s *Server) accountFinancials(raw json.RawMessage) (any, error) {
+	var vars struct {
+		IdentityId string  `json:"identityId"`
+		PageSize   *int    `json:"pageSize"`
+		Cursor     *string `json:"cursor"`
+	}
+	if err := json.Unmarshal(raw, &vars); err != nil {
+		return nil, err
+	}
+	if vars.IdentityId != s.IdentityId {
+		return nil, gqlerror.Errorf("identity %s not found", vars.IdentityId)
+	}
+
+	start, end, err := page(len(s.Fixtures.Accounts), vars.PageSize, vars.Cursor)
+	if err != nil {
+		return nil, err
+	}
+	conn := &generated.AllAccountFinancialsAccountsAccountConnection{
+		PageInfo: generated.AllAccountFinancialsAccountsAccountConnectionPageInfo{
+			HasNextPage: end < len(s.Fixtures.Accounts),
+			EndCursor:   cursor(end - 1),
+		},
+		Edges: []generated.AllAccountFinancialsAccountsAccountConnectionEdgesAccountEdge{},
+	}
+	for i, a := range s.Fixtures.Accounts[start:end] {
+		conn.Edges = append(conn.Edges, generated.AllAccountFinancialsAccountsAccountConnectionEdgesAccountEdge{
+			Cursor: cursor(start + i),
+			Node: generated.AllAccountFinancialsAccountsAccountConnectionEdgesAccountEdgeNodeAccount{
+				AccountWithFinancials: a,
+			},
+		})
+	}
+	return &generated.FetchAllAccountFinancialsResponse{
+		Identity: generated.FetchAllAccountFinancialsIdentity{
+			Id:                   s.IdentityId,
+			AllAccountFinancials: generated.AllAccountFinancials{Accounts: conn},
+		},
+	}, nil
+}
+
+func (s *Server) activityFeedItems(raw json.RawMessage) (any, error) {
+	var vars struct {
+		First     *int                          `json:"first"`
+		Cursor    *string                       `json:"cursor"`
+		Condition *generated.ActivityCondition  `json:"condition"`
+		OrderBy   []generated.ActivitiesOrderBy `json:"orderBy"`
+	}
+	if err := json.Unmarshal(raw, &vars); err != nil {
+		return nil, err
+	}
+
+	activities := lo.Filter(s.Fixtures.Activities, func(a generated.Activity, _ int) bool {
+		return matches(vars.Condition, a)
+	})
+	ascending := lo.Contains(vars.OrderBy, generated.ActivitiesOrderByOccurredAtAsc)
+	sort.SliceStable(activities, func(i, j int) bool {
+		ti, tj := lo.FromPtr(activities[i].OccurredAt), lo.FromPtr(activities[j].OccurredAt)
+		if ascending {
+			return ti.Before(tj)
+		}
+		return ti.After(tj)
+	})
+
+	start, end, err := page(len(activities), vars.First, vars.Cursor)
+	if err != nil {
+		return nil, err
+	}
+	conn := &generated.FetchActivityFeedItemsActivityFeedItemsActivityFeedItemConnection{
+		PageInfo: generated.FetchActivityFeedItemsActivityFeedItemsActivityFeedItemConnectionPageInfo{
+			HasNextPage: end < len(activities),
+			EndCursor:   cursor(end - 1),
+		},
+		Edges: []generated.FetchActivityFeedItemsActivityFeedItemsActivityFeedItemConnectionEdgesActivityFeedItemEdge{},
+	}
+	for _, a := range activities[start:end] {
+		conn.Edges = append(conn.Edges, generated.FetchActivityFeedItemsActivityFeedItemsActivityFeedItemConnectionEdgesActivityFeedItemEdge{
+			Node: generated.FetchActivityFeedItemsActivityFeedItemsActivityFeedItemConnectionEdgesActivityFeedItemEdgeNodeActivityFeedItem{
+				Activity: a,
+			},
+		})
+	}
+	return &generated.FetchActivityFeedItemsResponse{ActivityFeedItems: conn}, nil
+}
+
+// matches reports whether the activity satisfies the condition, the
+// date range is inclusive
+func matches(c *generated.ActivityCondition, a generated.Activity) bool {
+	if c == nil {
+		return true
+	}
+	if len(c.AccountIds) != 0 && !lo.Contains(c.AccountIds, a.AccountId) {
+		return false
+	}
+	if len(c.Types) != 0 && !lo.Contains(c.Types, a.Type) {
+		return false
+	}
+	occurredAt := lo.FromPtr(a.OccurredAt)
+	if c.StartDate != nil && occurredAt.Before(*c.StartDate) {
+		return false
+	}
+	if c.EndDate != nil && occurredAt.After(*c.EndDate) {
+		return false
+	}
+	return true
+}
+
+func (s *Server) securityMarketData(raw json.RawMessage) (any, error) {
+	var vars struct {
+		Id string `json:"id"`
+	}
+	if err := json.Unmarshal(raw, &vars); err != nil {
+		return nil, err
+	}
+	security, ok := lo.Find(s.Fixtures.Securities, func(sec generated.SecurityMarketData) bool {
+		return sec.Id == vars.Id
+	})
+	if !ok {
+		return nil, gqlerror.Errorf("security %s not found", vars.Id)
+	}
+	return &generated.FetchSecurityMarketDataResponse{
+		Security: generated.FetchSecurityMarketDataSecurity{
+			Id:                 security.Id,
+			SecurityMarketData: security,
+		},
+	}, nil
+}
+
+// page returns the bounds of the page of size items following cursor
+// in a list of n items
+func page(n int, size *int, after *string) (int, int, error) {
+	start := 0
+	if after != nil && *after != "" {
+		i, err := parseCursor(*after)
+		if err != nil {
+			return 0, 0, err
+		}
+		start = min(i+1, n)
+	}
+	pageSize := defaultPageSize
+	if size != nil && *size > 0 {
+		pageSize = *size
+	}
+	return start, min(start+pageSize, n), nil
+}
+
+// cursor returns the opaque cursor of the item at index i
+func cursor(i int) string {
+	if i < 0 {
+		return ""
+	}
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.Itoa(i)))
+}
+
+func parseCursor(c string) (int, error) {
+	bits, err := base64.RawURLEncoding.DecodeString(c)
+	if err != nil {
+		return 0, gqlerror.Errorf("invalid cursor %q", c)
+	}
+	i, err := strconv.Atoi(string(bits))
+	if err != nil || i < 0 {
+		return 0, gqlerror.Errorf("invalid cursor %q", c)
+	}
+	return i, nil
+}
+
+func writeGraphQLError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"errors": gqlerror.List{gqlerror.Errorf("%s", message)},
+		"data":   nil,
+	})
+}
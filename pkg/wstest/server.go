@@ -0,0 +1,402 @@
+// Package wstest runs an in-process fake of Wealthsimple so code built on
+// base.Wealthsimple and client.Client can be tested end to end, 2FA and
+// pagination included, without reaching production.
+//
+// The server implements the login page and app bundle the client id is
+// discovered from, the OAuth token, token info and revoke endpoints, web
+// sessions and a GraphQL endpoint serving the seeded Fixtures. Point the
+// clients at it with Endpoints:
+//
+//	srv := wstest.NewServer(wstest.WithTwoFactor("123456"))
+//	defer srv.Close()
+//	ws := base.DefaultAuthClient(srv.Credentials(),
+//		base.WithEndpoints(srv.Endpoints()),
+//		base.WithAuthenticatorOptions(authenticator.WithCodeFetcher(srv.CodeFetcher())))
+package wstest
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vpnda/wsfetch/pkg/auth/types"
+	"github.com/vpnda/wsfetch/pkg/endpoints"
+)
+
+const (
+	// DefaultUsername and DefaultPassword are accepted by the password grant
+	DefaultUsername = "someone@example.com"
+	DefaultPassword = "somePassword"
+
+	// DefaultClientId is served in the app bundle
+	DefaultClientId = "4da53ac2b03225bed1550eba8e4611e086c7b905a3855e6ed12ea08c246758fa"
+
+	// DefaultDeviceId is set in the wssdi cookie of the login page
+	DefaultDeviceId = "wstest-device"
+
+	// DefaultIdentityId and DefaultUserId identify the fake user
+	DefaultIdentityId = "identity-wstest"
+	DefaultUserId     = "user-wstest"
+
+	// appBundlePath is where the app bundle holding the client id is served
+	appBundlePath = "/assets/app-0123abcd.js"
+)
+
+// Server is a fake Wealthsimple, it is safe for concurrent use
+type Server struct {
+	*httptest.Server
+
+	// Username and Password are accepted by the password grant
+	Username string
+	Password string
+
+	// OTPCode, when set, is required to complete a password grant,
+	// unless the device presents a remembered OTP claim
+	OTPCode string
+
+	ClientId   string
+	DeviceId   string
+	IdentityId string
+	UserId     string
+
+	// TokenTTL is how long access tokens are valid
+	TokenTTL time.Duration
+
+	Fixtures Fixtures
+
+	mu            sync.Mutex
+	accessTokens  map[string]time.Time
+	refreshTokens map[string]bool
+	otpClaims     map[string]bool
+	requests      map[string]int
+}
+
+// Option configures the server
+type Option func(*Server)
+
+// WithCredentials sets the username and password the server accepts
+func WithCredentials(username, password string) Option {
+	return func(s *Server) {
+		s.Username = username
+		s.Password = password
+	}
+}
+
+// WithTwoFactor requires code to complete password grants
+func WithTwoFactor(code string) Option {
+	return func(s *Server) {
+		s.OTPCode = code
+	}
+}
+
+// WithFixtures sets the accounts, activities and securities
+// served over GraphQL, defaults to DefaultFixtures
+func WithFixtures(f Fixtures) Option {
+	return func(s *Server) {
+		s.Fixtures = f
+	}
+}
+
+// WithTokenTTL sets how long access tokens are valid, defaults to 30 minutes
+func WithTokenTTL(ttl time.Duration) Option {
+	return func(s *Server) {
+		s.TokenTTL = ttl
+	}
+}
+
+// NewServer starts a fake Wealthsimple, it must be closed once done
+func NewServer(opts ...Option) *Server {
+	s := &Server{
+		Username:      DefaultUsername,
+		Password:      DefaultPassword,
+		ClientId:      DefaultClientId,
+		DeviceId:      DefaultDeviceId,
+		IdentityId:    DefaultIdentityId,
+		UserId:        DefaultUserId,
+		TokenTTL:      30 * time.Minute,
+		Fixtures:      DefaultFixtures(),
+		accessTokens:  map[string]time.Time{},
+		refreshTokens: map[string]bool{},
+		otpClaims:     map[string]bool{},
+		requests:      map[string]int{},
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(endpoints.MyWealthsimpleLoginSplash.Path, s.handleLoginPage)
+	mux.HandleFunc(appBundlePath, s.handleAppBundle)
+	mux.HandleFunc(endpoints.AuthToken.Path, s.handleToken)
+	mux.HandleFunc(endpoints.AuthTokenInfo.Path, s.handleTokenInfo)
+	mux.HandleFunc(endpoints.AuthRevoke.Path, s.handleRevoke)
+	mux.HandleFunc(endpoints.MyWealthsimpleSession.Path, s.handleSession)
+	mux.HandleFunc(endpoints.MyWealthsimpleGetGraphQl.Path, s.handleGraphQL)
+	s.Server = httptest.NewServer(s.countRequests(mux))
+	return s
+}
+
+// Endpoints sends every route to the server
+func (s *Server) Endpoints() endpoints.Config {
+	return endpoints.ForBase(&url.URL{Scheme: "http", Host: s.Listener.Addr().String()})
+}
+
+// Credentials returns the credentials the server accepts
+func (s *Server) Credentials() types.PasswordCredentials {
+	return types.PasswordCredentials{Username: s.Username, Password: s.Password}
+}
+
+// CodeFetcher answers 2FA challenges with the expected code
+func (s *Server) CodeFetcher() types.TwoFactorCodeFetcher {
+	return types.TwoFactorCodeFetcherFunc(func(types.TwoFactorAuthRequest) (string, error) {
+		return s.OTPCode, nil
+	})
+}
+
+// Requests returns how many requests were received for the route
+func (s *Server) Requests(r endpoints.Route) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests[r.Path]
+}
+
+// ExpireAccessTokens invalidates every access token issued so far,
+// forcing clients to refresh their session
+func (s *Server) ExpireAccessTokens() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.accessTokens = map[string]time.Time{}
+}
+
+func (s *Server) countRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.requests[r.URL.Path]++
+		s.mu.Unlock()
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) handleLoginPage(w http.ResponseWriter, r *http.Request) {
+	http.SetCookie(w, &http.Cookie{Name: "wssdi", Value: s.DeviceId, Path: "/"})
+	w.Header().Set("Content-Type", "text/html")
+	fmt.Fprintf(w, `<html><head><script src="%s"></script></head><body></body></html>`, appBundlePath)
+}
+
+func (s *Server) handleAppBundle(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/javascript")
+	fmt.Fprintf(w, `var config={development:{clientId:"0000"},production:{clientId:"%s"}};`, s.ClientId)
+}
+
+func (s *Server) handleToken(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		GrantType    string `json:"grant_type"`
+		Username     string `json:"username"`
+		Password     string `json:"password"`
+		RefreshToken string `json:"refresh_token"`
+		ClientId     string `json:"client_id"`
+		Scope        string `json:"scope"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeOAuthError(w, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+	if req.ClientId != s.ClientId {
+		writeOAuthError(w, http.StatusUnauthorized, "invalid_client", "unknown client")
+		return
+	}
+
+	switch req.GrantType {
+	case "password":
+		if req.Username != s.Username || req.Password != s.Password {
+			writeOAuthError(w, http.StatusUnauthorized, "invalid_grant", "invalid username or password")
+			return
+		}
+		if !s.checkTwoFactor(w, r) {
+			return
+		}
+	case "refresh_token":
+		s.mu.Lock()
+		valid := s.refreshTokens[req.RefreshToken]
+		delete(s.refreshTokens, req.RefreshToken)
+		s.mu.Unlock()
+		if !valid {
+			writeOAuthError(w, http.StatusUnauthorized, "invalid_grant", "refresh token is invalid")
+			return
+		}
+	default:
+		writeOAuthError(w, http.StatusBadRequest, "unsupported_grant_type", req.GrantType)
+		return
+	}
+
+	scope := req.Scope
+	if scope == "" {
+		scope = types.FormatScopes(types.DefaultScopes)
+	}
+	writeJSON(w, s.issueToken(scope))
+}
+
+// checkTwoFactor challenges password grants when 2FA is enabled, it
+// writes the response and returns false unless the grant can go on
+func (s *Server) checkTwoFactor(w http.ResponseWriter, r *http.Request) bool {
+	if s.OTPCode == "" {
+		return true
+	}
+
+	s.mu.Lock()
+	remembered := s.otpClaims[r.Header.Get("x-wealthsimple-otp-claim")]
+	s.mu.Unlock()
+	if remembered {
+		return true
+	}
+
+	otp := r.Header.Get("x-wealthsimple-otp")
+	if otp == "" {
+		w.Header().Set("x-wealthsimple-otp-required", "true")
+		w.Header().Set("x-wealthsimple-otp-authenticated-claim", randomToken())
+		w.Header().Set("x-wealthsimple-otp", "required; method=sms")
+		writeOAuthError(w, http.StatusUnauthorized, "invalid_grant", "2FA required")
+		return false
+	}
+
+	code, params, _ := strings.Cut(otp, ";")
+	if r.Header.Get("x-wealthsimple-otp-authenticated-claim") == "" || strings.TrimSpace(code) != s.OTPCode {
+		writeOAuthError(w, http.StatusUnauthorized, "invalid_grant", "invalid 2FA code")
+		return false
+	}
+	if strings.TrimSpace(params) == "remember=true" {
+		claim := randomToken()
+		s.mu.Lock()
+		s.otpClaims[claim] = true
+		s.mu.Unlock()
+		w.Header().Set("x-wealthsimple-otp-claim", claim)
+	}
+	return true
+}
+
+// issueToken creates a session, its access token is an unsigned JWT
+// so clients can read its claims
+func (s *Server) issueToken(scope string) map[string]any {
+	now := time.Now()
+	claims, _ := json.Marshal(map[string]any{
+		"sub":                   s.IdentityId,
+		"identity_canonical_id": s.IdentityId,
+		"user_canonical_id":     s.UserId,
+		"iss":                   "wstest",
+		"iat":                   now.Unix(),
+		"exp":                   now.Add(s.TokenTTL).Unix(),
+		"scope":                 scope,
+		"jti":                   randomToken(),
+		"profiles":              s.profiles(),
+	})
+	accessToken := strings.Join([]string{
+		base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none","typ":"JWT"}`)),
+		base64.RawURLEncoding.EncodeToString(claims),
+		"",
+	}, ".")
+	refreshToken := randomToken()
+
+	s.mu.Lock()
+	s.accessTokens[accessToken] = now.Add(s.TokenTTL)
+	s.refreshTokens[refreshToken] = true
+	s.mu.Unlock()
+
+	return map[string]any{
+		"access_token":  accessToken,
+		"refresh_token": refreshToken,
+		"token_type":    "Bearer",
+		"expires_in":    int(s.TokenTTL.Seconds()),
+		"scope":         scope,
+		"created_at":    now.Unix(),
+	}
+}
+
+func (s *Server) handleTokenInfo(w http.ResponseWriter, r *http.Request) {
+	if !s.authorized(r) {
+		writeOAuthError(w, http.StatusUnauthorized, "invalid_token", "access token is invalid")
+		return
+	}
+	writeJSON(w, map[string]any{
+		"identity_canonical_id": s.IdentityId,
+		"user_canonical_id":     s.UserId,
+		"profiles":              s.profiles(),
+	})
+}
+
+// profiles are the client ids of the profiles of the user, given by
+// the token information and the claims of access tokens alike
+func (s *Server) profiles() map[string]any {
+	return map[string]any{
+		"invest": map[string]string{"default": s.UserId + "-invest"},
+		"trade":  map[string]string{"default": s.UserId + "-trade"},
+	}
+}
+
+func (s *Server) handleRevoke(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Token string `json:"token"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeOAuthError(w, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+	s.mu.Lock()
+	delete(s.refreshTokens, req.Token)
+	s.mu.Unlock()
+	writeJSON(w, map[string]any{})
+}
+
+func (s *Server) handleSession(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Session struct {
+			AccessToken string `json:"access_token"`
+		} `json:"session"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || !s.validAccessToken(req.Session.AccessToken) {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	http.SetCookie(w, &http.Cookie{Name: "_session_id", Value: randomToken(), Path: "/"})
+	writeJSON(w, map[string]any{})
+}
+
+// authorized checks the bearer token of r
+func (s *Server) authorized(r *http.Request) bool {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && s.validAccessToken(token)
+}
+
+func (s *Server) validAccessToken(token string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	expiry, ok := s.accessTokens[token]
+	return ok && time.Now().Before(expiry)
+}
+
+func writeOAuthError(w http.ResponseWriter, status int, code, description string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]string{
+		"error":             code,
+		"error_description": description,
+	})
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func randomToken() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
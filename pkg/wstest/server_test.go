@@ -0,0 +1,119 @@
+package wstest
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/samber/lo"
+	"github.com/vpnda/wsfetch/pkg/auth/authenticator"
+	"github.com/vpnda/wsfetch/pkg/auth/types"
+	"github.com/vpnda/wsfetch/pkg/base"
+	"github.com/vpnda/wsfetch/pkg/client"
+	"github.com/vpnda/wsfetch/pkg/client/generated"
+	"github.com/vpnda/wsfetch/pkg/endpoints"
+)
+
+func newTestClient(srv *Server) *base.Wealthsimple {
+	return newTestClientWithCredentials(srv, srv.Credentials())
+}
+
+func newTestClientWithCredentials(srv *Server, pc types.PasswordCredentials) *base.Wealthsimple {
+	return base.DefaultAuthClient(pc,
+		base.WithEndpoints(srv.Endpoints()),
+		base.WithRateLimiters(nil, nil),
+		base.WithRetryPolicy(base.NoRetry),
+		base.WithAuthenticatorOptions(authenticator.WithCodeFetcher(srv.CodeFetcher())),
+	)
+}
+
+func Test_Server_EndToEnd(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	srv := NewServer(WithTwoFactor("123456"))
+	defer srv.Close()
+
+	c, err := client.NewClient(ctx, newTestClient(srv))
+	g.Expect(err).ToNot(HaveOccurred())
+
+	accounts, err := c.GetAccounts(ctx)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(lo.Map(accounts, func(a generated.AccountWithFinancials, _ int) string { return a.Id })).
+		To(Equal([]string{"tfsa-abc123", "rrsp-def456"}))
+
+	activities, err := c.GetActivities(ctx, []client.AccountId{"tfsa-abc123"}, nil, nil)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(activities).To(HaveLen(1))
+	g.Expect(activities["tfsa-abc123"]).To(HaveLen(6))
+
+	security, err := c.GetSecurityMarketData(ctx, "sec-s-aapl")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(security.Id).To(Equal("sec-s-aapl"))
+
+	g.Expect(srv.Requests(endpoints.AuthToken)).To(Equal(2), "challenged then authenticated with the code")
+	g.Expect(srv.Requests(endpoints.MyWealthsimpleSession)).To(Equal(1))
+	g.Expect(srv.Requests(endpoints.AuthTokenInfo)).To(BeZero(), "identity read from the token claims")
+}
+
+func Test_Server_RefreshesExpiredSessions(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	srv := NewServer()
+	defer srv.Close()
+
+	c, err := client.NewClient(ctx, newTestClient(srv))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(srv.Requests(endpoints.AuthToken)).To(Equal(1))
+
+	srv.ExpireAccessTokens()
+	_, err = c.GetAccounts(ctx)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(srv.Requests(endpoints.AuthToken)).To(Equal(2))
+}
+
+func Test_Server_RejectsInvalidCredentials(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	srv := NewServer(WithCredentials("someone@example.com", "anotherPassword"))
+	defer srv.Close()
+
+	_, err := client.NewClient(ctx, newTestClientWithCredentials(srv, types.PasswordCredentials{
+		Username: "someone@example.com",
+		Password: DefaultPassword,
+	}))
+	g.Expect(err).To(MatchError(authenticator.ErrInvalidCredentials))
+}
+
+func Test_Server_Pagination(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	// more TFSA activities than the client fetches per page
+	f := DefaultFixtures()
+	for i := range 30 {
+		act := f.Activity("act-6")
+		act.CanonicalId = lo.ToPtr(fmt.Sprintf("act-6-%02d", i))
+		act.OccurredAt = lo.ToPtr(act.OccurredAt.Add(-time.Duration(i+1) * time.Minute))
+		f.Activities = append(f.Activities, act)
+	}
+	srv := NewServer(WithFixtures(f))
+	defer srv.Close()
+
+	c, err := client.NewClient(ctx, newTestClient(srv))
+	g.Expect(err).ToNot(HaveOccurred())
+	from := time.Date(2024, 4, 27, 0, 0, 0, 0, time.UTC)
+	activities, err := c.GetActivities(ctx, []client.AccountId{"tfsa-abc123"}, &from, nil)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	// newest first across pages
+	ids := lo.Map(activities["tfsa-abc123"], func(a generated.Activity, _ int) string { return lo.FromPtr(a.CanonicalId) })
+	g.Expect(ids).To(HaveLen(6 + 30))
+	g.Expect(ids[:6]).To(Equal([]string{"act-1", "act-2", "act-3", "act-4", "act-5", "act-6"}))
+	g.Expect(ids[len(ids)-1]).To(Equal("act-6-29"))
+	g.Expect(srv.Requests(endpoints.MyWealthsimpleGetGraphQl)).To(Equal(2))
+}